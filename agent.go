@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// runAgent serves the local configs (the --cnf files and --dsn servers of
+// this host) as snapshots, so a central differ can read them with --agent
+// without MySQL credentials or network access to every server.
+// The configs are read again on every request.
+func runAgent(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if opts.AgentToken == "" {
+		return "", errors.New("The agent requires --agent-token")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/snapshot", newAgentHandler(opts.AgentToken, loadConfigs))
+
+	server := &http.Server{Addr: opts.Listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	log.Printf("Serving config snapshots on %s", opts.Listen)
+	var err error
+	if opts.TLSCert != "" {
+		err = server.ListenAndServeTLS(opts.TLSCert, opts.TLSKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return "", nil
+	}
+
+	return "", err
+}
+
+func newAgentHandler(token string, loadConfigs configLoader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		configs, err := loadConfigs(r.Context())
+		if err != nil {
+			log.Printf("Cannot get configs: %s", err.Error())
+			http.Error(w, "Cannot get configs", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now().UTC()
+		snapshots := make([]snapshot, 0, len(configs))
+		for _, cfg := range configs {
+			snapshots = append(snapshots, newSnapshot(cfg, now))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshots)
+	})
+}
+
+// getAgents reads the snapshots served by every --agent
+func getAgents(ctx context.Context, opts *options, client *http.Client) ([]configReader, error) {
+	var configs []configReader
+
+	for _, url := range opts.Agents {
+		req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(url, "/")+"/snapshot", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+opts.AgentToken)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the agent %s: %s", url, err.Error())
+		}
+
+		var snapshots []snapshot
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("%s", resp.Status)
+		} else {
+			err = json.NewDecoder(resp.Body).Decode(&snapshots)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the agent %s: %s", url, err.Error())
+		}
+
+		for _, s := range snapshots {
+			configs = append(configs, s.config())
+		}
+	}
+
+	return configs, nil
+}
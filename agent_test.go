@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAgent(t *testing.T) {
+	cnf := &config{
+		configType: "cnf",
+		name:       "/etc/my.cnf",
+		entries:    map[string]interface{}{"key_buffer_size": "1G"},
+		origins:    map[string]entryOrigin{"key_buffer_size": {File: "/etc/my.cnf", Section: "mysqld", Line: 3}},
+	}
+	mysql := &config{
+		configType: "mysql",
+		name:       "localhost",
+		entries:    map[string]interface{}{"key_buffer_size": []byte("1073741824")},
+	}
+
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf, mysql}, nil
+	}
+	server := httptest.NewServer(newAgentHandler("secret", loadConfigs))
+	defer server.Close()
+
+	if _, err := getAgents(context.Background(), &options{Agents: []string{server.URL}, AgentToken: "wrong"}, server.Client()); err == nil {
+		t.Error("Should return error on invalid tokens")
+	}
+
+	configs, err := getAgents(context.Background(), &options{Agents: []string{server.URL}, AgentToken: "secret"}, server.Client())
+	if err != nil {
+		t.Fatalf("Shouldn't return error reading the agent: %s", err.Error())
+	}
+	if len(configs) != 2 {
+		t.Fatalf("There must be 2 configs, got %d", len(configs))
+	}
+
+	mysql.entries["key_buffer_size"] = "1073741824"
+	for i, want := range []*config{cnf, mysql} {
+		if !reflect.DeepEqual(configs[i], want) {
+			t.Errorf("Got:\n%#v\nWant:\n%#v\n", configs[i], want)
+		}
+	}
+}
+
+func TestAgentUnauthorized(t *testing.T) {
+	handler := newAgentHandler("secret", func(ctx context.Context) ([]configReader, error) { return nil, nil })
+
+	req := httptest.NewRequest("GET", "/snapshot", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Got status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+// variableAliases maps the old names of the renamed variables to their
+// current names. Servers of different versions (and their cnf files) report
+// the variable with one name or the other, so they are compared by the
+// current name instead of being reported as missing in both.
+var variableAliases = map[string]string{
+	"init_slave":                                "init_replica",
+	"key_buffer":                                "key_buffer_size",
+	"log_bin_trust_routine_creators":            "log_bin_trust_function_creators",
+	"log_slave_updates":                         "log_replica_updates",
+	"log_slow_slave_statements":                 "log_slow_replica_statements",
+	"master_verify_checksum":                    "source_verify_checksum",
+	"rpl_semi_sync_master_enabled":              "rpl_semi_sync_source_enabled",
+	"rpl_semi_sync_master_timeout":              "rpl_semi_sync_source_timeout",
+	"rpl_semi_sync_master_wait_for_slave_count": "rpl_semi_sync_source_wait_for_replica_count",
+	"rpl_semi_sync_master_wait_point":           "rpl_semi_sync_source_wait_point",
+	"rpl_semi_sync_slave_enabled":               "rpl_semi_sync_replica_enabled",
+	"rpl_stop_slave_timeout":                    "rpl_stop_replica_timeout",
+	"skip_slave_start":                          "skip_replica_start",
+	"slave_allow_batching":                      "replica_allow_batching",
+	"slave_checkpoint_group":                    "replica_checkpoint_group",
+	"slave_checkpoint_period":                   "replica_checkpoint_period",
+	"slave_compressed_protocol":                 "replica_compressed_protocol",
+	"slave_exec_mode":                           "replica_exec_mode",
+	"slave_load_tmpdir":                         "replica_load_tmpdir",
+	"slave_max_allowed_packet":                  "replica_max_allowed_packet",
+	"slave_net_timeout":                         "replica_net_timeout",
+	"slave_parallel_type":                       "replica_parallel_type",
+	"slave_parallel_workers":                    "replica_parallel_workers",
+	"slave_pending_jobs_size_max":               "replica_pending_jobs_size_max",
+	"slave_preserve_commit_order":               "replica_preserve_commit_order",
+	"slave_rows_search_algorithms":              "replica_rows_search_algorithms",
+	"slave_skip_errors":                         "replica_skip_errors",
+	"slave_sql_verify_checksum":                 "replica_sql_verify_checksum",
+	"slave_transaction_retries":                 "replica_transaction_retries",
+	"slave_type_conversions":                    "replica_type_conversions",
+	"sql_slave_skip_counter":                    "sql_replica_skip_counter",
+	"storage_engine":                            "default_storage_engine",
+	"tx_isolation":                              "transaction_isolation",
+	"tx_read_only":                              "transaction_read_only",
+}
+
+// previousNames maps the current names of the renamed variables to their old
+// names, which the catalog may know them by
+var previousNames = func() map[string]string {
+	names := make(map[string]string, len(variableAliases))
+	for previous, current := range variableAliases {
+		names[current] = previous
+	}
+	return names
+}()
+
+// variableName is the name a variable is compared by: its canonical name, or
+// the current name of the renamed variables
+func variableName(name string) string {
+	name = canonicalName(name)
+	if current, ok := variableAliases[name]; ok {
+		return current
+	}
+	return name
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+)
+
+// cloudSQLNet is the network used in the dsns to connect to Cloud SQL
+// instances by their connection name instead of IP and port:
+//
+//	user@cloudsql-mysql(project:region:instance)/
+//
+// Connections go through the server side proxy of the instance, like the
+// Cloud SQL connectors do, with an ephemeral certificate of the Cloud SQL
+// Admin API. They use the Application Default Credentials, so no authorized
+// networks are needed.
+const cloudSQLNet = "cloudsql-mysql"
+
+// cloudSQLProxyPort is the port of the server side proxy of the instances
+const cloudSQLProxyPort = "3307"
+
+// cloudSQLLoginScope is the scope of the tokens of IAM database
+// authentication
+const cloudSQLLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+
+// cloudSQLRefreshBuffer is how long before they expire the certificates are
+// renewed
+const cloudSQLRefreshBuffer = 4 * time.Minute
+
+func usesCloudSQL(dsns []string) bool {
+	for _, dsn := range dsns {
+		if strings.Contains(dsn, "@"+cloudSQLNet+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudSQLDialer opens connections to the instances by connection name
+type cloudSQLDialer interface {
+	Dial(ctx context.Context, instance string) (net.Conn, error)
+	Close() error
+}
+
+// cloudSQLConnector connects to the instances with a single dialer, which
+// keeps the certificates of every instance. It is safe for concurrent use.
+type cloudSQLConnector struct {
+	dialer cloudSQLDialer
+}
+
+// newCloudSQLConnector creates the dialer. With IAM auth the connections log
+// in with an OAuth2 token of the credentials (IAM database authentication)
+// instead of the dsn password.
+func newCloudSQLConnector(ctx context.Context, iamAuth bool) (*cloudSQLConnector, error) {
+	service, err := sqladmin.NewService(ctx, option.WithScopes(sqladmin.SqlserviceAdminScope))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the Cloud SQL Admin client: %s", err.Error())
+	}
+	var tokens oauth2.TokenSource
+	if iamAuth {
+		if tokens, err = google.DefaultTokenSource(ctx, cloudSQLLoginScope); err != nil {
+			return nil, fmt.Errorf("Cannot get the credentials for IAM database authentication: %s", err.Error())
+		}
+	}
+	dialer, err := newSQLAdminDialer(service, tokens, cloudSQLProxyPort)
+	if err != nil {
+		return nil, err
+	}
+	return &cloudSQLConnector{dialer: dialer}, nil
+}
+
+// dial is registered in the MySQL driver for the cloudsql-mysql network
+func (c *cloudSQLConnector) dial(ctx context.Context, instance string) (net.Conn, error) {
+	if strings.Count(instance, ":") != 2 {
+		return nil, fmt.Errorf("Invalid Cloud SQL instance connection name %s. Use project:region:instance", instance)
+	}
+	conn, err := c.dialer.Dial(ctx, instance)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to the Cloud SQL instance %s: %s", instance, err.Error())
+	}
+	return conn, nil
+}
+
+// close releases the dialer
+func (c *cloudSQLConnector) close() {
+	c.dialer.Close()
+}
+
+// registerCloudSQL makes the cloudsql-mysql network available to the MySQL
+// driver. The returned connector must be closed once done.
+func registerCloudSQL(ctx context.Context, iamAuth bool) (*cloudSQLConnector, error) {
+	connector, err := newCloudSQLConnector(ctx, iamAuth)
+	if err != nil {
+		return nil, err
+	}
+	mysql.RegisterDialContext(cloudSQLNet, connector.dial)
+	return connector, nil
+}
+
+// sqlAdminDialer signs its key with the generateEphemeralCert call of the
+// Cloud SQL Admin API and connects with TLS to the server side proxy of the
+// instances, checking their certificates against the CA of connectSettings.
+type sqlAdminDialer struct {
+	service *sqladmin.Service
+	tokens  oauth2.TokenSource // Embedded in the certificates for IAM database authentication, if set
+	key     *rsa.PrivateKey
+	port    string
+
+	mu    sync.Mutex
+	certs map[string]*cloudSQLCert // By instance connection name
+}
+
+// cloudSQLCert is what is needed to connect to an instance, valid until
+// the client certificate expires
+type cloudSQLCert struct {
+	addr    string
+	config  *tls.Config
+	expires time.Time
+}
+
+func newSQLAdminDialer(service *sqladmin.Service, tokens oauth2.TokenSource, port string) (*sqlAdminDialer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot generate the Cloud SQL client key: %s", err.Error())
+	}
+	return &sqlAdminDialer{service: service, tokens: tokens, key: key, port: port, certs: make(map[string]*cloudSQLCert)}, nil
+}
+
+func (d *sqlAdminDialer) Dial(ctx context.Context, instance string) (net.Conn, error) {
+	cert, err := d.cert(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", cert.addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, cert.config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+func (d *sqlAdminDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.certs = make(map[string]*cloudSQLCert)
+	return nil
+}
+
+// cert returns the certificate of the instance, renewing it if it is about
+// to expire. The lock is held while renewing so the concurrent connections to
+// an instance share the same certificate.
+func (d *sqlAdminDialer) cert(ctx context.Context, instance string) (*cloudSQLCert, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cert, ok := d.certs[instance]; ok && time.Now().Add(cloudSQLRefreshBuffer).Before(cert.expires) {
+		return cert, nil
+	}
+	cert, err := d.newCert(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	d.certs[instance] = cert
+	return cert, nil
+}
+
+func (d *sqlAdminDialer) newCert(ctx context.Context, instance string) (*cloudSQLCert, error) {
+	parts := strings.Split(instance, ":")
+	project, name := parts[0], parts[2]
+
+	settings, err := d.service.Connect.Get(project, name).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get the connect settings: %s", err.Error())
+	}
+	addr := cloudSQLAddress(settings.IpAddresses)
+	if addr == "" {
+		return nil, fmt.Errorf("The instance has no IP address")
+	}
+	if settings.ServerCaCert == nil {
+		return nil, fmt.Errorf("The instance has no server CA certificate")
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(settings.ServerCaCert.Cert)) {
+		return nil, fmt.Errorf("Invalid server CA certificate")
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&d.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	request := &sqladmin.GenerateEphemeralCertRequest{
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+	}
+	if d.tokens != nil {
+		token, err := d.tokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot get the IAM database authentication token: %s", err.Error())
+		}
+		request.AccessToken = token.AccessToken
+	}
+	response, err := d.service.Connect.GenerateEphemeralCert(project, name, request).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("Cannot generate the ephemeral certificate: %s", err.Error())
+	}
+	if response.EphemeralCert == nil {
+		return nil, fmt.Errorf("No ephemeral certificate was generated")
+	}
+	block, _ := pem.Decode([]byte(response.EphemeralCert.Cert))
+	if block == nil {
+		return nil, fmt.Errorf("Invalid ephemeral certificate")
+	}
+	client, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ephemeral certificate: %s", err.Error())
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: d.key, Leaf: client}},
+		RootCAs:      roots,
+		MinVersion:   tls.VersionTLS13,
+		// The server certificates are issued to project:instance, that is
+		// not a host name, so they are verified by verifyCloudSQLServer
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: verifyCloudSQLServer(roots, project+":"+name, settings.DnsName),
+	}
+	return &cloudSQLCert{addr: net.JoinHostPort(addr, d.port), config: config, expires: client.NotAfter}, nil
+}
+
+// cloudSQLAddress returns the public IP of the instance, or the private one
+// if it has no public IP
+func cloudSQLAddress(addresses []*sqladmin.IpMapping) string {
+	var private string
+	for _, address := range addresses {
+		switch address.Type {
+		case "PRIMARY":
+			return address.IpAddress
+		case "PRIVATE":
+			private = address.IpAddress
+		}
+	}
+	return private
+}
+
+// verifyCloudSQLServer checks that the server certificate is signed by the
+// instance CA and issued to the instance: with its project:instance common
+// name, or with its DNS name for the instances with a shared CA
+func verifyCloudSQLServer(roots *x509.CertPool, commonName, dnsName string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("The server sent no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates}); err != nil {
+			return err
+		}
+
+		if certs[0].Subject.CommonName == commonName {
+			return nil
+		}
+		if dnsName != "" && certs[0].VerifyHostname(strings.TrimSuffix(dnsName, ".")) == nil {
+			return nil
+		}
+		return fmt.Errorf("The server certificate is not issued to %s", commonName)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+)
+
+type fakeCloudSQLDialer struct {
+	dialed []string
+	err    error
+	closed bool
+}
+
+func (d *fakeCloudSQLDialer) Dial(ctx context.Context, instance string) (net.Conn, error) {
+	d.dialed = append(d.dialed, instance)
+	if d.err != nil {
+		return nil, d.err
+	}
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func (d *fakeCloudSQLDialer) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestCloudSQLDSN(t *testing.T) {
+	dsn := "tool@cloudsql-mysql(my-project:us-central1:db1)/"
+
+	if !usesCloudSQL([]string{"u:p@tcp(10.0.0.1:3306)/", dsn}) {
+		t.Errorf("%s must use the Cloud SQL connector", dsn)
+	}
+	if usesCloudSQL([]string{"u:p@tcp(10.0.0.1:3306)/"}) {
+		t.Error("tcp dsns must not use the Cloud SQL connector")
+	}
+	if got := dsnName(dsn); got != "my-project:us-central1:db1" {
+		t.Errorf("Got: %s  --  Want: %s\n", got, "my-project:us-central1:db1")
+	}
+}
+
+func TestCloudSQLConnectorDial(t *testing.T) {
+	instance := "my-project:us-central1:db1"
+	dialer := &fakeCloudSQLDialer{}
+	connector := &cloudSQLConnector{dialer: dialer}
+
+	conn, err := connector.dial(context.Background(), instance)
+	if err != nil {
+		t.Fatalf("Cannot dial %s: %s", instance, err)
+	}
+	conn.Close()
+
+	if _, err := connector.dial(context.Background(), "db1"); err == nil {
+		t.Error("Instance names without project and region must fail")
+	}
+
+	want := []string{instance}
+	if !reflect.DeepEqual(dialer.dialed, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", dialer.dialed, want)
+	}
+
+	dialer.err = errors.New("instance not found")
+	if _, err := connector.dial(context.Background(), instance); err == nil {
+		t.Error("Dial errors must be returned")
+	}
+
+	connector.close()
+	if !dialer.closed {
+		t.Error("close must close the dialer")
+	}
+}
+
+// fakeCloudSQL is a Cloud SQL instance: a CA, the Admin API that signs the
+// client keys with it and a TLS server that requires those certificates
+type fakeCloudSQL struct {
+	caKey  *rsa.PrivateKey
+	ca     *x509.Certificate
+	serial int64
+}
+
+func (f *fakeCloudSQL) sign(t *testing.T, template *x509.Certificate, key interface{}) []byte {
+	f.serial++
+	template.SerialNumber = big.NewInt(f.serial)
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, f.ca, key, f.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func newFakeCloudSQL(t *testing.T) *fakeCloudSQL {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Google Cloud SQL Server CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	return &fakeCloudSQL{caKey: caKey, ca: ca, serial: 1}
+}
+
+// serve listens with a server certificate issued to commonName, and
+// returns its port
+func (f *fakeCloudSQL) serve(t *testing.T, commonName string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := f.sign(t, &x509.Certificate{Subject: pkix.Name{CommonName: commonName}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, &key.PublicKey)
+	clients := x509.NewCertPool()
+	clients.AddCert(f.ca)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clients,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Write([]byte("ok"))
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port
+}
+
+// adminAPI serves connectSettings and generateEphemeralCert of the instance
+func (f *fakeCloudSQL) adminAPI(t *testing.T, requests *[]sqladmin.GenerateEphemeralCertRequest) *sqladmin.Service {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/projects/my-project/instances/db1/connectSettings"):
+			json.NewEncoder(w).Encode(sqladmin.ConnectSettings{
+				IpAddresses:  []*sqladmin.IpMapping{{Type: "PRIVATE", IpAddress: "127.0.0.1"}},
+				ServerCaCert: &sqladmin.SslCert{Cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.ca.Raw}))},
+			})
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/projects/my-project/instances/db1:generateEphemeralCert"):
+			var request sqladmin.GenerateEphemeralCertRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			*requests = append(*requests, request)
+			block, _ := pem.Decode([]byte(request.PublicKey))
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			der := f.sign(t, &x509.Certificate{Subject: pkix.Name{CommonName: "tool"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, key)
+			json.NewEncoder(w).Encode(sqladmin.GenerateEphemeralCertResponse{
+				EphemeralCert: &sqladmin.SslCert{Cert: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	service, err := sqladmin.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func TestSQLAdminDialer(t *testing.T) {
+	instance := "my-project:us-central1:db1"
+	fake := newFakeCloudSQL(t)
+	var requests []sqladmin.GenerateEphemeralCertRequest
+	service := fake.adminAPI(t, &requests)
+
+	dialer, err := newSQLAdminDialer(service, nil, fake.serve(t, "my-project:db1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		conn, err := dialer.Dial(context.Background(), instance)
+		if err != nil {
+			t.Fatalf("Cannot dial %s: %s", instance, err)
+		}
+		buffer := make([]byte, 2)
+		if _, err := conn.Read(buffer); err != nil || string(buffer) != "ok" {
+			t.Errorf("Got: %q, %v  --  Want: ok\n", buffer, err)
+		}
+		conn.Close()
+	}
+	// The certificate is reused until it expires, and has no token
+	// without IAM database authentication
+	if len(requests) != 1 || requests[0].AccessToken != "" {
+		t.Errorf("Got: %#v  --  Want: a single certificate without token\n", requests)
+	}
+
+	// With IAM database authentication the token goes in the certificate
+	iam, err := newSQLAdminDialer(service, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "iam-token"}), fake.serve(t, "my-project:db1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := iam.Dial(context.Background(), instance)
+	if err != nil {
+		t.Fatalf("Cannot dial %s with IAM auth: %s", instance, err)
+	}
+	conn.Close()
+	if len(requests) != 2 || requests[1].AccessToken != "iam-token" {
+		t.Errorf("Got: %#v  --  Want: the IAM token in the certificate request\n", requests)
+	}
+
+	// A server with a certificate of another instance is rejected
+	other, err := newSQLAdminDialer(service, nil, fake.serve(t, "my-project:db2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Dial(context.Background(), instance); err == nil {
+		t.Error("The certificates of other instances must be rejected")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxIncludeDepth limits the nesting of !include directives, to stop include
+// loops
+const maxIncludeDepth = 10
+
+// readOptionFiles reads an option file and, recursively, the files of its
+// !include and !includedir directives. Included options are inserted where
+// the directive is, so the last-wins rule of mergeOptions is the same as
+// mysqld's: an option set after the directive overrides the included one.
+func readOptionFiles(ctx context.Context, filename string, readOpts cnfReadOptions, runCommand commandRunner) ([]cnfOption, error) {
+	return readIncludedFiles(ctx, filename, readOpts, runCommand, nil)
+}
+
+func readIncludedFiles(ctx context.Context, filename string, readOpts cnfReadOptions, runCommand commandRunner, parents []string) ([]cnfOption, error) {
+	for _, parent := range parents {
+		if parent == filename {
+			return nil, fmt.Errorf("Include loop: %s includes itself", filename)
+		}
+	}
+	if len(parents) >= maxIncludeDepth {
+		return nil, fmt.Errorf("Too many nested includes reading %s", filename)
+	}
+	parents = append(parents, filename)
+
+	data, err := readFile(ctx, filename, readOpts.Remote, runCommand)
+	if err != nil {
+		return nil, err
+	}
+	if readOpts.Vars != nil {
+		if data, err = expandTemplate(filename, data, readOpts.Vars); err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := parseOptionFile(filename, bytes.NewReader(data), readOpts.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []cnfOption
+	for _, option := range options {
+		var included []string
+		switch option.Name {
+		case includeDirective:
+			included = []string{includePath(filename, option.Value)}
+		case includeDirDirective:
+			if included, err = includeDirFiles(ctx, includePath(filename, option.Value), readOpts.Remote, runCommand); err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", filename, option.Line, err.Error())
+			}
+		default:
+			all = append(all, option)
+			continue
+		}
+
+		for _, name := range included {
+			includedOptions, err := readIncludedFiles(ctx, name, readOpts, runCommand, parents)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: Cannot read the included file: %s", filename, option.Line, err.Error())
+			}
+			all = append(all, includedOptions...)
+		}
+	}
+
+	return all, nil
+}
+
+// includePath resolves the path of a directive. mysqld expects absolute paths;
+// relative ones are taken from the directory of the including file, which also
+// works for the files read from buckets. The paths in files read over ssh or
+// from containers are in the same host or container.
+func includePath(filename, path string) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+	if file, ok, err := parseHostFile(filename, nil); ok && err == nil && strings.HasPrefix(path, "/") {
+		return file.prefix + path
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if file, err := parseGitFile(filename); strings.HasPrefix(filename, "git:") && err == nil {
+		return file.relative(path)
+	}
+	if strings.Contains(filename, "://") {
+		return filename[:strings.LastIndex(filename, "/")+1] + path
+	}
+	return filepath.Join(filepath.Dir(filename), path)
+}
+
+// includeDirFiles returns the option files read by !includedir: the files
+// ending with .cnf, sorted by name. Only local directories and the ones of
+// other hosts or containers can be listed.
+func includeDirFiles(ctx context.Context, dir string, remote remoteOptions, runCommand commandRunner) ([]string, error) {
+	if file, ok, err := parseHostFile(dir, remote.Kubernetes); ok {
+		if err != nil {
+			return nil, err
+		}
+		return hostDirFiles(ctx, file, runCommand)
+	}
+	if strings.HasPrefix(dir, "git:") {
+		return gitDirFiles(ctx, dir, runCommand)
+	}
+	if strings.Contains(dir, "://") {
+		return nil, fmt.Errorf("Cannot list %s: !includedir only works with local, ssh://, docker://, k8s-pod:// and git: directories", dir)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".cnf" {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func hostDirFiles(ctx context.Context, file hostFile, runCommand commandRunner) ([]string, error) {
+	// -p adds a slash to the directories, so they are skipped
+	output, err := file.run(ctx, runCommand, "ls", "-1p", "--", file.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(output), "\n") {
+		name = strings.TrimSpace(name)
+		if path.Ext(name) != ".cnf" {
+			continue
+		}
+		files = append(files, file.prefix+path.Join(file.path, name))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func gitDirFiles(ctx context.Context, dir string, runCommand commandRunner) ([]string, error) {
+	file, err := parseGitFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	output, err := runCommand(ctx, "git", "ls-tree", "--name-only", file.ref+":"+file.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(output), "\n") {
+		name = strings.TrimSpace(name)
+		if path.Ext(name) != ".cnf" {
+			continue
+		}
+		files = append(files, file.name(path.Join(file.path, name)))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
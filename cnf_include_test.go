@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"my.cnf":              "[mysqld]\nport = 3306\nmax_connections = 100\n!includedir conf.d\n!include extra.cnf\nport = 3307\n",
+		"conf.d/10-base.cnf":  "[mysqld]\nmax_connections = 200\nslow_query_log = ON\n",
+		"conf.d/20-tuned.cnf": "[mysqld]\nmax_connections = 300\n",
+		"conf.d/README":       "not an option file",
+		"extra.cnf":           "[client]\nuser = root\n[mysqld]\nsync_binlog = 1\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cnf, err := newCNFReader(context.Background(), filepath.Join(dir, "my.cnf"), cnfReadOptions{}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	got := make(map[string]interface{})
+	for _, key := range cnf.Keys() {
+		got[key], _ = cnf.Get(key)
+	}
+	want := map[string]interface{}{
+		"port":            "3307",
+		"max_connections": "300",
+		"slow_query_log":  "ON",
+		"sync_binlog":     "1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	origin, _ := cnf.Origin("max_connections")
+	if origin.File != filepath.Join(dir, "conf.d/20-tuned.cnf") || origin.Line != 2 {
+		t.Errorf("Got: %s  --  Want: the line 2 of conf.d/20-tuned.cnf\n", origin)
+	}
+}
+
+func TestIncludeLoop(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "a.cnf"), []byte("[mysqld]\n!include b.cnf\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.cnf"), []byte("[mysqld]\n!include a.cnf\n"), 0644)
+
+	_, err := newCNFReader(context.Background(), filepath.Join(dir, "a.cnf"), cnfReadOptions{}, execCommand)
+	if err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Errorf("Should return an include loop error. Got: %v", err)
+	}
+}
+
+func TestIncludePath(t *testing.T) {
+	tests := map[[2]string]string{
+		{"/etc/my.cnf", "/etc/mysql/conf.d"}:                    "/etc/mysql/conf.d",
+		{"/etc/mysql/my.cnf", "conf.d"}:                         "/etc/mysql/conf.d",
+		{"s3://configs/golden/my.cnf", "tuning.cnf"}:            "s3://configs/golden/tuning.cnf",
+		{"s3://configs/golden/my.cnf", "gs://other/x.cnf"}:      "gs://other/x.cnf",
+		{"ssh://dbadmin@db01:/etc/my.cnf", "/etc/mysql/conf.d"}: "ssh://dbadmin@db01:/etc/mysql/conf.d",
+	}
+	for args, want := range tests {
+		if got := includePath(args[0], args[1]); got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+}
+
+func TestReadSSHIncludes(t *testing.T) {
+	files := map[string]string{
+		"cat /etc/my.cnf":                  "[mysqld]\nport = 3306\n!includedir /etc/mysql/conf.d/\n",
+		"ls -1p -- /etc/mysql/conf.d/":     "old/\nmysqld.cnf\nREADME\n",
+		"cat /etc/mysql/conf.d/mysqld.cnf": "[mysqld]\nport = 3307\n",
+	}
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		command := strings.Replace(strings.Join(args[4:], " "), "'", "", -1)
+		if name != "ssh" || args[2] != "dbadmin@db01" {
+			t.Errorf("Unexpected command: %s %s", name, strings.Join(args, " "))
+		}
+		return []byte(files[command]), nil
+	}
+
+	cnf, err := newCNFReader(context.Background(), "ssh://dbadmin@db01:/etc/my.cnf", cnfReadOptions{}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	origin, _ := cnf.Origin("port")
+	if value, _ := cnf.Get("port"); value != "3307" || origin.File != "ssh://dbadmin@db01:/etc/mysql/conf.d/mysqld.cnf" {
+		t.Errorf("Got: %v from %s  --  Want: 3307 from conf.d/mysqld.cnf\n", value, origin.File)
+	}
+}
+
+func TestReadGitIncludes(t *testing.T) {
+	files := map[string]string{
+		"show v1.3:my.cnf":                "[mysqld]\nport = 3306\n!include common.cnf\n!includedir conf.d\n",
+		"show v1.3:common.cnf":            "[mysqld]\nmax_connections = 500\n",
+		"ls-tree --name-only v1.3:conf.d": "mysqld.cnf\nREADME\n",
+		"show v1.3:conf.d/mysqld.cnf":     "[mysqld]\nport = 3307\n",
+	}
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		content, ok := files[strings.Join(args, " ")]
+		if name != "git" || !ok {
+			t.Errorf("Unexpected command: %s %s", name, strings.Join(args, " "))
+		}
+		return []byte(content), nil
+	}
+
+	cnf, err := newCNFReader(context.Background(), "git:v1.3:my.cnf", cnfReadOptions{}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := map[string]interface{}{"port": "3307", "max_connections": "500"}
+	if !reflect.DeepEqual(cnf.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cnf.Entries(), want)
+	}
+	origin, _ := cnf.Origin("port")
+	if origin.File != "git:v1.3:conf.d/mysqld.cnf" {
+		t.Errorf("Got: %s  --  Want: git:v1.3:conf.d/mysqld.cnf\n", origin.File)
+	}
+
+	if _, err := parseGitFile("git:my.cnf"); err == nil {
+		t.Error("Should return error without the ref")
+	}
+}
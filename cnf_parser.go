@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cnfOption is an option read from a MySQL option file, with the place where
+// it was set.
+type cnfOption struct {
+	Name    string
+	Value   string
+	Section string
+	File    string
+	Line    int
+	Text    string // The line as it is in the file
+}
+
+// entryOrigin tells where a config entry was set. cnf files have origins, and
+// servers read with the variables_info query source, that have the
+// VARIABLE_SOURCE of performance_schema.variables_info instead of a line.
+type entryOrigin struct {
+	File    string `json:"file"`
+	Section string `json:"section"`
+	Line    int    `json:"line"`
+	Text    string `json:"text,omitempty"`
+	Source  string `json:"source,omitempty"`
+	SetTime string `json:"set_time,omitempty"`
+	SetUser string `json:"set_user,omitempty"`
+
+	// Order is the position of the option in the options read, the files
+	// and their includes, so the last one read can be told
+	Order int `json:"-"`
+}
+
+func (o entryOrigin) String() string {
+	if o.Source == "" {
+		return fmt.Sprintf("%s:%d [%s]", o.File, o.Line, o.Section)
+	}
+
+	text := o.Source
+	if o.File != "" {
+		text += " " + o.File
+	}
+	if o.SetUser != "" {
+		text += " by " + o.SetUser
+	}
+	if o.SetTime != "" {
+		text += " at " + o.SetTime
+	}
+	return text
+}
+
+// cnfReadOptions tells newCNFReader how to read the option files
+type cnfReadOptions struct {
+	Strict        bool          // Fail on malformed lines instead of ignoring them
+	Groups        []string      // Groups to read. Default: mysqld
+	SplitGroups   bool          // Read every group as a config of its own instead of merging them
+	ServerVersion string        // Also read the [mysqld-major.minor] group of this version
+	Remote        remoteOptions // Settings to read the http(s):// and s3:// files
+
+	// Vars are the values of the ${VAR} placeholders of templates. nil if
+	// the files are not templates.
+	Vars map[string]string
+}
+
+// groups returns the groups to read, in lowercase like the parsed sections.
+// mysqld also reads the group of its version, like [mysqld-8.0], after
+// [mysqld]. MariaDB also reads its own groups, like [mariadb] and
+// [mariadb-10.11].
+func (o cnfReadOptions) groups() []string {
+	groups := []string{"mysqld"}
+	if len(o.Groups) > 0 {
+		groups = make([]string, len(o.Groups))
+		for i, group := range o.Groups {
+			groups[i] = strings.ToLower(strings.Trim(strings.TrimSpace(group), "[]"))
+		}
+	}
+
+	if version := majorMinor(o.ServerVersion); version != "" {
+		for _, group := range groups {
+			if group == "mysqld" {
+				groups = append(groups, "mysqld-"+version)
+				if isMariaDBVersion(o.ServerVersion) {
+					groups = append(groups, "mariadb", "mariadb-"+version, "mariadbd", "mariadbd-"+version)
+				}
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// clientOnlyOptions are options that only make sense for the client programs.
+// mysqld fails to start (or ignores them if they are loose-) when they are
+// set in its groups.
+var clientOnlyOptions = map[string]bool{
+	"auto-rehash":           true,
+	"batch":                 true,
+	"column-names":          true,
+	"database":              true,
+	"default-character-set": true,
+	"host":                  true,
+	"html":                  true,
+	"i-am-a-dummy":          true,
+	"no-auto-rehash":        true,
+	"no-beep":               true,
+	"pager":                 true,
+	"password":              true,
+	"prompt":                true,
+	"protocol":              true,
+	"quick":                 true,
+	"safe-updates":          true,
+	"silent":                true,
+	"ssl-mode":              true,
+	"tee":                   true,
+	"xml":                   true,
+}
+
+// Directives to read other option files
+const (
+	includeDirective    = "!include"
+	includeDirDirective = "!includedir"
+)
+
+// serverGroups are the groups read by mysqld
+var serverGroups = map[string]bool{
+	"mysqld": true,
+	"server": true,
+}
+
+// parseOptionFile reads an option file the way mysqld does: [group] headers,
+// "key", "key=value" lines, # and ; comments, quoted values and escape
+// sequences.
+// Options are returned in the same order they appear in the file so callers
+// can apply the last-wins rule. !include and !includedir directives are
+// returned as options named after the directive, with the path as value, to be
+// resolved by the caller (see readOptionFiles).
+// In strict mode, lines mysqld would silently accept but are probably wrong
+// (stray quotes, unknown escapes, client options in server groups) are
+// reported as errors.
+func parseOptionFile(filename string, r io.Reader, strict bool) ([]cnfOption, error) {
+	var options []cnfOption
+	var problems []string
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		line := strings.TrimSpace(text)
+		addProblem := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("%s:%d: %s", filename, lineNumber, fmt.Sprintf(format, args...)))
+		}
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '!' {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || (fields[0] != includeDirective && fields[0] != includeDirDirective) {
+				addProblem("unknown directive: %s", line)
+				continue
+			}
+			options = append(options, cnfOption{
+				Name:  fields[0],
+				Value: strings.TrimSpace(line[len(fields[0]):]),
+				File:  filename,
+				Line:  lineNumber,
+				Text:  text,
+			})
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: invalid group header: %s", filename, lineNumber, line)
+			}
+			if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != '#' && rest[0] != ';' {
+				addProblem("unexpected text after the group header: %s", line)
+			}
+			section = strings.ToLower(strings.TrimSpace(line[1:end]))
+			continue
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("%s:%d: option found before the first group: %s", filename, lineNumber, line)
+		}
+
+		name, value := line, "true"
+		if pos := strings.Index(line, "="); pos >= 0 {
+			var valueProblems []string
+			name = strings.TrimSpace(line[:pos])
+			value, valueProblems = parseOptionValue(line[pos+1:])
+			for _, problem := range valueProblems {
+				addProblem("%s: %s", name, problem)
+			}
+		} else if pos := strings.Index(line, "#"); pos >= 0 {
+			name = strings.TrimSpace(line[:pos])
+		}
+
+		if name == "" || strings.ContainsAny(name, " \t\"'") {
+			addProblem("malformed line: %s", line)
+			continue
+		}
+
+		if serverGroups[section] && clientOnlyOptions[strings.Replace(name, "_", "-", -1)] {
+			addProblem("%s is a client option and it is set in the [%s] group", name, section)
+		}
+
+		options = append(options, cnfOption{
+			Name:    name,
+			Value:   value,
+			Section: section,
+			File:    filename,
+			Line:    lineNumber,
+			Text:    text,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if strict && len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	return options, nil
+}
+
+var optionEscapes = map[byte]string{
+	'b':  "\b",
+	't':  "\t",
+	'n':  "\n",
+	'r':  "\r",
+	's':  " ",
+	'\\': `\`,
+	'"':  `"`,
+	'\'': "'",
+}
+
+// parseOptionValue removes the quotes, the trailing comments and resolves the
+// escape sequences of an option value. It also returns the problems found,
+// used by the strict mode.
+func parseOptionValue(raw string) (string, []string) {
+	var problems []string
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+
+	quote := byte(0)
+	if raw[0] == '"' || raw[0] == '\'' {
+		quote = raw[0]
+		raw = raw[1:]
+	}
+
+	var value strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\' && i+1 < len(raw):
+			i++
+			if escaped, ok := optionEscapes[raw[i]]; ok {
+				value.WriteString(escaped)
+			} else {
+				problems = append(problems, fmt.Sprintf("unknown escape sequence \\%c", raw[i]))
+				value.WriteByte('\\')
+				value.WriteByte(raw[i])
+			}
+		case quote != 0 && c == quote:
+			if rest := strings.TrimSpace(raw[i+1:]); rest != "" && rest[0] != '#' {
+				problems = append(problems, fmt.Sprintf("unexpected text after the closing quote: %s", rest))
+			}
+			return value.String(), problems
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(value.String()), problems
+		case quote == 0 && (c == '"' || c == '\''):
+			problems = append(problems, fmt.Sprintf("stray quote in value: %s", raw))
+			value.WriteByte(c)
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	if quote != 0 {
+		// Unbalanced quotes: mysqld keeps the value as it is
+		problems = append(problems, "missing closing quote")
+		return string(quote) + value.String(), problems
+	}
+	return strings.TrimSpace(value.String()), problems
+}
+
+// mergeOptions builds the effective config for the given groups. mysqld
+// concatenates every block of a group (repeated [mysqld] headers in a file or
+// in its includes), so options are applied in the order they were read and
+// the last one wins, no matter which block it belongs to.
+func mergeOptions(options []cnfOption, groups ...string) *config {
+	cnf := &config{
+		configType: "cnf",
+		entries:    make(map[string]interface{}),
+		origins:    make(map[string]entryOrigin),
+	}
+
+	wanted := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		wanted[group] = true
+	}
+
+	for i, option := range options {
+		if !wanted[option.Section] {
+			continue
+		}
+		name := optionName(option.Name)
+		// The same option with another spelling is replaced
+		if key := cnf.entryKey(name); key != name {
+			cnf.deleteEntry(key)
+			delete(cnf.origins, key)
+		}
+		cnf.setEntry(name, option.Value)
+		cnf.origins[name] = entryOrigin{
+			File:    option.File,
+			Section: option.Section,
+			Line:    option.Line,
+			Text:    option.Text,
+			Order:   i,
+		}
+	}
+
+	return cnf
+}
+
+// optionName removes the loose- prefix, that only makes mysqld ignore the
+// unknown options instead of failing, so loose-innodb_buffer_pool_size and
+// innodb_buffer_pool_size are the same option (the last one wins).
+func optionName(name string) string {
+	if len(name) > len("loose-") && strings.EqualFold(name[:len("loose")], "loose") && (name[5] == '-' || name[5] == '_') {
+		return name[len("loose-"):]
+	}
+	return name
+}
+
+// expandOptionPrefixes renames the options that are not variable names but
+// an unambiguous prefix of one of them, like innodb_buffer_pool for
+// innodb_buffer_pool_size, which mysqld accepted until 8.0. The options keep
+// their - or _ separators. Options whose full name is set too are kept, and
+// so are the full names of the options mysqld knows, even if they are the
+// prefix of a variable (user and userstat on Percona Server).
+func expandOptionPrefixes(cfg configReader, names []string) {
+	cnf, ok := cfg.(*config)
+	if !ok {
+		return
+	}
+
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[strings.Replace(name, "-", "_", -1)] = true
+	}
+
+	for key, value := range cnf.entries {
+		prefix := strings.Replace(key, "-", "_", -1)
+		if known[prefix] || isMysqldOption(prefix) {
+			continue
+		}
+
+		expanded := ""
+		for name := range known {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			if expanded != "" {
+				expanded = ""
+				break
+			}
+			expanded = name
+		}
+		if expanded == "" {
+			continue
+		}
+		if strings.Contains(key, "-") {
+			expanded = strings.Replace(expanded, "_", "-", -1)
+		}
+		if _, ok := cnf.entries[expanded]; ok {
+			continue
+		}
+
+		cnf.setEntry(expanded, value)
+		cnf.deleteEntry(key)
+		if origin, ok := cnf.origins[key]; ok {
+			cnf.origins[expanded] = origin
+			delete(cnf.origins, key)
+		}
+	}
+}
+
+// mysqldOptions are the mysqld command line options that are not server
+// variables, so they are never reported by the servers.
+var mysqldOptions = map[string]bool{
+	"bootstrap":                      true,
+	"character_set_client_handshake": true,
+	"chroot":                         true,
+	"console":                        true,
+	"daemonize":                      true,
+	"defaults_extra_file":            true,
+	"defaults_file":                  true,
+	"defaults_group_suffix":          true,
+	"early_plugin_load":              true,
+	"exit_info":                      true,
+	"gdb":                            true,
+	"initialize":                     true,
+	"initialize_insecure":            true,
+	"log_isam":                       true,
+	"log_short_format":               true,
+	"no_defaults":                    true,
+	"old_style_user_limits":          true,
+	"plugin_load":                    true,
+	"plugin_load_add":                true,
+	"print_defaults":                 true,
+	"safe_user_create":               true,
+	"skip_grant_tables":              true,
+	"skip_host_cache":                true,
+	"skip_new":                       true,
+	"skip_stack_trace":               true,
+	"symbolic_links":                 true,
+	"sysdate_is_now":                 true,
+	"tc_heuristic_recover":           true,
+	"temp_pool":                      true,
+	"user":                           true,
+	"validate_config":                true,
+}
+
+// isMysqldOption returns true if the name (with underscores) is the full name
+// of a mysqld option or of a variable in the catalog.
+func isMysqldOption(name string) bool {
+	if mysqldOptions[name] {
+		return true
+	}
+	_, ok := variablesMetadata[name]
+	return ok
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOptionValue(t *testing.T) {
+	equivalences := map[string]string{
+		"value":                   "value",
+		"  value  ":               "value",
+		"'file'":                  "file",
+		`""`:                      "",
+		`"a # b"`:                 "a # b",
+		"value # comment":         "value",
+		`C:\\mysql\\data`:         `C:\mysql\data`,
+		`'it\'s'`:                 "it's",
+		`a\sb`:                    "a b",
+		"'unbalanced":             "'unbalanced",
+		"/var/log/mysql/slow.log": "/var/log/mysql/slow.log",
+	}
+
+	for raw, want := range equivalences {
+		if got, _ := parseOptionValue(raw); got != want {
+			t.Errorf("%s -- Got: %#v  --  Want: %#v\n", raw, got, want)
+		}
+	}
+}
+
+func TestParseOptionFile(t *testing.T) {
+	cnf := `# comment
+[mysqld]
+port = 3306
+skip-name-resolve
+
+[Client]
+user=root
+`
+	want := []cnfOption{
+		{Name: "port", Value: "3306", Section: "mysqld", File: "my.cnf", Line: 3, Text: "port = 3306"},
+		{Name: "skip-name-resolve", Value: "true", Section: "mysqld", File: "my.cnf", Line: 4, Text: "skip-name-resolve"},
+		{Name: "user", Value: "root", Section: "client", File: "my.cnf", Line: 7, Text: "user=root"},
+	}
+
+	got, err := parseOptionFile("my.cnf", strings.NewReader(cnf), true)
+	if err != nil {
+		t.Errorf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := parseOptionFile("my.cnf", strings.NewReader("port=3306\n"), false); err == nil {
+		t.Error("Should return error on options without group")
+	}
+}
+
+func TestVerboseJsonOutput(t *testing.T) {
+	cfg := &config{
+		configType: "cnf",
+		entries:    map[string]interface{}{"key1": "1"},
+		origins:    map[string]entryOrigin{"key1": {File: "my.cnf", Section: "mysqld", Line: 3}},
+	}
+	diff := map[string][]interface{}{"key1": []interface{}{"1", missing}}
+
+	want := `{"key1":{"values":["1",null],"status":"missing","origins":[{"file":"my.cnf","section":"mysqld","line":3}]}}`
+	got, _ := (&jsonOutput{verbose: true, configs: []configReader{cfg}}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestMergeRepeatedGroups(t *testing.T) {
+	cnf := `[mysqld]
+key1 = 1
+key2 = 2
+
+[client]
+key1 = client
+
+[mysqld]
+key1 = 10
+key3 = 3
+`
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	want := map[string]interface{}{
+		"key1": "10",
+		"key2": "2",
+		"key3": "3",
+	}
+
+	got := mergeOptions(options, "mysqld")
+	if !reflect.DeepEqual(got.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got.Entries(), want)
+	}
+	if origin, _ := got.Origin("key1"); origin.Line != 9 {
+		t.Errorf("key1 should come from the second [mysqld] block. Got line %d", origin.Line)
+	}
+}
+
+func TestOptionPrefixes(t *testing.T) {
+	cnf := `[mysqld]
+loose-innodb_buffer_pool_size = 1G
+innodb_buffer_pool_size = 2G
+loose_audit_log_format = JSON
+max_conn = 100
+innodb-log = ON
+key-buffer = 8M
+`
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	got := mergeOptions(options, "mysqld")
+	expandOptionPrefixes(got, []string{"innodb_buffer_pool_size", "audit_log_format", "max_connections",
+		"max_connect_errors", "max_connections_per_hour", "innodb_log_file_size", "innodb_log_files_in_group", "key_buffer_size"})
+
+	want := map[string]interface{}{
+		"innodb_buffer_pool_size": "2G",
+		"audit_log_format":        "JSON",
+		"max_conn":                "100",
+		"innodb-log":              "ON",
+		"key-buffer-size":         "8M",
+	}
+	if !reflect.DeepEqual(got.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got.Entries(), want)
+	}
+	if origin, _ := got.Origin("key-buffer-size"); origin.Line != 7 {
+		t.Errorf("key-buffer-size should keep the origin of key-buffer. Got line %d", origin.Line)
+	}
+}
+
+func TestOptionPrefixesKeepMysqldOptions(t *testing.T) {
+	cnf := `[mysqld]
+user = mysql
+plugin-load = audit_log.so
+skip-grant-tables
+`
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	got := mergeOptions(options, "mysqld")
+	// Percona Server has userstat, and plugin_load_add / skip_grant_tables are
+	// not reported at all
+	expandOptionPrefixes(got, []string{"userstat", "plugin_load_add_ons", "skip_grant_tables_extra"})
+
+	want := map[string]interface{}{
+		"user":              "mysql",
+		"plugin-load":       "audit_log.so",
+		"skip-grant-tables": "true",
+	}
+	if !reflect.DeepEqual(got.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got.Entries(), want)
+	}
+}
+
+func TestReadGroups(t *testing.T) {
+	cnf := `[client]
+user = app
+port = 3307
+
+[mysqldump]
+quick
+max_allowed_packet = 1G
+
+[mysqld]
+port = 3306
+`
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), false)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	want := map[string]interface{}{
+		"user":               "app",
+		"port":               "3307",
+		"quick":              "true",
+		"max_allowed_packet": "1G",
+	}
+
+	got := mergeOptions(options, cnfReadOptions{Groups: []string{"Client", "[mysqldump]"}}.groups()...)
+	if !reflect.DeepEqual(got.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got.Entries(), want)
+	}
+	if groups := (cnfReadOptions{}).groups(); !reflect.DeepEqual(groups, []string{"mysqld"}) {
+		t.Errorf("Got: %v  --  Want: [mysqld]\n", groups)
+	}
+}
+
+func TestReadVersionGroups(t *testing.T) {
+	cnf := `[mysqld]
+innodb_buffer_pool_size = 1G
+
+[mysqld-5.7]
+query_cache_size = 0
+
+[mysqld-8.0]
+innodb_buffer_pool_size = 2G
+`
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), false)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	tests := map[string]map[string]interface{}{
+		"":           {"innodb_buffer_pool_size": "1G"},
+		"5.7.44-log": {"innodb_buffer_pool_size": "1G", "query_cache_size": "0"},
+		"8.0.36":     {"innodb_buffer_pool_size": "2G"},
+	}
+	for version, want := range tests {
+		got := mergeOptions(options, cnfReadOptions{ServerVersion: version}.groups()...)
+		if !reflect.DeepEqual(got.Entries(), want) {
+			t.Errorf("%s -- Got:\n%#v\nWant:\n%#v\n", version, got.Entries(), want)
+		}
+	}
+
+	groups := cnfReadOptions{Groups: []string{"client"}, ServerVersion: "8.0.36"}.groups()
+	if !reflect.DeepEqual(groups, []string{"client"}) {
+		t.Errorf("Got: %v  --  Want: [client]\n", groups)
+	}
+}
+
+func TestStrictParsing(t *testing.T) {
+	cnf := `[mysqld]
+password = secret
+datadir = /var/lib/"mysql
+tmpdir = '/tmp' extra
+log-error = C:\\logs\q.log
+socket = '/var/run/mysqld.sock
+= 1
+`
+	want := []string{
+		"my.cnf:2: password is a client option and it is set in the [mysqld] group",
+		"my.cnf:3: datadir: stray quote in value: /var/lib/\"mysql",
+		"my.cnf:4: tmpdir: unexpected text after the closing quote: extra",
+		"my.cnf:5: log-error: unknown escape sequence \\q",
+		"my.cnf:6: socket: missing closing quote",
+		"my.cnf:7: malformed line: = 1",
+	}
+
+	options, err := parseOptionFile("my.cnf", strings.NewReader(cnf), false)
+	if err != nil {
+		t.Errorf("Shouldn't return error in permissive mode: %s", err.Error())
+	}
+	if len(options) != 5 {
+		t.Errorf("Permissive mode should skip only malformed lines. Got %d options", len(options))
+	}
+
+	_, err = parseOptionFile("my.cnf", strings.NewReader(cnf), true)
+	if err == nil {
+		t.Fatal("Should return error in strict mode")
+	}
+	if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
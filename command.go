@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// commandRunner runs an external program and returns its standard output.
+// Readers that rely on external tools (aws, ssh, docker...) receive it as a
+// parameter so it can be mocked on tests. The program is killed if the
+// context is done before it exits.
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// commandInputKey is the context key of the standard input of the commands
+type commandInputKey struct{}
+
+// withCommandInput returns a context that makes execCommand write input to
+// the standard input of the program. The secrets are passed this way, since
+// the arguments can be seen by every user of the host in the process list.
+func withCommandInput(ctx context.Context, input string) context.Context {
+	return context.WithValue(ctx, commandInputKey{}, input)
+}
+
+func execCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if input, ok := ctx.Value(commandInputKey{}).(string); ok {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %s: %s", name, strings.Join(redactArgs(args), " "), err.Error(), strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// redactArgs hides the passwords of the URLs in the arguments, to show them
+// in the errors
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		if !strings.Contains(arg, "://") || !strings.Contains(arg, "@") {
+			continue
+		}
+		if u, err := url.Parse(arg); err == nil {
+			redacted[i] = u.Redacted()
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// internPool keeps a single copy of every distinct string. Variable names and
+// most values (ON, OFF, 0, paths...) are the same on every server, so big
+// fleets share almost all their strings. It is safe for concurrent use, so
+// the servers can be compacted as they are read.
+type internPool struct {
+	mu      sync.Mutex
+	strings map[string]string
+}
+
+func newInternPool() *internPool {
+	return &internPool{strings: make(map[string]string)}
+}
+
+func (p *internPool) intern(s string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if interned, ok := p.strings[s]; ok {
+		return interned
+	}
+	p.strings[s] = s
+	return s
+}
+
+// compactConfig is a read only config that stores its entries in sorted
+// slices of interned strings instead of a map, to hold hundreds of sources
+// in a small amount of memory.
+type compactConfig struct {
+	configType string
+	name       string
+	keys       []string // Sorted
+	values     []string
+	origins    map[string]entryOrigin
+	nulls      map[string]bool // Keys of the NULL values, stored as NULL
+	section    string
+
+	// aliases has the position of the keys that are not their variableName,
+	// by variableName. The servers have none.
+	aliases map[string]int
+}
+
+// newCompactConfig copies cfg into a compactConfig. Values are stored as
+// strings ([]byte values from the MySQL driver are converted).
+func newCompactConfig(cfg configReader, pool *internPool) *compactConfig {
+	keys := cfg.Keys()
+	sort.Strings(keys)
+
+	c := &compactConfig{
+		configType: cfg.Type(),
+		name:       cfg.Name(),
+		keys:       make([]string, len(keys)),
+		values:     make([]string, len(keys)),
+		section:    configSection(cfg),
+	}
+	for i, key := range keys {
+		value, _ := cfg.Get(key)
+		c.keys[i] = pool.intern(key)
+		c.values[i] = pool.intern(valueString(value))
+		if isNull(value) {
+			if c.nulls == nil {
+				c.nulls = make(map[string]bool)
+			}
+			c.nulls[c.keys[i]] = true
+		}
+		if origin, ok := cfg.Origin(key); ok {
+			if c.origins == nil {
+				c.origins = make(map[string]entryOrigin)
+			}
+			c.origins[c.keys[i]] = origin
+		}
+		if variable := variableName(key); variable != key {
+			if c.aliases == nil {
+				c.aliases = make(map[string]int)
+			}
+			c.aliases[variable] = i
+		}
+	}
+
+	return c
+}
+
+// compactConfigs converts the configs to compactConfigs sharing the strings of
+// the pool. The configs already compacted are kept as they are.
+func compactConfigs(configs []configReader, pool *internPool) []configReader {
+	compacted := make([]configReader, len(configs))
+	for i, cfg := range configs {
+		if c, ok := cfg.(*compactConfig); ok {
+			compacted[i] = c
+			continue
+		}
+		compacted[i] = newCompactConfig(cfg, pool)
+	}
+	return compacted
+}
+
+// Entries builds a new map on every call. Use Keys and Get when possible.
+func (c *compactConfig) Entries() map[string]interface{} {
+	entries := make(map[string]interface{}, len(c.keys))
+	for i, key := range c.keys {
+		entries[key] = c.value(i)
+	}
+	return entries
+}
+
+func (c *compactConfig) Keys() []string {
+	return append([]string(nil), c.keys...)
+}
+
+// Get finds the entries by their canonical name too, like config.Get
+func (c *compactConfig) Get(key string) (interface{}, bool) {
+	if i := c.index(key); i >= 0 {
+		return c.value(i), true
+	}
+	return nil, false
+}
+
+// value returns the value at the position, sqlNull for the NULL values
+func (c *compactConfig) value(i int) interface{} {
+	if c.nulls[c.keys[i]] {
+		return sqlNull
+	}
+	return c.values[i]
+}
+
+func (c *compactConfig) Origin(key string) (entryOrigin, bool) {
+	if i := c.index(key); i >= 0 {
+		origin, ok := c.origins[c.keys[i]]
+		return origin, ok
+	}
+	return entryOrigin{}, false
+}
+
+// index returns the position of the key, or of the key with the same
+// canonical or current name, or -1
+func (c *compactConfig) index(key string) int {
+	if i := c.search(key); i >= 0 {
+		return i
+	}
+	canonical := variableName(key)
+	if i := c.search(canonical); i >= 0 {
+		return i
+	}
+	if i, ok := c.aliases[canonical]; ok {
+		return i
+	}
+	return -1
+}
+
+// search returns the position of the key, or -1
+func (c *compactConfig) search(key string) int {
+	i := sort.SearchStrings(c.keys, key)
+	if i < len(c.keys) && c.keys[i] == key {
+		return i
+	}
+	return -1
+}
+
+func (c *compactConfig) Type() string {
+	return c.configType
+}
+
+func (c *compactConfig) Name() string {
+	return c.name
+}
+
+func (c *compactConfig) Section() string {
+	return c.section
+}
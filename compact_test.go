@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompactConfig(t *testing.T) {
+	cfg1 := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"max_connections": []byte("151"),
+		"read_only":       "OFF",
+	}}
+	cfg2 := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"read_only": "OFF",
+	}, origins: map[string]entryOrigin{"read_only": {File: "my.cnf", Section: "mysqld", Line: 2}}}
+
+	configs := compactConfigs([]configReader{cfg1, cfg2}, newInternPool())
+
+	want := map[string]interface{}{"max_connections": "151", "read_only": "OFF"}
+	if got := configs[0].Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+	if got, ok := configs[1].Get("read_only"); !ok || got != "OFF" {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, "OFF")
+	}
+	if _, ok := configs[1].Get("max_connections"); ok {
+		t.Errorf("max_connections is not in %s", configs[1].Name())
+	}
+	if origin, ok := configs[1].Origin("read_only"); !ok || origin.Line != 2 {
+		t.Errorf("Got:\n%#v\nWant line 2\n", origin)
+	}
+	if configs[0].Name() != "db1" || configs[1].Type() != "cnf" {
+		t.Errorf("Name and type must be kept")
+	}
+}
+
+func TestCompactConfigsOnce(t *testing.T) {
+	pool := newInternPool()
+	server := newCompactConfig(&config{configType: "mysql", name: "db1", entries: map[string]interface{}{"max_connections": []byte("151")}}, pool)
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{"max-connections": "151"}}
+
+	// The servers compacted while they are read are not copied again
+	configs := compactConfigs([]configReader{server, cnf}, pool)
+	if configs[0] != configReader(server) {
+		t.Errorf("Got:\n%#v\nWant the same compacted server\n", configs[0])
+	}
+	if len(pool.strings) != 3 {
+		t.Errorf("The values must be shared. Got: %#v", pool.strings)
+	}
+
+	prepared := (&comparer{}).prepareConfig(configs[1])
+	if value, ok := prepared.get("max_connections"); !ok || value.typed.text != "151" || len(prepared.names) != 1 {
+		t.Errorf("Got:\n%#v\nWant max_connections 151\n", prepared)
+	}
+}
+
+func TestGetByVariableName(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"Max-Connections":   "500",
+		"log-slave-updates": "ON",
+		"read_only":         "OFF",
+	}}
+	// The entries added after a lookup are found too
+	cnf.Get("sync_binlog")
+	cnf.entries["Sync-Binlog"] = "1"
+
+	for _, cfg := range []configReader{cnf, newCompactConfig(cnf, newInternPool())} {
+		tests := map[string]interface{}{
+			"max_connections":     "500",
+			"MAX-CONNECTIONS":     "500",
+			"log_replica_updates": "ON",
+			"log_slave_updates":   "ON",
+			"read-only":           "OFF",
+			"sync_binlog":         "1",
+		}
+		for key, want := range tests {
+			if got, ok := cfg.Get(key); !ok || got != want {
+				t.Errorf("%T %s -- Got: %#v  --  Want: %#v\n", cfg, key, got, want)
+			}
+		}
+		if _, ok := cfg.Get("max_connect_errors"); ok {
+			t.Errorf("%T -- max_connect_errors is not set", cfg)
+		}
+	}
+}
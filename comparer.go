@@ -0,0 +1,453 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// variableNormalizers are the extra normalizers for specific variables, by
+// variableName, so the rules of the old names of the renamed variables apply
+// to the current ones
+type variableNormalizers map[string][]normalizer
+
+// comparer compares configs. The zero value uses only the default normalizers.
+// A comparer is never modified once created, so it can be used by many
+// goroutines at the same time.
+type comparer struct {
+	normalizers  variableNormalizers     // Applied before the default normalizers
+	platform     string                  // OS of the servers, for the platform defaults. Default: linux
+	ignoreVendor bool                    // Don't report the Percona Server only variables missing in community MySQL
+	ignored      variableFilter          // Variables left out of the comparison
+	only         variableFilter          // If set, the only variables compared
+	symlinks     bool                    // Resolve the symlinks of the local paths
+	tolerance    tolerance               // Margin of the numeric values
+	rules        map[string]variableRule // Of the --rules file, by variableName
+	assertions   []assertion             // Of the --rules file, checked by checkRules
+	noMissing    bool                    // Never report the missing variables
+	missingAs    *string                 // Value the missing variables are compared as, if set
+	version      string                  // Of the first server compared, for the sql_mode combination modes
+
+	// absentAsDefault compares the variables not set in the cnf files as
+	// the documented default of the defaultsVersion, the version of the
+	// servers compared
+	absentAsDefault bool
+	defaultsVersion string
+}
+
+func newComparer(opts *options) (*comparer, error) {
+	ignore := append([]string{}, opts.IgnoreVariables...)
+	if opts.IgnoreHostSpecific {
+		ignore = append(ignore, hostSpecificVariables...)
+	}
+	for _, filename := range opts.IgnoreFiles {
+		patterns, err := readIgnoreFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+		}
+		ignore = append(ignore, patterns...)
+	}
+	ignored, err := newVariableFilter(ignore)
+	if err != nil {
+		return nil, err
+	}
+	only, err := newVariableFilter(opts.Variables)
+	if err != nil {
+		return nil, err
+	}
+	tolerance, err := parseTolerance(opts.Tolerance)
+	if err != nil {
+		return nil, err
+	}
+	c := &comparer{platform: opts.Platform, ignoreVendor: opts.IgnoreVendor, ignored: ignored, only: only, symlinks: opts.ResolveSymlinks, tolerance: tolerance, noMissing: opts.NoReportMissing, absentAsDefault: opts.AbsentAsDefault}
+	if opts.missingAsSet {
+		c.missingAs = &opts.MissingAs
+	}
+
+	if opts.RulesFile != "" {
+		if c.rules, c.assertions, err = loadRules(opts.RulesFile); err != nil {
+			return nil, fmt.Errorf("Cannot read the rules of %s: %s", opts.RulesFile, err.Error())
+		}
+	}
+
+	if opts.ConfigFile != "" {
+		cfg, err := loadToolConfig(opts.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", opts.ConfigFile, err.Error())
+		}
+		if c.normalizers, err = cfg.variableNormalizers(); err != nil {
+			return nil, fmt.Errorf("Invalid normalizers in %s: %s", opts.ConfigFile, err.Error())
+		}
+	}
+
+	return c, nil
+}
+
+// normalize returns the canonical form of the value of the variable
+func (c *comparer) normalize(key string, value interface{}) interface{} {
+	return c.typedValue(key, value).text
+}
+
+// typedValue applies the custom normalizers and parses the value according
+// to the type of the variable
+func (c *comparer) typedValue(key string, value interface{}) typedValue {
+	for _, n := range c.normalizers[variableName(key)] {
+		value = n(value)
+	}
+	if variableName(key) == "sql_mode" {
+		value = expandSQLModes(valueString(value), c.version)
+	}
+
+	return parseTypedValue(key, unlimitedNormalizer(key, value))
+}
+
+// equal returns true if both values of the variable are equivalent
+func (c *comparer) equal(key string, value1, value2 interface{}) bool {
+	return c.equalValues(key, c.prepare(key, value1), c.prepare(key, value2))
+}
+
+// equalValues returns true if the prepared values are equivalent, or numbers
+// within the tolerance of the variable rule or the --tolerance
+func (c *comparer) equalValues(key string, value1, value2 preparedValue) bool {
+	if value1.equal(value2) {
+		return true
+	}
+	if rule, ok := c.rules[variableName(key)]; ok && rule.tolerance != nil {
+		return rule.tolerance.match(value1.typed, value2.typed)
+	}
+	if !c.tolerance.percent && !toleratesAbsolute(key) {
+		return false
+	}
+	return c.tolerance.match(value1.typed, value2.typed)
+}
+
+// toleratesAbsolute returns true if an absolute --tolerance applies to the
+// variable. Only the sizes and the fractional numbers get it: for integers
+// like sync_binlog or server_id any margin would hide a real difference, so
+// they need a per variable rule.
+func toleratesAbsolute(key string) bool {
+	info, ok := getVariableInfo(key)
+	return ok && (info.Type == "size" || info.Type == "numeric")
+}
+
+// variableName is the name a variable is compared by, its rule alias if any
+func (c *comparer) variableName(key string) string {
+	name := variableName(key)
+	if rule, ok := c.rules[name]; ok && rule.Alias != "" {
+		return rule.Alias
+	}
+	return name
+}
+
+// preparedValue is a value normalized once, so it can be compared against the
+// values of many sources without normalizing it again.
+type preparedValue struct {
+	raw       interface{}
+	typed     typedValue
+	autoSized bool
+	isDefault bool
+}
+
+func (c *comparer) prepare(key string, value interface{}) preparedValue {
+	return preparedValue{
+		raw:       value,
+		typed:     c.typedValue(key, value),
+		autoSized: isAutoSized(key, value),
+		isDefault: c.isDefault(key, value),
+	}
+}
+
+func (v preparedValue) equal(other preparedValue) bool {
+	if v.autoSized || other.autoSized {
+		return true
+	}
+	if v.isDefault && other.isDefault {
+		return true
+	}
+	return v.typed.equal(other.typed)
+}
+
+// preparedConfig has the prepared values of a config, sorted by the name they
+// are compared by. Slices take much less memory than maps when the configs of
+// a whole fleet are prepared at the same time, like for --pairwise.
+type preparedConfig struct {
+	configType string
+	community  bool            // A MySQL server other than Percona Server
+	names      []string        // Sorted
+	values     []preparedValue // Of the names
+}
+
+// get returns the prepared value of a variable by the name it is compared by
+func (p preparedConfig) get(name string) (preparedValue, bool) {
+	i := sort.SearchStrings(p.names, name)
+	if i < len(p.names) && p.names[i] == name {
+		return p.values[i], true
+	}
+	return preparedValue{}, false
+}
+
+func (c *comparer) prepareConfig(cfg configReader) preparedConfig {
+	keys := cfg.Keys()
+	prepared := preparedConfig{
+		configType: cfg.Type(),
+		community:  cfg.Type() == "mysql" && !isPerconaServer(cfg),
+	}
+	datadir := ""
+	if value, ok := cfg.Get("datadir"); ok {
+		datadir = cleanPath(valueString(value))
+	}
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		names[canonicalName(key)] = true
+	}
+
+	type namedValue struct {
+		name  string
+		value preparedValue
+	}
+	values := make([]namedValue, 0, len(keys))
+	for _, key := range keys {
+		if !c.compared(key) {
+			continue
+		}
+		value, _ := cfg.Get(key)
+		p, name := preparedValue{}, c.variableName(key)
+		if name == canonicalName(key) {
+			p = c.prepare(key, value)
+		} else if names[name] {
+			// Servers reporting both names are compared by the current one
+			continue
+		} else {
+			p = c.prepare(name, value)
+		}
+		if p.typed.kind == pathKind {
+			p.typed.text = c.resolvePath(p.typed.text, datadir)
+		}
+		values = append(values, namedValue{name: name, value: p})
+	}
+	// A name set by several keys, like max-connections and max_connections,
+	// keeps a single value
+	sort.SliceStable(values, func(i, j int) bool { return values[i].name < values[j].name })
+	prepared.names = make([]string, 0, len(values))
+	prepared.values = make([]preparedValue, 0, len(values))
+	for i, v := range values {
+		if i+1 < len(values) && values[i+1].name == v.name {
+			continue
+		}
+		prepared.names = append(prepared.names, v.name)
+		prepared.values = append(prepared.values, v.value)
+	}
+
+	// The servers report the size they use
+	i := sort.SearchStrings(prepared.names, "innodb_buffer_pool_size")
+	if i < len(prepared.names) && prepared.names[i] == "innodb_buffer_pool_size" {
+		p := &prepared.values[i]
+		if p.typed.isNumber() && !p.autoSized && !reportsAllVariables(cfg.Type()) {
+			size := bufferPoolSize(p.typed.number, configNumber(cfg, "innodb_buffer_pool_chunk_size"), configNumber(cfg, "innodb_buffer_pool_instances"))
+			p.typed = typedValue{kind: integerKind, text: strconv.FormatFloat(size, 'f', 0, 64), number: size}
+		}
+	}
+	return prepared
+}
+
+// bufferPoolSize returns the innodb_buffer_pool_size the server uses for the
+// configured size: the size rounded up to a multiple of the chunk size times
+// the instances. Without them, the chunks are of 128M and there are 8
+// instances for the buffer pools of 1G or more. The server reduces the chunk
+// size instead if the chunks don't fit in the buffer pool.
+func bufferPoolSize(size, chunkSize, instances float64) float64 {
+	if chunkSize <= 0 {
+		chunkSize = float64(128 * sizeMultipliers["M"])
+	}
+	if instances <= 0 {
+		instances = 1
+		if size >= float64(sizeMultipliers["G"]) {
+			instances = 8
+		}
+	}
+	unit := chunkSize * instances
+	if unit > size {
+		return size
+	}
+	return math.Ceil(size/unit) * unit
+}
+
+// configNumber returns the numeric value of a variable of the config, or 0
+func configNumber(cfg configReader, name string) float64 {
+	value, ok := cfg.Get(name)
+	if !ok {
+		return 0
+	}
+	return parseTypedValue(name, value).number
+}
+
+// resolvePath makes the relative paths absolute with the datadir, like mysqld
+// does with the log files, and resolves the symlinks if asked to
+func (c *comparer) resolvePath(path, datadir string) string {
+	if path != "" && !filepath.IsAbs(path) && filepath.IsAbs(datadir) {
+		path = filepath.Join(datadir, path)
+	}
+	if c.symlinks && filepath.IsAbs(path) {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return resolved
+		}
+		// Files like the sockets only exist while the server runs
+		if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+			return filepath.Join(dir, filepath.Base(path))
+		}
+	}
+	return path
+}
+
+// withServerVersion returns the comparer with the version of the first server
+// of the configs, also as the version of the defaults of the absent variables
+func (c *comparer) withServerVersion(configs []configReader) *comparer {
+	copy := *c
+	copy.version = serverVersion(configs)
+	if copy.absentAsDefault && copy.defaultsVersion == "" {
+		copy.defaultsVersion = copy.version
+	}
+	return &copy
+}
+
+// absentDefault returns the documented default of a variable not set in a
+// config, if it is a cnf (servers report all their variables) and the
+// defaults are used for the absent variables
+func (c *comparer) absentDefault(key, configType string) (preparedValue, bool) {
+	if !c.absentAsDefault || c.defaultsVersion == "" || reportsAllVariables(configType) {
+		return preparedValue{}, false
+	}
+	info, ok := getVariableInfo(key)
+	if !ok {
+		return preparedValue{}, false
+	}
+	value, ok := defaultForVersion(info, c.defaultsVersion)
+	if !ok {
+		return preparedValue{}, false
+	}
+	return c.prepare(key, value), true
+}
+
+// reportMissing returns true if the variable, set to the value in a config,
+// is reported as missing in the configs without it
+func (c *comparer) reportMissing(key string, value preparedValue) bool {
+	if c.noMissing {
+		return false
+	}
+	if c.missingAs != nil {
+		return !c.equalValues(key, value, c.prepare(key, *c.missingAs))
+	}
+	return true
+}
+
+// compared returns false for the variables left out by --ignore-variables or
+// an ignore rule, or not in --variables
+func (c *comparer) compared(key string) bool {
+	return !c.ignored.match(key) && !c.rules[variableName(key)].Ignore && (len(c.only) == 0 || c.only.match(key))
+}
+
+// isDefault returns true if the value is the platform default for the
+// variable, so it is the same as not setting it
+func (c *comparer) isDefault(key string, value interface{}) bool {
+	platform := c.platform
+	if platform == "" {
+		platform = "linux"
+	}
+	return isPlatformDefault(key, platform, value)
+}
+
+// toolConfig is the content of the --config file
+type toolConfig struct {
+	Normalizers map[string][]normalizerRule `yaml:"normalizers"`
+}
+
+// normalizerRule is a user defined normalizer. Only one of its fields should
+// be set:
+//
+//	normalizers:
+//	  log_error:
+//	    - regex: 'db[0-9]+\.example\.com'
+//	      replace: HOST
+//	  innodb_flush_method:
+//	    - map: {"": fsync}
+//	  datadir:
+//	    - type: path
+type normalizerRule struct {
+	Regex   string            `yaml:"regex"`
+	Replace string            `yaml:"replace"`
+	Map     map[string]string `yaml:"map"`
+	Type    string            `yaml:"type"` // set or path
+}
+
+func loadToolConfig(filename string) (*toolConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &toolConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (t *toolConfig) variableNormalizers() (variableNormalizers, error) {
+	normalizers := make(variableNormalizers)
+
+	for variable, rules := range t.Normalizers {
+		for _, rule := range rules {
+			n, err := rule.normalizer()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", variable, err.Error())
+			}
+			name := variableName(variable)
+			normalizers[name] = append(normalizers[name], n)
+		}
+	}
+
+	return normalizers, nil
+}
+
+func (r normalizerRule) normalizer() (normalizer, error) {
+	switch {
+	case r.Regex != "":
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, err
+		}
+		return func(value interface{}) interface{} {
+			return re.ReplaceAllString(valueString(value), r.Replace)
+		}, nil
+	case r.Map != nil:
+		return func(value interface{}) interface{} {
+			if mapped, ok := r.Map[valueString(value)]; ok {
+				return mapped
+			}
+			return value
+		}, nil
+	case r.Type == "set":
+		return setsNormalizer, nil
+	case r.Type == "path":
+		return pathNormalizer, nil
+	default:
+		return nil, fmt.Errorf("Invalid rule: %+v", r)
+	}
+}
+
+// pathNormalizer makes the value a path, compared like the file and directory
+// variables: /var/lib/mysql/ and /var/lib//mysql are the same as
+// /var/lib/mysql, and the relative paths are resolved with the datadir
+func pathNormalizer(value interface{}) interface{} {
+	if isNull(value) {
+		return value
+	}
+	return pathValue(valueString(value))
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCustomNormalizers(t *testing.T) {
+	cmp, err := newComparer(&options{ConfigFile: "./test/tool-config.yaml"})
+	if err != nil {
+		t.Fatalf("Shouldn't return error reading a valid config: %s", err.Error())
+	}
+
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"log-error":           "/var/log/mysql/db01-error.log",
+		"innodb_flush_method": "",
+		"datadir":             "/var/lib/mysql/",
+		"tmpdir":              "/tmp/",
+	}}
+	cfg2 := &config{configType: "cnf", entries: map[string]interface{}{
+		"log-error":           "/var/log/mysql/db02-error.log",
+		"innodb_flush_method": "fsync",
+		"datadir":             "/var/lib/mysql",
+		"tmpdir":              "/var/tmp",
+	}}
+
+	want := map[string][]interface{}{
+		"tmpdir": []interface{}{"/tmp/", "/var/tmp"},
+	}
+
+	got := cmp.compare([]configReader{cfg1, cfg2})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n %#v\n", got, want)
+	}
+
+	if _, err := (&toolConfig{Normalizers: map[string][]normalizerRule{"x": {{Type: "unknown"}}}}).variableNormalizers(); err == nil {
+		t.Error("Should return error on invalid rules")
+	}
+}
+
+func TestCustomNormalizersTypes(t *testing.T) {
+	normalizers, err := (&toolConfig{Normalizers: map[string][]normalizerRule{
+		"my_plugin_log": {{Type: "path"}},
+		"tx_isolation":  {{Map: map[string]string{"RC": "READ-COMMITTED"}}},
+	}}).variableNormalizers()
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	cmp := &comparer{normalizers: normalizers}
+
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"my_plugin_log": []byte("logs/plugin.log"),
+		"tx_isolation":  "RC",
+	}}
+	cfg2 := &config{configType: "cnf", entries: map[string]interface{}{
+		"my_plugin_log":         "/var/lib/mysql/logs//plugin.log",
+		"transaction_isolation": "READ-COMMITTED",
+	}}
+
+	// The relative path is the same as the absolute one, and the rule of the
+	// old name applies to the new one
+	if got := cmp.compare([]configReader{cfg1, cfg2}); len(got) != 0 {
+		t.Errorf("Got:\n%#v\nWant no differences\n", got)
+	}
+}
+
+func TestPathValues(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "data"), 0755)
+	os.Symlink(filepath.Join(dir, "data"), filepath.Join(dir, "mysql"))
+
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"datadir":   dir + "/mysql/",
+		"log_error": "./db01.err",
+		"relay_log": "relay-bin",
+		"tmpdir":    "/tmp//",
+	}}
+	cfg2 := &config{configType: "cnf", entries: map[string]interface{}{
+		"datadir":   dir + "/data",
+		"log_error": dir + "/data/db01.err",
+		"tmpdir":    "/tmp",
+	}}
+	cfg3 := &config{configType: "cnf", entries: map[string]interface{}{
+		"relay_log": "/var/lib/mysql/relay-bin",
+	}}
+
+	want := map[string][]interface{}{
+		"datadir":   {dir + "/mysql/", dir + "/data"},
+		"log_error": {"./db01.err", dir + "/data/db01.err"},
+		"relay_log": {"relay-bin", missing},
+	}
+	if got := compare([]configReader{cfg1, cfg2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// Relative paths of configs without datadir match the absolute ones
+	if got := compare([]configReader{&config{configType: "cnf", entries: map[string]interface{}{"relay_log": "./relay-bin"}}, cfg3}); len(got) != 0 {
+		t.Errorf("Got:\n%#v\nWant no differences\n", got)
+	}
+
+	cmp, _ := newComparer(&options{ResolveSymlinks: true})
+	delete(want, "datadir")
+	delete(want, "log_error")
+	if got := cmp.compare([]configReader{cfg1, cfg2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
+
+func TestVariableNameMatching(t *testing.T) {
+	options, err := parseOptionFile("my.cnf", strings.NewReader("[mysqld]\nSkip_Name_Resolve = OFF\nmax-connections = 500\nskip-name-resolve\n"), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+	cnf := mergeOptions(options, "mysqld")
+	if want := map[string]interface{}{"skip-name-resolve": "true", "max-connections": "500"}; !reflect.DeepEqual(cnf.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cnf.Entries(), want)
+	}
+	if origin, ok := cnf.Origin("skip_name_resolve"); !ok || origin.Line != 4 {
+		t.Errorf("skip_name_resolve should be found in line 4. Got: %#v", origin)
+	}
+
+	server := &config{configType: "mysql", entries: map[string]interface{}{"skip_name_resolve": "ON", "max_connections": "151"}}
+	want := map[string][]interface{}{"max_connections": {"500", "151"}}
+	if got := compare([]configReader{cnf, server}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+	if got := compare(compactConfigs([]configReader{cnf, server}, newInternPool())); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
+
+func TestVariableAliases(t *testing.T) {
+	mysql57 := &config{configType: "mysql", entries: map[string]interface{}{
+		"tx_isolation":           "READ-COMMITTED",
+		"slave_parallel_workers": "4",
+		"key_buffer_size":        "67108864",
+	}}
+	mysql80 := &config{configType: "mysql", entries: map[string]interface{}{
+		"transaction_isolation":    "READ-COMMITTED",
+		"replica_parallel_workers": "8",
+		"slave_parallel_workers":   "8",
+		"key_buffer_size":          "67108864",
+	}}
+	want := map[string][]interface{}{"replica_parallel_workers": {"4", "8"}}
+	if got := compare([]configReader{mysql57, mysql80}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	options, err := parseOptionFile("my.cnf", strings.NewReader("[mysqld]\nkey-buffer = 64M\ntx-isolation = READ-COMMITTED\n"), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+	cnf := mergeOptions(options, "mysqld")
+	if got := compare([]configReader{cnf, mysql80}); len(got) != 0 {
+		t.Errorf("The renamed variables should be equal. Got: %#v", got)
+	}
+	if origin, ok := cnf.Origin("key_buffer_size"); !ok || origin.Line != 2 {
+		t.Errorf("key_buffer_size should be found in line 2. Got: %#v", origin)
+	}
+}
+
+func TestRenamedVariablesMetadata(t *testing.T) {
+	options, err := parseOptionFile("my.cnf", strings.NewReader("[mysqld]\nlog_slave_updates = 1\n"), true)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+	cnf := mergeOptions(options, "mysqld")
+	mysql57 := &config{configType: "mysql", entries: map[string]interface{}{"log_slave_updates": "ON"}}
+	mysql80 := &config{configType: "mysql", entries: map[string]interface{}{"log_replica_updates": "ON", "log_slave_updates": "ON"}}
+	for _, server := range []configReader{mysql57, mysql80} {
+		if got := compare([]configReader{cnf, server}); len(got) != 0 {
+			t.Errorf("The renamed booleans should be equal. Got: %#v", got)
+		}
+	}
+	if isDynamic("log_replica_updates") {
+		t.Errorf("log_replica_updates should need a restart like log_slave_updates")
+	}
+}
+
+func TestSentinelValues(t *testing.T) {
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"max_execution_time":       "0",
+		"max_join_size":            "4294967295",
+		"thread_cache_size":        "-1",
+		"performance_schema_hosts": "-1",
+		"max_connections":          "0",
+	}}
+	cfg2 := &config{configType: "mysql", entries: map[string]interface{}{
+		"max_execution_time":       "18446744073709551615",
+		"max_join_size":            "18446744073709551615",
+		"thread_cache_size":        "9",
+		"performance_schema_hosts": "100",
+		"max_connections":          "151",
+	}}
+
+	want := map[string][]interface{}{
+		"max_connections": []interface{}{"0", "151"},
+	}
+
+	got := compare([]configReader{cfg1, cfg2})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n %#v\n", got, want)
+	}
+}
+
+func TestPlatformDefaults(t *testing.T) {
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"innodb_flush_method": "",
+		"key_buffer_size":     "1G",
+	}}
+	cfg2 := &config{configType: "cnf", entries: map[string]interface{}{
+		"innodb_flush_method":    "fsync",
+		"lower_case_table_names": "0",
+		"key_buffer_size":        "1G",
+	}}
+
+	got := compare([]configReader{cfg1, cfg2})
+	if len(got) != 0 {
+		t.Errorf("Platform defaults must be equal to unset values. Got:\n%#v\n", got)
+	}
+
+	want := map[string][]interface{}{
+		"innodb_flush_method":    []interface{}{"", "fsync"},
+		"lower_case_table_names": []interface{}{missing, "0"},
+	}
+	got = (&comparer{platform: "windows"}).compare([]configReader{cfg1, cfg2})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n %#v\n", got, want)
+	}
+}
+
+// fleetConfigs returns sources cnf-like configs with variables entries each,
+// every source differing from the base in a few values.
+func fleetConfigs(sources, variables int) []configReader {
+	configs := make([]configReader, 0, sources)
+	for i := 0; i < sources; i++ {
+		entries := make(map[string]interface{}, variables)
+		for j := 0; j < variables; j++ {
+			entries[fmt.Sprintf("variable_%d", j)] = fmt.Sprintf("%dM", j)
+		}
+		entries["sql_mode"] = "STRICT_TRANS_TABLES,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO"
+		entries["max_connections"] = fmt.Sprintf("%d", 100+i%3)
+		configs = append(configs, &config{configType: "cnf", name: fmt.Sprintf("db%d", i), entries: entries})
+	}
+	return configs
+}
+
+func BenchmarkCompare(b *testing.B) {
+	configs := fleetConfigs(50, 500)
+	cmp := &comparer{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if diffs := cmp.compare(configs); len(diffs) != 1 {
+			b.Fatalf("Got %d diffs, want 1", len(diffs))
+		}
+	}
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	values := []interface{}{"128M", "0010.000", "IGNORE_SPACE,NO_ZERO_IN_DATE", "/var/lib/mysql", []byte("ON")}
+	cmp := &comparer{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, value := range values {
+			cmp.normalize("key_buffer_size", value)
+		}
+	}
+}
+
+func TestReportIdentical(t *testing.T) {
+	cnf := &config{configType: "cnf", entries: map[string]interface{}{"max_connections": "500", "sync_binlog": "1", "key_buffer_size": "64M"}}
+	server := &config{configType: "mysql", entries: map[string]interface{}{"max_connections": "151", "sync_binlog": "1", "key_buffer_size": "67108864", "port": "3306"}}
+
+	cmp := &comparer{}
+	diffs := cmp.compare([]configReader{cnf, server})
+	identical := cmp.identical([]configReader{cnf, server}, diffs)
+	want := map[string][]interface{}{"sync_binlog": {"1", "1"}, "key_buffer_size": {"64M", "67108864"}}
+	if !reflect.DeepEqual(identical, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", identical, want)
+	}
+
+	got, _ := (&plainOutput{identical: identical}).Format(diffs)
+	wantText := fmt.Sprintf("%35s: %40s : %40s\n", "max_connections", "500", "151") +
+		fmt.Sprintf("%35s: %40s : %40s  (equal)\n", "key_buffer_size", "64M", "67108864") +
+		fmt.Sprintf("%35s: %40s : %40s  (equal)\n", "sync_binlog", "1", "1")
+	if got != wantText {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantText)
+	}
+
+	gotJSON, _ := (&jsonOutput{identical: map[string][]interface{}{"sync_binlog": {"1", "1"}}}).Format(map[string][]interface{}{})
+	if wantJSON := `{"sync_binlog":{"values":["1","1"],"status":"equal"}}`; gotJSON != wantJSON {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", gotJSON, wantJSON)
+	}
+}
+
+func TestMissingHandling(t *testing.T) {
+	cnf1 := &config{configType: "cnf", entries: map[string]interface{}{"max_connections": "500", "custom_counter": "0", "sync_binlog": "1"}}
+	cnf2 := &config{configType: "cnf", entries: map[string]interface{}{"max_connections": "151"}}
+
+	want := map[string][]interface{}{"max_connections": {"500", "151"}}
+	if got := (&comparer{noMissing: true}).compare([]configReader{cnf1, cnf2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	zero := "0"
+	want = map[string][]interface{}{"max_connections": {"500", "151"}, "sync_binlog": {"1", missing}}
+	if got := (&comparer{missingAs: &zero}).compare([]configReader{cnf1, cnf2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	opts, err := processParams(context.Background(), []string{"--missing-as", ""})
+	if err != nil || !opts.missingAsSet {
+		t.Errorf("An empty --missing-as should be set")
+	}
+}
+
+func TestAbsentAsDefault(t *testing.T) {
+	cnf := &config{configType: "cnf", entries: map[string]interface{}{"max_connections": "500"}}
+	server := &config{configType: "mysql", entries: map[string]interface{}{
+		"version":            "8.0.36",
+		"max_connections":    "151",
+		"max_allowed_packet": "67108864",
+		"sync_binlog":        "0",
+	}}
+
+	cmp := &comparer{absentAsDefault: true}
+	want := map[string][]interface{}{
+		"max_connections": {"500", "151"},
+		"sync_binlog":     {"1", "0"},
+	}
+	if got := cmp.compare([]configReader{cnf, server}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	cnf2 := &config{configType: "cnf", entries: map[string]interface{}{"max_connections": "151", "sync_binlog": "1"}}
+	// sync_binlog is the default in both cnfs, but not in the server
+	want = map[string][]interface{}{
+		"max_connections": {"151", "500"},
+		"sync_binlog":     {"1", "0"},
+	}
+	if got := cmp.compare([]configReader{cnf2, cnf, server}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
+
+func TestSQLModeCombinations(t *testing.T) {
+	cmp := &comparer{}
+	tests := []struct {
+		value1, value2 string
+		want           bool
+	}{
+		{"TRADITIONAL", "STRICT_TRANS_TABLES,STRICT_ALL_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION", true},
+		{"traditional", "STRICT_TRANS_TABLES,STRICT_ALL_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,TRADITIONAL,NO_ENGINE_SUBSTITUTION", true},
+		{"ANSI,NO_ENGINE_SUBSTITUTION", "NO_ENGINE_SUBSTITUTION,REAL_AS_FLOAT,PIPES_AS_CONCAT,ANSI_QUOTES,IGNORE_SPACE,ONLY_FULL_GROUP_BY", true},
+		{"ANSI", "REAL_AS_FLOAT,PIPES_AS_CONCAT,ANSI_QUOTES,IGNORE_SPACE", false},
+		{"TRADITIONAL", "STRICT_TRANS_TABLES", false},
+	}
+	for _, test := range tests {
+		if got := cmp.equal("sql_mode", test.value1, test.value2); got != test.want {
+			t.Errorf("%s = %s  --  Got: %v  --  Want: %v\n", test.value1, test.value2, got, test.want)
+		}
+	}
+
+	// 5.7 servers add NO_AUTO_CREATE_USER to TRADITIONAL
+	server := &config{configType: "mysql", entries: map[string]interface{}{
+		"version":  "5.7.44",
+		"sql_mode": "STRICT_TRANS_TABLES,STRICT_ALL_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,TRADITIONAL,NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION",
+	}}
+	cnf := &config{configType: "cnf", entries: map[string]interface{}{"sql_mode": "TRADITIONAL"}}
+	if got := cmp.compare([]configReader{cnf, server}); len(got) != 0 {
+		t.Errorf("Got:\n%#v\nWant no differences\n", got)
+	}
+}
+
+func TestBufferPoolRounding(t *testing.T) {
+	cmp := &comparer{}
+	tests := []struct {
+		cnf  map[string]interface{}
+		size string // Reported by the server
+		want bool
+	}{
+		{map[string]interface{}{"innodb_buffer_pool_size": "1G"}, "1073741824", true},
+		{map[string]interface{}{"innodb_buffer_pool_size": "1024m"}, "1073741824", true},
+		// 1 instance of 128M chunks
+		{map[string]interface{}{"innodb_buffer_pool_size": "1000M"}, "1073741824", true},
+		// 8 instances of 128M chunks
+		{map[string]interface{}{"innodb_buffer_pool_size": "1100M"}, "2147483648", true},
+		{map[string]interface{}{"innodb_buffer_pool_size": "1100M", "innodb_buffer_pool_instances": "1"}, "1207959552", true},
+		{map[string]interface{}{"innodb_buffer_pool_size": "1100M", "innodb-buffer-pool-chunk-size": "64M", "innodb_buffer_pool_instances": "2"}, "1207959552", true},
+		// Smaller than a chunk
+		{map[string]interface{}{"innodb_buffer_pool_size": "64M"}, "67108864", true},
+		{map[string]interface{}{"innodb_buffer_pool_size": "2G"}, "1073741824", false},
+	}
+	for _, test := range tests {
+		cnf := &config{configType: "cnf", entries: test.cnf}
+		server := &config{configType: "mysql", entries: map[string]interface{}{"innodb_buffer_pool_size": test.size}}
+		_, different := cmp.compare([]configReader{cnf, server})["innodb_buffer_pool_size"]
+		if different == test.want {
+			t.Errorf("%v vs %s  --  Got equal: %v  --  Want: %v\n", test.cnf, test.size, !different, test.want)
+		}
+	}
+}
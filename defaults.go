@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultChange is a variable whose documented default is different in two
+// versions, and what the cnf files set it to.
+type defaultChange struct {
+	Variable string        `json:"variable"`
+	From     string        `json:"from"` // Default in the old version
+	To       string        `json:"to"`   // Default in the new version
+	Pinned   []pinnedValue `json:"cnfs,omitempty"`
+}
+
+// pinnedValue is the value of a changed variable in a cnf file. Variables not
+// set in the cnf will silently take the new default.
+type pinnedValue struct {
+	Source string       `json:"source"`
+	Set    bool         `json:"set"`
+	Value  interface{}  `json:"value,omitempty"`
+	Origin *entryOrigin `json:"origin,omitempty"`
+}
+
+// knownVersions returns the major.minor versions we have defaults for
+func knownVersions() []string {
+	seen := make(map[string]bool)
+	for _, info := range variablesMetadata {
+		for version := range info.Defaults {
+			seen[version] = true
+		}
+	}
+	versions := make([]string, 0, len(seen))
+	for version := range seen {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+func isKnownVersion(version string) bool {
+	for _, known := range knownVersions() {
+		if known == version {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersionPair validates a pair of versions like 5.7 and 8.0
+func parseVersionPair(from, to string) error {
+	for _, version := range []string{from, to} {
+		if !isKnownVersion(version) {
+			return fmt.Errorf("Unknown version %s. Known versions are: %s", version, strings.Join(knownVersions(), ", "))
+		}
+	}
+	return nil
+}
+
+// changedDefaults returns the variables, sorted by name, documented in both
+// versions with a different default. Variables added or removed between the
+// versions are not listed.
+func changedDefaults(from, to string) []defaultChange {
+	var changes []defaultChange
+	for name, info := range variablesMetadata {
+		oldDefault, ok1 := info.Defaults[from]
+		newDefault, ok2 := info.Defaults[to]
+		if !ok1 || !ok2 || parseTypedValue(name, oldDefault).equal(parseTypedValue(name, newDefault)) {
+			continue
+		}
+		changes = append(changes, defaultChange{Variable: name, From: oldDefault, To: newDefault})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Variable < changes[j].Variable })
+	return changes
+}
+
+// defaultChangesByVariable indexes the changed defaults, for the annotations
+// of the diffs. The value is like 5.7:8.0.
+func defaultChangesByVariable(value string) (map[string]defaultChange, error) {
+	if value == "" {
+		return nil, nil
+	}
+	versions := strings.Split(value, ":")
+	if len(versions) != 2 {
+		return nil, fmt.Errorf("Invalid version pair %s. Use from:to, like 5.7:8.0", value)
+	}
+	if err := parseVersionPair(versions[0], versions[1]); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]defaultChange)
+	for _, change := range changedDefaults(versions[0], versions[1]) {
+		changes[change.Variable] = change
+	}
+	return changes, nil
+}
+
+// documentedDefaults returns, as a config, the documented defaults of the
+// variables of the catalog for a version like 8.0.36, matched by major.minor.
+// It is nil without a version.
+func documentedDefaults(version string) (configReader, error) {
+	if version == "" {
+		return nil, nil
+	}
+	mm := majorMinor(version)
+	if !isKnownVersion(mm) {
+		return nil, fmt.Errorf("Unknown version %s. Known versions are: %s", version, strings.Join(knownVersions(), ", "))
+	}
+
+	cfg := &config{
+		configType: "documented-defaults",
+		name:       "defaults-" + version,
+		entries:    make(map[string]interface{}),
+	}
+	for name, info := range variablesMetadata {
+		if value, ok := info.Defaults[mm]; ok {
+			cfg.entries[name] = value
+		}
+	}
+	return cfg, nil
+}
+
+// pinDefaultChanges adds, to every change, the value set in each cnf
+func pinDefaultChanges(changes []defaultChange, configs []configReader) {
+	for i := range changes {
+		for _, cfg := range configs {
+			if cfg.Type() != "cnf" {
+				continue
+			}
+			item := pinnedValue{Source: cfg.Name()}
+			if value, key, ok := lookupVariable(cfg, changes[i].Variable); ok {
+				item.Set, item.Value = true, valueString(value)
+				if origin, ok := cfg.Origin(key); ok {
+					item.Origin = &origin
+				}
+			}
+			changes[i].Pinned = append(changes[i].Pinned, item)
+		}
+	}
+}
+
+// runDefaultsDiff lists the variables whose default changed between two
+// versions, like defaults-diff 5.7 8.0, and whether the --cnf files pin them,
+// so upgrades don't change behaviors without notice.
+func runDefaultsDiff(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if len(opts.args) != 2 {
+		return "", fmt.Errorf("defaults-diff needs two versions, like defaults-diff 5.7 8.0")
+	}
+	if err := parseVersionPair(opts.args[0], opts.args[1]); err != nil {
+		return "", err
+	}
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	changes := changedDefaults(opts.args[0], opts.args[1])
+	pinDefaultChanges(changes, configs)
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(changes, "", "\t")
+		} else {
+			output, err = json.Marshal(changes)
+		}
+		return string(output), err
+	case "plain":
+		return formatDefaultChanges(opts.args[0], opts.args[1], changes), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for defaults-diff", opts.OutputFmt)
+	}
+}
+
+func formatDefaultChanges(from, to string, changes []defaultChange) string {
+	var buffer bytes.Buffer
+
+	for _, change := range changes {
+		buffer.WriteString(fmt.Sprintf("%35s: %s=%s -> %s=%s\n", change.Variable, from, change.From, to, change.To))
+		for _, item := range change.Pinned {
+			if !item.Set {
+				buffer.WriteString(fmt.Sprintf("%35s  not set in %s: changes to %s\n", "", item.Source, change.To))
+				continue
+			}
+			line := fmt.Sprintf("%35s  pinned to %s in %s", "", item.Value, item.Source)
+			if item.Origin != nil {
+				line += fmt.Sprintf(" (set at %s)", item.Origin)
+			}
+			buffer.WriteString(line + "\n")
+		}
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestChangedDefaults(t *testing.T) {
+	changes := make(map[string]defaultChange)
+	for _, change := range changedDefaults("5.7", "8.0") {
+		changes[change.Variable] = change
+	}
+
+	want := defaultChange{Variable: "max_allowed_packet", From: "4194304", To: "67108864"}
+	if got := changes["max_allowed_packet"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+	if _, ok := changes["innodb_buffer_pool_size"]; ok {
+		t.Errorf("innodb_buffer_pool_size has the same default in 5.7 and 8.0")
+	}
+
+	if err := parseVersionPair("5.7", "9.9"); err == nil {
+		t.Errorf("Should return an error for unknown versions")
+	}
+}
+
+func TestDefaultsDiff(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf",
+		entries: map[string]interface{}{"max-allowed-packet": "64M"},
+		origins: map[string]entryOrigin{"max-allowed-packet": {File: "my.cnf", Section: "mysqld", Line: 3}},
+	}
+
+	changes := []defaultChange{
+		{Variable: "event_scheduler", From: "OFF", To: "ON"},
+		{Variable: "max_allowed_packet", From: "4194304", To: "67108864"},
+	}
+	pinDefaultChanges(changes, []configReader{cnf})
+
+	got := formatDefaultChanges("5.7", "8.0", changes)
+	want := fmt.Sprintf("%35s: %s\n", "event_scheduler", "5.7=OFF -> 8.0=ON") +
+		fmt.Sprintf("%35s  %s\n", "", "not set in my.cnf: changes to ON") +
+		fmt.Sprintf("%35s: %s\n", "max_allowed_packet", "5.7=4194304 -> 8.0=67108864") +
+		fmt.Sprintf("%35s  %s\n", "", "pinned to 64M in my.cnf (set at my.cnf:3 [mysqld])")
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf}, nil
+	}
+	if _, err := runDefaultsDiff(context.Background(), &options{OutputFmt: "plain", args: []string{"5.7"}}, loadConfigs); err == nil {
+		t.Errorf("Should return an error without two versions")
+	}
+	if _, err := runDefaultsDiff(context.Background(), &options{OutputFmt: "json", args: []string{"5.7", "8.0"}}, loadConfigs); err != nil {
+		t.Errorf("Shouldn't return error: %s", err.Error())
+	}
+}
+
+func TestDefaultChangesAnnotation(t *testing.T) {
+	formatter, err := getOutputFormatter(&options{OutputFmt: "plain", DefaultChanges: "5.7:8.0"}, nil, nil)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	got, _ := formatter.Format(map[string][]interface{}{"max_allowed_packet": {"4M", "16M"}})
+	want := fmt.Sprintf("%35s: %40s : %40s\n", "max_allowed_packet", "4M", "16M") +
+		fmt.Sprintf("%35s  %s\n", "", "default changes from 4194304 to 67108864")
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	if _, err := getOutputFormatter(&options{OutputFmt: "plain", DefaultChanges: "8.0"}, nil, nil); err == nil {
+		t.Errorf("Should return an error for an invalid version pair")
+	}
+}
+
+func TestAgainstDefaults(t *testing.T) {
+	defaults, err := documentedDefaults("8.0.36")
+	if err != nil {
+		t.Fatalf("Shouldn't return error for a known version: %s", err.Error())
+	}
+	if value, ok := defaults.Get("max_allowed_packet"); !ok || value != "67108864" {
+		t.Errorf("Got: %v  --  Want: %s\n", value, "67108864")
+	}
+
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"max_allowed_packet": "67108864",
+		"max_connections":    "500",
+		"server_id":          "12",
+	}}
+	cnf := &config{configType: "cnf", entries: map[string]interface{}{"sync-binlog": "0"}}
+	want := map[string][]interface{}{
+		"max_connections": {"151", "500"},
+		"server_id":       {"1", "12"},
+		"sync_binlog":     {"1", "0"},
+	}
+	if got := compare([]configReader{defaults, server, cnf}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := documentedDefaults("9.9.1"); err == nil {
+		t.Errorf("Should return an error for unknown versions")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// numericDelta is how much the second value of a diff differs from the
+// first one, for --show-delta. Ratio is nil when the first value is 0.
+type numericDelta struct {
+	Delta float64
+	Ratio *float64
+}
+
+// getNumericDelta returns the delta of the values of a variable, if both are
+// numbers. The auto-sized values, like -1, are not numbers of the variable.
+func getNumericDelta(key string, values []interface{}) (numericDelta, bool) {
+	if len(values) < 2 {
+		return numericDelta{}, false
+	}
+	var numbers [2]float64
+	for i, value := range values[:2] {
+		if isMissing(value) || isNull(value) || isAutoSized(key, value) {
+			return numericDelta{}, false
+		}
+		typed := parseTypedValue(key, value)
+		if !typed.isNumber() {
+			return numericDelta{}, false
+		}
+		numbers[i] = typed.number
+	}
+
+	delta := numericDelta{Delta: numbers[1] - numbers[0]}
+	if numbers[0] != 0 {
+		ratio := numbers[1] / numbers[0]
+		delta.Ratio = &ratio
+	}
+	return delta, true
+}
+
+// text shows the delta like +56G (8x), with the K, M, G or T suffix for the
+// sizes
+func (d numericDelta) text(key string) string {
+	sign := "+"
+	if d.Delta < 0 {
+		sign = "-"
+	}
+	text := strconv.FormatFloat(math.Abs(d.Delta), 'f', -1, 64)
+	if info, ok := getVariableInfo(key); ok && info.Type == "size" {
+		text = formatBytes(math.Abs(d.Delta))
+	}
+	text = sign + text
+
+	if d.Ratio != nil {
+		text += fmt.Sprintf(" (%sx)", strconv.FormatFloat(math.Round(*d.Ratio*100)/100, 'f', -1, 64))
+	}
+	return text
+}
+
+// deltaDetails adds the delta and the ratio of the numbers to the details of
+// the differences
+func deltaDetails(details map[string]diffDetail) {
+	for key, detail := range details {
+		if detail.Status != "different" {
+			continue
+		}
+		if delta, ok := getNumericDelta(key, detail.Values); ok {
+			detail.Delta, detail.Ratio = &delta.Delta, delta.Ratio
+			details[key] = detail
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNumericDelta(t *testing.T) {
+	tests := []struct {
+		key    string
+		values []interface{}
+		want   string
+		ok     bool
+	}{
+		{"innodb_buffer_pool_size", []interface{}{"8G", "64G"}, "+56G (8x)", true},
+		{"innodb_buffer_pool_size", []interface{}{"64G", "8G"}, "-56G (0.13x)", true},
+		{"max_connections", []interface{}{"0", "151"}, "+151", true},
+		{"long_query_time", []interface{}{"10", "0.5"}, "-9.5 (0.05x)", true},
+		{"innodb_buffer_pool_size", []interface{}{"8G", missing}, "", false},
+		{"secure_file_priv", []interface{}{"", sqlNull}, "", false},
+		{"binlog_format", []interface{}{"ROW", "MIXED"}, "", false},
+	}
+
+	for _, tc := range tests {
+		delta, ok := getNumericDelta(tc.key, tc.values)
+		if ok != tc.ok || ok && delta.text(tc.key) != tc.want {
+			t.Errorf("%s %v. Got: %s, %v  --  Want: %s, %v\n", tc.key, tc.values, delta.text(tc.key), ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestShowDelta(t *testing.T) {
+	diff := map[string][]interface{}{"key_buffer_size": {"8M", "64M"}}
+
+	got, _ := (&plainOutput{showDelta: true}).Format(diff)
+	want := fmt.Sprintf("%35s: %40s : %40s\n", "key_buffer_size", "8M", "64M") +
+		fmt.Sprintf("%35s  delta: +56M (8x)\n", "")
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	got, _ = (&jsonOutput{showDelta: true}).Format(diff)
+	want = `{"key_buffer_size":{"values":["8M","64M"],"status":"different","delta":58720256,"ratio":8}}`
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
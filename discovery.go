@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// replicaFinders are the ways to find the replicas connected to a server,
+// like the --recursion-method of the other Percona tools.
+var replicaFinders = map[string]func(context.Context, *sql.DB) ([]string, error){
+	"processlist": findReplicasByProcesslist,
+	"hosts":       findReplicasByHosts,
+	"group":       findGroupMembers,
+}
+
+// parseRecursionMethod validates a --recursion-method value like
+// processlist,hosts or none and returns the methods to try, in order.
+func parseRecursionMethod(value string) ([]string, error) {
+	if value == "" || value == "none" {
+		return nil, nil
+	}
+
+	methods := strings.Split(value, ",")
+	for _, method := range methods {
+		if _, ok := replicaFinders[method]; !ok {
+			return nil, fmt.Errorf("Invalid recursion method: %s", method)
+		}
+	}
+
+	return methods, nil
+}
+
+// topologyNode is a server found by discoverReplicas
+type topologyNode struct {
+	DSN    string
+	Source string // Name of the server it was found on, empty for the given dsns
+	Level  int    // 0 for the given dsns, 1 for their replicas...
+}
+
+// discoverReplicas returns the dsns plus the dsns of their replicas, and of
+// the replicas of the replicas, up to maxLevel levels (0: the whole
+// topology). The methods are tried in order until one finds replicas. The
+// replicas use the same credentials and options as the server they were
+// found on.
+func discoverReplicas(ctx context.Context, dsns []string, methods []string, maxLevel int, dbConnector func(string) (*sql.DB, error)) ([]topologyNode, error) {
+	seen := make(map[string]bool)
+	var nodes []topologyNode
+	for _, dsn := range dsns {
+		seen[dsnName(dsn)] = true
+		nodes = append(nodes, topologyNode{DSN: dsn})
+	}
+	if len(methods) == 0 {
+		return nodes, nil
+	}
+
+	// Breadth first, so the replicas are listed by level
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[i]
+		if maxLevel > 0 && node.Level >= maxLevel {
+			continue
+		}
+		replicas, err := findReplicas(ctx, node.DSN, methods, dbConnector)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot find the replicas of %s: %s", dsnName(node.DSN), err.Error())
+		}
+		for _, replica := range replicas {
+			replicaDSN, err := replaceDsnAddr(node.DSN, replica)
+			if err != nil {
+				return nil, err
+			}
+			if seen[dsnName(replicaDSN)] {
+				continue
+			}
+			seen[dsnName(replicaDSN)] = true
+			nodes = append(nodes, topologyNode{DSN: replicaDSN, Source: dsnName(node.DSN), Level: node.Level + 1})
+		}
+	}
+
+	return nodes, nil
+}
+
+// parseDSNTable validates a --recurse-to-dsn-table value, a pt dsn like
+// h=host,D=percona,t=dsns, and returns the dsn to connect and the table.
+func parseDSNTable(ctx context.Context, value string, runCommand commandRunner) (string, string, error) {
+	if !isLegacyDsn(value) {
+		return "", "", fmt.Errorf("Invalid dsn table %s. Use h=host,D=db,t=table", value)
+	}
+	parts := make(map[byte]string)
+	for _, part := range strings.Split(value, ",") {
+		if len(part) >= 3 && part[1] == '=' {
+			parts[part[0]] = part[2:]
+		}
+	}
+	if parts['D'] == "" || parts['t'] == "" {
+		return "", "", fmt.Errorf("Invalid dsn table %s. The D and t parts are required", value)
+	}
+
+	dsn, err := convertFromLegacyDsnFormat(ctx, value, runCommand)
+	if err != nil {
+		return "", "", err
+	}
+	return dsn, quoteIdentifier(parts['D']) + "." + quoteIdentifier(parts['t']), nil
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// readDSNTable reads the servers of a dsn table, like the dsn=DSN
+// --recursion-method of pt-table-checksum: a table with a dsn column (and an
+// id column to sort them). The dsns without user or password use the ones of
+// the table dsn.
+func readDSNTable(ctx context.Context, value string, dbConnector func(string) (*sql.DB, error), runCommand commandRunner) ([]string, error) {
+	tableDSN, table, err := parseDSNTable(ctx, value, runCommand)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := mysql.ParseDSN(tableDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dbConnector(tableDSN)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT dsn FROM "+table+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dsns []string
+	for rows.Next() {
+		var dsn string
+		if err := rows.Scan(&dsn); err != nil {
+			return nil, err
+		}
+		if dsn = strings.TrimSpace(dsn); dsn == "" {
+			continue
+		}
+		if dsn, err = convertFromLegacyDsnFormat(ctx, dsn, runCommand); err != nil {
+			return nil, err
+		}
+		if dsn, err = withCredentials(dsn, clientCredentials{User: cfg.User, Password: cfg.Passwd}); err != nil {
+			return nil, err
+		}
+		dsns = append(dsns, dsn)
+	}
+
+	return dsns, rows.Err()
+}
+
+// withDSNTable adds the servers of the dsn table to the topology, as
+// replicas of the first --dsn server, like pt-table-checksum does. Without
+// --dsn they are the servers to compare.
+func withDSNTable(nodes []topologyNode, dsns []string) []topologyNode {
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		seen[dsnName(node.DSN)] = true
+	}
+
+	source, level := "", 0
+	if len(nodes) > 0 {
+		source, level = dsnName(nodes[0].DSN), 1
+	}
+	for _, dsn := range dsns {
+		if seen[dsnName(dsn)] {
+			continue
+		}
+		seen[dsnName(dsn)] = true
+		nodes = append(nodes, topologyNode{DSN: dsn, Source: source, Level: level})
+	}
+	return nodes
+}
+
+func findReplicas(ctx context.Context, dsn string, methods []string, dbConnector func(string) (*sql.DB, error)) ([]string, error) {
+	db, err := dbConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var lastErr error
+	for _, method := range methods {
+		replicas, err := replicaFinders[method](ctx, db)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(replicas) > 0 {
+			return replicas, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+// replaceDsnAddr returns the dsn pointing to another host:port
+func replaceDsnAddr(dsn, addr string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	cfg.Net = "tcp"
+	cfg.Addr = addr
+	return cfg.FormatDSN(), nil
+}
+
+// findReplicasByProcesslist looks for the binlog dump threads. Since the
+// processlist has the client port, replicas are supposed to listen on the
+// same port as the source (same as pt-table-checksum does).
+func findReplicasByProcesslist(ctx context.Context, db *sql.DB) ([]string, error) {
+	var port string
+	if err := db.QueryRowContext(ctx, "SELECT @@port").Scan(&port); err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(ctx, db, "SHOW FULL PROCESSLIST")
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas []string
+	for _, row := range rows {
+		if !strings.HasPrefix(row["Command"], "Binlog Dump") {
+			continue
+		}
+		host := row["Host"]
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		replicas = append(replicas, net.JoinHostPort(host, port))
+	}
+
+	return replicas, nil
+}
+
+// findReplicasByHosts uses SHOW REPLICAS (8.0.22+) or SHOW SLAVE HOSTS. Only
+// replicas started with --report-host are listed.
+func findReplicasByHosts(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := queryRows(ctx, db, "SHOW REPLICAS")
+	if err != nil {
+		if rows, err = queryRows(ctx, db, "SHOW SLAVE HOSTS"); err != nil {
+			return nil, err
+		}
+	}
+
+	var replicas []string
+	for _, row := range rows {
+		if row["Host"] == "" {
+			continue
+		}
+		replicas = append(replicas, net.JoinHostPort(row["Host"], row["Port"]))
+	}
+
+	return replicas, nil
+}
+
+// groupMembersQuery lists the other reachable members of the Group
+// Replication group of the server
+const groupMembersQuery = "SELECT MEMBER_HOST, MEMBER_PORT FROM performance_schema.replication_group_members " +
+	"WHERE MEMBER_ID != @@server_uuid AND MEMBER_STATE IN ('ONLINE', 'RECOVERING')"
+
+// findGroupMembers returns the other members of the group, so a single --dsn
+// checks the whole Group Replication cluster. They are listed as replicas of
+// the member they were found on.
+func findGroupMembers(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := queryRows(ctx, db, groupMembersQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, row := range rows {
+		if row["MEMBER_HOST"] == "" {
+			continue
+		}
+		members = append(members, net.JoinHostPort(row["MEMBER_HOST"], row["MEMBER_PORT"]))
+	}
+
+	return members, nil
+}
+
+// queryRows returns all the rows of a query as column name -> value, for
+// statements like SHOW ... that have different columns between versions.
+func queryRows(ctx context.Context, db *sql.DB, query string) ([]map[string]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]string
+	for rows.Next() {
+		values := make([]sql.RawBytes, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(columns))
+		for i, column := range columns {
+			row[column] = string(values[i])
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
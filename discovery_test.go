@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestDiscoverReplicas(t *testing.T) {
+	dbConnector := func(dsn string) (*sql.DB, error) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+
+		mock.ExpectQuery(`SELECT @@port`).WillReturnRows(sqlmock.NewRows([]string{"@@port"}).AddRow("3306"))
+		mock.ExpectQuery("SHOW FULL PROCESSLIST").WillReturnRows(
+			sqlmock.NewRows([]string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}).
+				AddRow("1", "repl", "10.0.0.2:51234", nil, "Binlog Dump GTID", "100", "", nil).
+				AddRow("2", "app", "10.0.0.9:40000", "app", "Query", "0", "", "SELECT 1"))
+		mock.ExpectQuery("SHOW REPLICAS").WillReturnError(errors.New("syntax error"))
+		mock.ExpectQuery("SHOW SLAVE HOSTS").WillReturnRows(
+			sqlmock.NewRows([]string{"Server_id", "Host", "Port", "Master_id", "Slave_UUID"}).
+				AddRow("2", "db2.example.com", "3307", "1", "uuid"))
+
+		return db, nil
+	}
+
+	dsns := []string{"user:pass@tcp(10.0.0.1:3306)/"}
+
+	got, err := discoverReplicas(context.Background(), dsns, []string{"processlist"}, 1, dbConnector)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := []topologyNode{
+		{DSN: "user:pass@tcp(10.0.0.1:3306)/"},
+		{DSN: "user:pass@tcp(10.0.0.2:3306)/", Source: "10.0.0.1:3306", Level: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	got, err = discoverReplicas(context.Background(), dsns, []string{"hosts"}, 1, func(dsn string) (*sql.DB, error) {
+		db, mock, _ := sqlmock.New()
+		mock.ExpectQuery("SHOW REPLICAS").WillReturnError(errors.New("syntax error"))
+		mock.ExpectQuery("SHOW SLAVE HOSTS").WillReturnRows(
+			sqlmock.NewRows([]string{"Server_id", "Host", "Port", "Master_id", "Slave_UUID"}).
+				AddRow("2", "db2.example.com", "3307", "1", "uuid"))
+		return db, nil
+	})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want = []topologyNode{
+		{DSN: "user:pass@tcp(10.0.0.1:3306)/"},
+		{DSN: "user:pass@tcp(db2.example.com:3307)/", Source: "10.0.0.1:3306", Level: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// Without a level limit, the replicas of the replicas are found too
+	replicasOf := map[string][]string{"10.0.0.1:3306": {"10.0.0.2", "3306"}, "10.0.0.2:3306": {"10.0.0.3", "3306"}, "10.0.0.3:3306": {"10.0.0.1", "3306"}}
+	got, err = discoverReplicas(context.Background(), dsns, []string{"hosts"}, 0, func(dsn string) (*sql.DB, error) {
+		db, mock, _ := sqlmock.New()
+		rows := sqlmock.NewRows([]string{"Server_id", "Host", "Port", "Source_id", "Replica_UUID"})
+		if replica, ok := replicasOf[dsnName(dsn)]; ok {
+			rows.AddRow("2", replica[0], replica[1], "1", "uuid")
+		}
+		mock.ExpectQuery("SHOW REPLICAS").WillReturnRows(rows)
+		return db, nil
+	})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want = []topologyNode{
+		{DSN: "user:pass@tcp(10.0.0.1:3306)/"},
+		{DSN: "user:pass@tcp(10.0.0.2:3306)/", Source: "10.0.0.1:3306", Level: 1},
+		{DSN: "user:pass@tcp(10.0.0.3:3306)/", Source: "10.0.0.2:3306", Level: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// Every member of a group finds the other ones
+	members := [][]string{{"gr1", "3306"}, {"gr2", "3306"}, {"gr3", "3306"}}
+	got, err = discoverReplicas(context.Background(), []string{"root@tcp(gr1:3306)/"}, []string{"group"}, 0, func(dsn string) (*sql.DB, error) {
+		db, mock, _ := sqlmock.New()
+		rows := sqlmock.NewRows([]string{"MEMBER_HOST", "MEMBER_PORT"})
+		for _, member := range members {
+			if net.JoinHostPort(member[0], member[1]) != dsnName(dsn) {
+				rows.AddRow(member[0], member[1])
+			}
+		}
+		mock.ExpectQuery("SELECT MEMBER_HOST, MEMBER_PORT FROM performance_schema.replication_group_members").WillReturnRows(rows)
+		return db, nil
+	})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want = []topologyNode{
+		{DSN: "root@tcp(gr1:3306)/"},
+		{DSN: "root@tcp(gr2:3306)/", Source: "gr1:3306", Level: 1},
+		{DSN: "root@tcp(gr3:3306)/", Source: "gr1:3306", Level: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := parseRecursionMethod("processlist,dsn"); err == nil {
+		t.Error("Should return error on invalid methods")
+	}
+}
+
+func TestReadDSNTable(t *testing.T) {
+	var gotDSN string
+	dbConnector := func(dsn string) (*sql.DB, error) {
+		gotDSN = dsn
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		mock.ExpectQuery("SELECT dsn FROM `percona`.`dsns` ORDER BY id").WillReturnRows(
+			sqlmock.NewRows([]string{"dsn"}).
+				AddRow("h=replica1,P=3307").
+				AddRow("").
+				AddRow("repl:secret@tcp(replica2:3306)/"))
+		return db, nil
+	}
+
+	got, err := readDSNTable(context.Background(), "h=db1,u=checksum,p=pass,D=percona,t=dsns", dbConnector, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if want := "checksum:pass@tcp(db1:3306)/percona"; gotDSN != want {
+		t.Errorf("Got: %s  --  Want: %s\n", gotDSN, want)
+	}
+	want := []string{"checksum:pass@tcp(replica1:3307)/", "repl:secret@tcp(replica2:3306)/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	nodes := withDSNTable([]topologyNode{{DSN: "root@tcp(db1:3306)/"}}, append(want, "root@tcp(db1:3306)/"))
+	wantNodes := []topologyNode{
+		{DSN: "root@tcp(db1:3306)/"},
+		{DSN: "checksum:pass@tcp(replica1:3307)/", Source: "db1:3306", Level: 1},
+		{DSN: "repl:secret@tcp(replica2:3306)/", Source: "db1:3306", Level: 1},
+	}
+	if !reflect.DeepEqual(nodes, wantNodes) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", nodes, wantNodes)
+	}
+
+	if _, _, err := parseDSNTable(context.Background(), "h=db1,D=percona", execCommand); err == nil {
+		t.Error("Should return error without the table")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// isLegacyDsn returns true if the dsn is in the Percona Toolkit format
+// (h=host,P=port,u=user...) instead of the Go MySQL driver format
+// (user:pass@tcp(host:port)/db).
+func isLegacyDsn(dsn string) bool {
+	return !strings.Contains(dsn, "@") && !strings.Contains(dsn, "(") && strings.Contains(dsn, "=")
+}
+
+// convertFromLegacyDsnFormat converts a Percona Toolkit style dsn like
+// h=127.1,P=3306,u=root,p=pass,D=db into user:pass@tcp(127.1:3306)/db.
+// Go style dsns are returned unchanged. The whole pt dsn grammar is accepted:
+//
+//	A  charset
+//	D  database
+//	F  defaults file, whose [client] group has the defaults of the other parts
+//	h  host
+//	p  password
+//	P  port
+//	S  socket, used instead of the host and port
+//	t  table, not used by this tool
+//	u  user
+//
+// The F= files can be remote, read with runCommand like the --cnf files.
+func convertFromLegacyDsnFormat(ctx context.Context, dsn string, runCommand commandRunner) (string, error) {
+	if !isLegacyDsn(dsn) {
+		return dsn, nil
+	}
+
+	parts := make(map[byte]string)
+	for _, part := range strings.Split(dsn, ",") {
+		if len(part) < 3 || part[1] != '=' {
+			continue
+		}
+		parts[part[0]] = part[2:]
+	}
+
+	if file, ok := parts['F']; ok {
+		defaults, err := legacyDefaultsFile(ctx, file, runCommand)
+		if err != nil {
+			return "", fmt.Errorf("Cannot read the defaults file of the dsn %s: %s", file, err.Error())
+		}
+		for key, value := range defaults {
+			if _, ok := parts[key]; !ok {
+				parts[key] = value
+			}
+		}
+	}
+
+	cfg := mysql.NewConfig()
+	cfg.User, cfg.Passwd, cfg.DBName = parts['u'], parts['p'], parts['D']
+	if charset, ok := parts['A']; ok {
+		cfg.Params = map[string]string{"charset": charset}
+	}
+
+	if socket, ok := parts['S']; ok {
+		cfg.Net, cfg.Addr = "unix", socket
+	} else {
+		host, port := "127.0.0.1", "3306"
+		if value, ok := parts['h']; ok {
+			host = value
+		}
+		if value, ok := parts['P']; ok {
+			port = value
+		}
+		cfg.Net, cfg.Addr = "tcp", net.JoinHostPort(host, port)
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// legacyDefaultsFile reads the [client] group of the F= file of a dsn, as the
+// dsn parts they are the default of
+func legacyDefaultsFile(ctx context.Context, filename string, runCommand commandRunner) (map[byte]string, error) {
+	options, err := readOptionFiles(ctx, filename, cnfReadOptions{Groups: []string{"client"}}, runCommand)
+	if err != nil {
+		return nil, err
+	}
+	cnf := mergeOptions(options, "client")
+
+	defaults := make(map[byte]string)
+	for key, name := range map[byte]string{'u': "user", 'p': "password", 'h': "host", 'P': "port", 'S': "socket"} {
+		if value, ok := cnf.entries[name].(string); ok {
+			defaults[key] = value
+		}
+	}
+	return defaults, nil
+}
+
+// dsnDefaults is the address used by the dsns without host, from --socket
+// or the MYSQL_UNIX_PORT, MYSQL_HOST and MYSQL_TCP_PORT environment variables
+type dsnDefaults struct {
+	Socket string
+	Host   string
+	Port   string
+}
+
+// withDefaultAddr sets the default address to the legacy dsns without h= or
+// S= and to the Go style dsns without address, like user:pass@/db. A host
+// other than localhost is used instead of the socket, like the mysql clients
+// do.
+func withDefaultAddr(dsn string, defaults dsnDefaults) (string, error) {
+	useHost := defaults.Host != "" && defaults.Host != "localhost"
+	if !useHost && defaults.Socket == "" {
+		return dsn, nil
+	}
+
+	if isLegacyDsn(dsn) {
+		hasPort := false
+		for _, part := range strings.Split(dsn, ",") {
+			if strings.HasPrefix(part, "h=") || strings.HasPrefix(part, "S=") {
+				return dsn, nil
+			}
+			hasPort = hasPort || strings.HasPrefix(part, "P=")
+		}
+		if !useHost {
+			return dsn + ",S=" + defaults.Socket, nil
+		}
+		dsn += ",h=" + defaults.Host
+		if !hasPort && defaults.Port != "" {
+			dsn += ",P=" + defaults.Port
+		}
+		return dsn, nil
+	}
+
+	address := dsn
+	if slash := strings.LastIndex(address, "/"); slash >= 0 {
+		address = address[:slash]
+	}
+	if strings.Contains(address[strings.LastIndex(address, "@")+1:], "(") {
+		return dsn, nil
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if useHost {
+		port := defaults.Port
+		if port == "" {
+			port = "3306"
+		}
+		cfg.Net, cfg.Addr = "tcp", net.JoinHostPort(defaults.Host, port)
+	} else {
+		cfg.Net, cfg.Addr = "unix", defaults.Socket
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// dsnName returns a name for the dsn without the credentials, to be used in
+// the outputs.
+func dsnName(dsn string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil || cfg.Addr == "" {
+		return "mysql"
+	}
+	return cfg.Addr
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertFromLegacyDsnFormat(t *testing.T) {
+	defaultsFile := filepath.Join(t.TempDir(), "client.cnf")
+	ioutil.WriteFile(defaultsFile, []byte("[client]\nuser = monitor\npassword = secret\nhost = db9\n"), 0600)
+
+	tests := map[string]string{
+		"h=127.1,P=3306,u=root,p=pass,D=db":  "root:pass@tcp(127.1:3306)/db",
+		"h=db1,u=root,t=mysql.user":          "root@tcp(db1:3306)/",
+		"S=/var/lib/mysql/mysql.sock,u=root": "root@unix(/var/lib/mysql/mysql.sock)/",
+		"h=db1,u=root,A=utf8mb4":             "root@tcp(db1:3306)/?charset=utf8mb4",
+		"F=" + defaultsFile + ",h=db2":       "monitor:secret@tcp(db2:3306)/",
+		"user:pass@tcp(db1:3306)/":           "user:pass@tcp(db1:3306)/",
+		"h=::1,P=3307,u=root":                "root@tcp([::1]:3307)/",
+	}
+	for dsn, want := range tests {
+		got, err := convertFromLegacyDsnFormat(context.Background(), dsn, execCommand)
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", dsn, err.Error())
+		}
+		if got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+
+	if _, err := convertFromLegacyDsnFormat(context.Background(), "F=/nonexistent/my.cnf,h=db1", execCommand); err == nil {
+		t.Errorf("Should return an error if the defaults file cannot be read")
+	}
+
+	// The remote defaults files are read with the command runner
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		return []byte("[client]\nuser = monitor\n"), nil
+	}
+	got, err := convertFromLegacyDsnFormat(context.Background(), "F=ssh://db9:/etc/client.cnf,h=db2", runCommand)
+	if err != nil || got != "monitor@tcp(db2:3306)/" || !strings.HasPrefix(gotCommand, "ssh ") {
+		t.Errorf("Got: %s %v, ran %q  --  Want: monitor@tcp(db2:3306)/ read over ssh\n", got, err, gotCommand)
+	}
+}
+
+func TestWithDefaultAddr(t *testing.T) {
+	socket := "/var/run/mysqld/mysqld.sock"
+	tests := map[string]string{
+		"u=root,p=pass":                 "u=root,p=pass,S=" + socket,
+		"h=db1,u=root":                  "h=db1,u=root",
+		"S=/tmp/mysql.sock,u=root":      "S=/tmp/mysql.sock,u=root",
+		"root:pass@/app":                "root:pass@unix(" + socket + ")/app",
+		"root:pass@tcp(db1:3306)/app":   "root:pass@tcp(db1:3306)/app",
+		"root:p@ss@unix(/tmp/my.sock)/": "root:p@ss@unix(/tmp/my.sock)/",
+	}
+	for dsn, want := range tests {
+		got, err := withDefaultAddr(dsn, dsnDefaults{Socket: socket})
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", dsn, err.Error())
+		}
+		if got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+
+	opts, err := processParams(context.Background(), []string{"--socket", socket, "--dsn", "u=root"})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if want := "root@unix(" + socket + ")/"; len(opts.DSNs) != 1 || opts.DSNs[0] != want {
+		t.Errorf("Got: %v  --  Want: %s\n", opts.DSNs, want)
+	}
+}
+
+func TestWithDefaultHost(t *testing.T) {
+	defaults := dsnDefaults{Socket: "/tmp/mysql.sock", Host: "db9", Port: "3307"}
+	tests := map[string]string{
+		"u=root":                      "u=root,h=db9,P=3307",
+		"u=root,P=3306":               "u=root,P=3306,h=db9",
+		"root:pass@/app":              "root:pass@tcp(db9:3307)/app",
+		"root:pass@tcp(db1:3306)/app": "root:pass@tcp(db1:3306)/app",
+	}
+	for dsn, want := range tests {
+		got, err := withDefaultAddr(dsn, defaults)
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", dsn, err.Error())
+		}
+		if got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+}
+
+func TestWithDefaultIPv6Host(t *testing.T) {
+	got, err := withDefaultAddr("root:pass@/app", dsnDefaults{Host: "fd00::9", Port: "3307"})
+	if want := "root:pass@tcp([fd00::9]:3307)/app"; err != nil || got != want {
+		t.Errorf("Got: %s %v  --  Want: %s\n", got, err, want)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// driftEvent is a variable of a source that differs from the base config
+type driftEvent struct {
+	Host     string
+	Variable string
+	Old      string // Value in the base config
+	New      string // Value in the source
+	Severity string
+}
+
+// driftEvents returns an event per differing variable and source, sorted by
+// source and variable. The sourceDiffs are the differences of every config
+// with the base, as returned by compareSources, and the severity of every
+// event is the one of the variable impact.
+func driftEvents(configs []configReader, sourceDiffs []map[string][]interface{}) []driftEvent {
+	var events []driftEvent
+	for i := 1; i < len(configs) && i < len(sourceDiffs); i++ {
+		diffs := sourceDiffs[i]
+		keys := make([]string, 0, len(diffs))
+		for key := range diffs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			_, severity := variableImpact(key)
+			events = append(events, driftEvent{
+				Host:     configs[i].Name(),
+				Variable: key,
+				Old:      valueString(diffs[key][0]),
+				New:      valueString(diffs[key][1]),
+				Severity: severity,
+			})
+		}
+	}
+
+	return events
+}
+
+// String returns the event as key="value" pairs, easy to parse by log
+// collectors
+func (e driftEvent) String() string {
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+	return fmt.Sprintf(`config drift host="%s" variable="%s" old="%s" new="%s" severity="%s"`,
+		quote(e.Host), quote(e.Variable), quote(e.Old), quote(e.New), quote(e.Severity))
+}
+
+// eventSink sends the drift events to a logging system
+type eventSink interface {
+	Send(events []driftEvent) error
+	Close() error
+}
+
+// eventSinks are the available --event-sink values. The implementations are
+// platform specific.
+var eventSinks = map[string]func() (eventSink, error){
+	"syslog":   newSyslogSink,
+	"journald": newJournaldSink,
+}
+
+func sendEvents(sinkName string, events []driftEvent) error {
+	newSink, ok := eventSinks[sinkName]
+	if !ok {
+		return fmt.Errorf("Invalid event sink: %s", sinkName)
+	}
+
+	sink, err := newSink()
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	return sink.Send(events)
+}
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"errors"
+)
+
+func newSyslogSink() (eventSink, error) {
+	return nil, errors.New("syslog is not available on this platform")
+}
+
+func newJournaldSink() (eventSink, error) {
+	return nil, errors.New("journald is not available on this platform")
+}
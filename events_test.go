@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDriftEvents(t *testing.T) {
+	base := &config{configType: "cnf", name: "golden.cnf", entries: map[string]interface{}{
+		"max_connections": "500",
+		"read_only":       "OFF",
+	}}
+	db1 := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{
+		"max_connections": "500",
+		"read_only":       "ON",
+	}}
+	db2 := &config{configType: "mysql", name: "db2:3306", entries: map[string]interface{}{
+		"max_connections": "151",
+	}}
+
+	want := []driftEvent{
+		{Host: "db1:3306", Variable: "read_only", Old: "OFF", New: "ON", Severity: "high"},
+		{Host: "db2:3306", Variable: "max_connections", Old: "500", New: "151", Severity: "medium"},
+		{Host: "db2:3306", Variable: "read_only", Old: "OFF", New: "<Missing>", Severity: "high"},
+	}
+	configs := []configReader{base, db1, db2}
+	got := driftEvents(configs, (&comparer{}).compareSources(configs))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// --min-severity drops the events of the filtered variables
+	sourceDiffs := (&comparer{}).compareSources(configs)
+	for i := range sourceDiffs {
+		sourceDiffs[i], _ = filterBySeverity(sourceDiffs[i], "high")
+	}
+	if got := driftEvents(configs, sourceDiffs); len(got) != 2 || got[1].Variable != "read_only" {
+		t.Errorf("Got: %#v  --  Want: only the read_only events\n", got)
+	}
+
+	wantMessage := `config drift host="db1:3306" variable="read_only" old="OFF" new="ON" severity="high"`
+	if got[0].String() != wantMessage {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got[0].String(), wantMessage)
+	}
+
+	if err := sendEvents("invalid", got); err == nil {
+		t.Error("Should return error on invalid sinks")
+	}
+}
@@ -0,0 +1,97 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/syslog"
+	"net"
+	"strings"
+)
+
+const (
+	syslogTag      = "pt-mysql-config-diff"
+	journaldSocket = "/run/systemd/journal/socket"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func newSyslogSink() (eventSink, error) {
+	writer, err := syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, syslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Send(events []driftEvent) error {
+	for _, event := range events {
+		if err := s.writer.Warning(event.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
+
+// journaldSink writes the events to the systemd journal using its native
+// protocol, so every field can be used in journalctl filters, e.g.
+// journalctl MYSQL_VARIABLE=max_connections
+type journaldSink struct {
+	conn net.Conn
+}
+
+func newJournaldSink() (eventSink, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{conn: conn}, nil
+}
+
+func (s *journaldSink) Send(events []driftEvent) error {
+	for _, event := range events {
+		if _, err := s.conn.Write(journaldMessage(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+// journaldMessage encodes the event for the journal socket. Values with
+// new lines use the binary form: name, new line, little endian length, value.
+func journaldMessage(event driftEvent) []byte {
+	var buffer bytes.Buffer
+
+	fields := [][2]string{
+		{"MESSAGE", event.String()},
+		{"PRIORITY", "4"},
+		{"SYSLOG_IDENTIFIER", syslogTag},
+		{"MYSQL_HOST", event.Host},
+		{"MYSQL_VARIABLE", event.Variable},
+		{"MYSQL_OLD_VALUE", event.Old},
+		{"MYSQL_NEW_VALUE", event.New},
+		{"MYSQL_SEVERITY", event.Severity},
+	}
+	for _, field := range fields {
+		if !strings.Contains(field[1], "\n") {
+			buffer.WriteString(field[0] + "=" + field[1] + "\n")
+			continue
+		}
+		buffer.WriteString(field[0] + "\n")
+		binary.Write(&buffer, binary.LittleEndian, uint64(len(field[1])))
+		buffer.WriteString(field[1] + "\n")
+	}
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,24 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJournaldMessage(t *testing.T) {
+	event := driftEvent{Host: "db1:3306", Variable: "init_connect", Old: "", New: "SET a=1;\nSET b=2", Severity: "medium"}
+
+	message := string(journaldMessage(event))
+	for _, want := range []string{
+		"PRIORITY=4\n",
+		"MYSQL_HOST=db1:3306\n",
+		"MYSQL_OLD_VALUE=\n",
+		"MYSQL_NEW_VALUE\n\x10\x00\x00\x00\x00\x00\x00\x00SET a=1;\nSET b=2\n",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Got:\n%q\nWant it to contain:\n%q\n", message, want)
+		}
+	}
+}
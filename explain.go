@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// variableExplanation is what explain-variable prints for a variable
+type variableExplanation struct {
+	Name        string              `json:"name"`
+	Type        string              `json:"type,omitempty"`
+	Scope       string              `json:"scope,omitempty"`
+	Unit        string              `json:"unit,omitempty"`
+	Dynamic     bool                `json:"dynamic"`
+	Description string              `json:"description,omitempty"`
+	Vendor      string              `json:"vendor,omitempty"` // Vendors of the variables community MySQL doesn't have
+	Defaults    map[string]string   `json:"defaults,omitempty"`
+	Sources     []explainedVariable `json:"sources"`
+}
+
+// explainedVariable is the value of the variable in one source
+type explainedVariable struct {
+	Source  string       `json:"source"`
+	Type    string       `json:"type"`
+	Value   interface{}  `json:"value"`
+	Set     bool         `json:"set"`
+	Version string       `json:"version,omitempty"`
+	Default *string      `json:"default,omitempty"` // Documented default for the server version
+	Origin  *entryOrigin `json:"origin,omitempty"`
+}
+
+// lookupVariable gets a variable from a config trying both the cnf (dashes)
+// and the server (underscores) spelling.
+func lookupVariable(cfg configReader, name string) (interface{}, string, bool) {
+	for _, key := range []string{name, strings.Replace(name, "-", "_", -1), strings.Replace(name, "_", "-", -1)} {
+		if value, ok := cfg.Get(key); ok {
+			return value, key, true
+		}
+	}
+	return nil, "", false
+}
+
+// majorMinor returns the major.minor part of a version like 8.0.36-log, or
+// an empty string if it is not a version
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	minor := strings.TrimFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if parts[0] == "" || minor == "" {
+		return ""
+	}
+	return parts[0] + "." + minor
+}
+
+// defaultForVersion returns the documented default of a variable for a
+// server version like 8.0.36-log, matching it by major.minor.
+func defaultForVersion(info variableInfo, version string) (string, bool) {
+	mm := majorMinor(version)
+	if mm == "" {
+		return "", false
+	}
+	value, ok := info.Defaults[mm]
+	return value, ok
+}
+
+func explainVariable(name string, configs []configReader) variableExplanation {
+	name = strings.Replace(name, "-", "_", -1)
+	info, _ := getVariableInfo(name)
+	explanation := variableExplanation{
+		Name:        name,
+		Type:        info.Type,
+		Scope:       info.Scope,
+		Unit:        info.Unit,
+		Dynamic:     isDynamic(name),
+		Description: info.Description,
+		Vendor:      vendorOf(name),
+		Defaults:    info.Defaults,
+	}
+
+	for _, cfg := range configs {
+		source := explainedVariable{Source: cfg.Name(), Type: cfg.Type()}
+		value, key, ok := lookupVariable(cfg, name)
+		if ok {
+			source.Value, source.Set = valueString(value), true
+			if origin, ok := cfg.Origin(key); ok {
+				source.Origin = &origin
+			}
+		}
+		if version, ok := cfg.Get("version"); ok && cfg.Type() == "mysql" {
+			source.Version = valueString(version)
+			if def, ok := defaultForVersion(info, source.Version); ok {
+				source.Default = &def
+			}
+		}
+		explanation.Sources = append(explanation.Sources, source)
+	}
+
+	return explanation
+}
+
+// runExplainVariable shows a single variable: its value on every source, its
+// metadata and the documented default for the version of each server.
+func runExplainVariable(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if len(opts.args) != 1 {
+		return "", fmt.Errorf("explain-variable needs a variable name")
+	}
+	opts.extraVariables = append(opts.extraVariables, strings.Replace(opts.args[0], "-", "_", -1))
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	explanation := explainVariable(opts.args[0], configs)
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(explanation, "", "\t")
+		} else {
+			output, err = json.Marshal(explanation)
+		}
+		return string(output), err
+	case "plain":
+		return formatExplanation(explanation), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for explain-variable", opts.OutputFmt)
+	}
+}
+
+func formatExplanation(e variableExplanation) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(e.Name + "\n")
+	if e.Description != "" {
+		buffer.WriteString("  " + e.Description + "\n")
+	}
+	if e.Type != "" {
+		line := fmt.Sprintf("  type: %s, scope: %s", e.Type, e.Scope)
+		if e.Unit != "" {
+			line += ", unit: " + e.Unit
+		}
+		buffer.WriteString(line + "\n")
+	}
+	if e.Vendor != "" {
+		buffer.WriteString("  only in: " + e.Vendor + "\n")
+	}
+	if e.Dynamic {
+		buffer.WriteString("  dynamic: yes (SET GLOBAL)\n")
+	} else {
+		buffer.WriteString("  dynamic: no (needs a restart)\n")
+	}
+	if len(e.Defaults) > 0 {
+		versions := make([]string, 0, len(e.Defaults))
+		for version := range e.Defaults {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+		defaults := make([]string, 0, len(versions))
+		for _, version := range versions {
+			defaults = append(defaults, fmt.Sprintf("%s=%s", version, e.Defaults[version]))
+		}
+		buffer.WriteString("  defaults: " + strings.Join(defaults, ", ") + "\n")
+	}
+
+	buffer.WriteString("\n")
+	for _, source := range e.Sources {
+		value := "<not set>"
+		if source.Set {
+			value = valueString(source.Value)
+		}
+		line := fmt.Sprintf("%-40s %s", source.Source, value)
+		if source.Origin != nil {
+			line += fmt.Sprintf(" (set at %s)", source.Origin)
+		}
+		if source.Default != nil {
+			line += fmt.Sprintf(" (default for %s: %s)", source.Version, *source.Default)
+		}
+		buffer.WriteString(line + "\n")
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestExplainVariable(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf",
+		entries: map[string]interface{}{"max-allowed-packet": "64M"},
+		origins: map[string]entryOrigin{"max-allowed-packet": {File: "my.cnf", Section: "mysqld", Line: 3}},
+	}
+	mysql57 := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{
+		"version":            "5.7.44-log",
+		"max_allowed_packet": "4194304",
+	}}
+	mysql80 := &config{configType: "mysql", name: "db2:3306", entries: map[string]interface{}{
+		"version": "8.0.36",
+	}}
+
+	got, err := runExplainVariable(context.Background(), &options{OutputFmt: "plain", args: []string{"max-allowed-packet"}}, func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf, mysql57, mysql80}, nil
+	})
+	if err != nil {
+		t.Errorf("Shouldn't return error: %s", err.Error())
+	}
+
+	want := "max_allowed_packet\n" +
+		"  Maximum size of one packet or any generated string.\n" +
+		"  type: size, scope: both\n" +
+		"  dynamic: yes (SET GLOBAL)\n" +
+		"  defaults: 5.7=4194304, 8.0=67108864, 8.4=67108864\n" +
+		"\n" +
+		fmt.Sprintf("%-40s %s\n", "my.cnf", "64M (set at my.cnf:3 [mysqld])") +
+		fmt.Sprintf("%-40s %s\n", "db1:3306", "4194304 (default for 5.7.44-log: 4194304)") +
+		fmt.Sprintf("%-40s %s\n", "db2:3306", "<not set> (default for 8.0.36: 67108864)")
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	if _, err := runExplainVariable(context.Background(), &options{OutputFmt: "plain"}, nil); err == nil {
+		t.Errorf("Should return an error without a variable name")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// fingerprint returns a stable hash of the normalized variables of a config,
+// so configs can be checked for equality without a full diff. Only the
+// compared variables are hashed (see --variables and --ignore-variables), by
+// the name they are compared by, so max-connections in a cnf file and the
+// max_connections of a server, or tx_isolation and transaction_isolation, are
+// the same variable.
+func fingerprint(cfg configReader, cmp *comparer) string {
+	prepared := cmp.prepareConfig(cfg)
+
+	hash := sha256.New()
+	for i, name := range prepared.names {
+		fmt.Fprintf(hash, "%s=%s\n", name, prepared.values[i].typed.text)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// sourceFingerprint is the fingerprint of a source. Sources can have the same
+// name, like the same cnf file given twice, so they are kept in order.
+type sourceFingerprint struct {
+	Source      string `json:"source"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// runFingerprint prints source -> fingerprint for every source and how many
+// different configs were found, to quickly find the outliers in a fleet.
+func runFingerprint(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return "", err
+	}
+	// The sql_mode combinations are expanded like diff does
+	cmp = cmp.withServerVersion(configs)
+
+	fingerprints := make([]sourceFingerprint, len(configs))
+	distinct := make(map[string]int)
+	for i, cfg := range configs {
+		fp := fingerprint(cfg, cmp)
+		fingerprints[i] = sourceFingerprint{Source: cfg.Name(), Fingerprint: fp}
+		distinct[fp]++
+	}
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(fingerprints, "", "\t")
+		} else {
+			output, err = json.Marshal(fingerprints)
+		}
+		return string(output), err
+	case "plain":
+		var buffer bytes.Buffer
+		for _, fp := range fingerprints {
+			buffer.WriteString(fmt.Sprintf("%-40s %s\n", fp.Source, fp.Fingerprint))
+		}
+		buffer.WriteString(fmt.Sprintf("%d sources, %d distinct configs\n", len(configs), len(distinct)))
+		return buffer.String(), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for fingerprint", opts.OutputFmt)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	cfg1 := &config{configType: "cnf", entries: map[string]interface{}{
+		"key_buffer_size": "1G",
+		"sql_mode":        "NO_ZERO_DATE,IGNORE_SPACE",
+	}}
+	cfg2 := &config{configType: "cnf", entries: map[string]interface{}{
+		"sql_mode":        "IGNORE_SPACE,NO_ZERO_DATE",
+		"key_buffer_size": "1024M",
+	}}
+	cfg3 := &config{configType: "cnf", entries: map[string]interface{}{
+		"key_buffer_size": "2G",
+		"sql_mode":        "IGNORE_SPACE,NO_ZERO_DATE",
+	}}
+
+	if fingerprint(cfg1, &comparer{}) != fingerprint(cfg2, &comparer{}) {
+		t.Errorf("Equivalent configs must have the same fingerprint")
+	}
+	if fingerprint(cfg1, &comparer{}) == fingerprint(cfg3, &comparer{}) {
+		t.Errorf("Different configs must have different fingerprints")
+	}
+
+	cfg1.name, cfg2.name, cfg3.name = "db1", "db2", "db3"
+	got, err := runFingerprint(context.Background(), &options{OutputFmt: "plain"}, func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cfg1, cfg2, cfg3}, nil
+	})
+	if err != nil {
+		t.Errorf("Shouldn't return error: %s", err.Error())
+	}
+	want := fmt.Sprintf("%-40s %s\n%-40s %s\n%-40s %s\n3 sources, 2 distinct configs\n",
+		"db1", fingerprint(cfg1, &comparer{}), "db2", fingerprint(cfg2, &comparer{}), "db3", fingerprint(cfg3, &comparer{}))
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestFingerprintCanonicalNames(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"max-connections": "500",
+		"tx_isolation":    "READ-COMMITTED",
+	}}
+	server := &config{configType: "mysql", name: "my.cnf", entries: map[string]interface{}{
+		"max_connections":       "500",
+		"transaction_isolation": "READ-COMMITTED",
+	}}
+
+	if fingerprint(cnf, &comparer{}) != fingerprint(server, &comparer{}) {
+		t.Errorf("Configs equal in a diff must have the same fingerprint")
+	}
+
+	// Sources with the same name are reported apart
+	got, err := runFingerprint(context.Background(), &options{OutputFmt: "json"}, func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf, server}, nil
+	})
+	if err != nil {
+		t.Errorf("Shouldn't return error: %s", err.Error())
+	}
+	fp := fingerprint(cnf, &comparer{})
+	want := fmt.Sprintf(`[{"source":"my.cnf","fingerprint":"%s"},{"source":"my.cnf","fingerprint":"%s"}]`, fp, fp)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// RDS IAM auth tokens are valid for 15 minutes. They are only used to open
+// the connection, so they are reused for a while and generated again before
+// they expire. This way daemon modes (agent, sidecar) keep working.
+const iamTokenTTL = 10 * time.Minute
+
+type iamToken struct {
+	token   string
+	expires time.Time
+}
+
+// iamTokenSource generates RDS IAM auth tokens with the aws cli and caches
+// them per endpoint and user. It is safe for concurrent use.
+type iamTokenSource struct {
+	opts       *options
+	runCommand commandRunner
+	now        func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]iamToken
+}
+
+func newIAMTokenSource(opts *options, runCommand commandRunner) *iamTokenSource {
+	return &iamTokenSource{opts: opts, runCommand: runCommand, now: time.Now, tokens: make(map[string]iamToken)}
+}
+
+func (s *iamTokenSource) token(ctx context.Context, host, port, user string) (string, error) {
+	key := user + "@" + net.JoinHostPort(host, port)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.tokens[key]; ok && s.now().Before(cached.expires) {
+		return cached.token, nil
+	}
+
+	output, err := s.runCommand(ctx, "aws", awsArgs(s.opts, "rds", "generate-db-auth-token",
+		"--hostname", host, "--port", port, "--username", user)...)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(output))
+	if strings.HasPrefix(token, `"`) {
+		if err := json.Unmarshal([]byte(token), &token); err != nil {
+			return "", fmt.Errorf("Invalid auth token: %s", err.Error())
+		}
+	}
+
+	s.tokens[key] = iamToken{token: token, expires: s.now().Add(iamTokenTTL)}
+	return token, nil
+}
+
+// iamDSN returns the dsn with an IAM auth token as password. IAM auth sends
+// the token in clear text, so TLS is enabled if the dsn doesn't set it. The
+// RDS CA bundle must be installed or the dsn must set another tls value.
+func (s *iamTokenSource) iamDSN(ctx context.Context, dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return "", fmt.Errorf("Invalid address %s: %s", cfg.Addr, err.Error())
+	}
+
+	if cfg.Passwd, err = s.token(ctx, host, port, cfg.User); err != nil {
+		return "", fmt.Errorf("Cannot generate the IAM auth token for %s@%s: %s", cfg.User, cfg.Addr, err.Error())
+	}
+	cfg.AllowCleartextPasswords = true
+	if cfg.TLSConfig == "" {
+		cfg.TLSConfig = "true"
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// iamDBConnector wraps a dbConnector so every connection uses a fresh IAM
+// auth token instead of the password of the dsn. The tokens are generated
+// with ctx.
+func iamDBConnector(ctx context.Context, tokens *iamTokenSource, dbConnector func(string) (*sql.DB, error)) func(string) (*sql.DB, error) {
+	return func(dsn string) (*sql.DB, error) {
+		iamDSN, err := tokens.iamDSN(ctx, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return dbConnector(iamDSN)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIAMDSN(t *testing.T) {
+	var commands []string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return []byte("db1.abc.us-east-1.rds.amazonaws.com:3306/?Action=connect&DBUser=tool&X-Amz-Signature=1\n"), nil
+	}
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tokens := newIAMTokenSource(&options{AWSRegion: "us-east-1"}, runCommand)
+	tokens.now = func() time.Time { return now }
+
+	dsn, err := tokens.iamDSN(context.Background(), "tool@tcp(db1.abc.us-east-1.rds.amazonaws.com:3306)/")
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("Invalid dsn %s: %s", dsn, err.Error())
+	}
+	if cfg.Passwd != "db1.abc.us-east-1.rds.amazonaws.com:3306/?Action=connect&DBUser=tool&X-Amz-Signature=1" ||
+		!cfg.AllowCleartextPasswords || cfg.TLSConfig != "true" {
+		t.Errorf("The dsn must use the token, cleartext passwords and TLS. Got: %#v", cfg)
+	}
+
+	wantCommand := "aws rds generate-db-auth-token --hostname db1.abc.us-east-1.rds.amazonaws.com --port 3306 --username tool --region us-east-1 --output json"
+	if len(commands) != 1 || commands[0] != wantCommand {
+		t.Errorf("Got: %v  --  Want: %s\n", commands, wantCommand)
+	}
+
+	// Tokens are reused until they are about to expire
+	tokens.iamDSN(context.Background(), "tool@tcp(db1.abc.us-east-1.rds.amazonaws.com:3306)/")
+	if len(commands) != 1 {
+		t.Errorf("The token must be reused. Got %d commands", len(commands))
+	}
+	now = now.Add(iamTokenTTL + time.Second)
+	tokens.iamDSN(context.Background(), "tool@tcp(db1.abc.us-east-1.rds.amazonaws.com:3306)/")
+	if len(commands) != 2 {
+		t.Errorf("An expired token must be generated again. Got %d commands", len(commands))
+	}
+}
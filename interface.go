@@ -1,18 +1,44 @@
 package main
 
+import (
+	"strings"
+)
+
 type configReader interface {
 	Entries() map[string]interface{}
 	Keys() []string
 	Get(string) (interface{}, bool)
+	Origin(string) (entryOrigin, bool)
 	Type() string
+	Name() string
 }
 
 type config struct {
 	configType string
+	name       string // File name, host or whatever identifies the source
 	entries    map[string]interface{}
+	origins    map[string]entryOrigin
+	section    string // The cnf group, if the groups are compared on their own
+
+	// aliases has the entries whose name is not their variableName, by
+	// variableName, built by entryKey when needed. indexed is the number of
+	// entries it was built with, to build it again if entries were added.
+	aliases map[string]string
+	indexed int
 }
 
+// Entries returns the entries with the NULL values as sqlNull, like Get
 func (c *config) Entries() map[string]interface{} {
+	for _, value := range c.entries {
+		if value != nil {
+			continue
+		}
+		entries := make(map[string]interface{}, len(c.entries))
+		for key, value := range c.entries {
+			entries[key] = nullable(value)
+		}
+		return entries
+	}
 	return c.entries
 }
 
@@ -24,11 +50,95 @@ func (c *config) Keys() []string {
 	return keys
 }
 
+// Get returns the value of an entry. The entries are found by their canonical
+// name too (see canonicalName), so the diffs can look them up. The NULL values
+// of the servers are sqlNull, so they are not mistaken for empty values or for
+// the entries not set.
 func (c *config) Get(key string) (interface{}, bool) {
-	val, ok := c.entries[key]
-	return val, ok
+	val, ok := c.entries[c.entryKey(key)]
+	if !ok {
+		return nil, false
+	}
+	return nullable(val), true
+}
+
+// Origin returns where the entry was set. Only configs read from files have
+// origins.
+func (c *config) Origin(key string) (entryOrigin, bool) {
+	origin, ok := c.origins[c.entryKey(key)]
+	return origin, ok
+}
+
+// entryKey returns the name of the entry with the same canonical name as key,
+// or the name of the same renamed variable (see variableAliases)
+func (c *config) entryKey(key string) string {
+	if _, ok := c.entries[key]; ok {
+		return key
+	}
+	canonical := variableName(key)
+	if _, ok := c.entries[canonical]; ok {
+		return canonical
+	}
+
+	if c.aliases == nil || c.indexed != len(c.entries) {
+		c.aliases = make(map[string]string)
+		for name := range c.entries {
+			if variable := variableName(name); variable != name {
+				c.aliases[variable] = name
+			}
+		}
+		c.indexed = len(c.entries)
+	}
+	if name, ok := c.aliases[canonical]; ok {
+		if _, ok := c.entries[name]; ok {
+			return name
+		}
+	}
+	return key
+}
+
+// setEntry sets an entry, keeping the aliases of entryKey up to date
+func (c *config) setEntry(name string, value interface{}) {
+	c.entries[name] = value
+	if c.aliases != nil {
+		if variable := variableName(name); variable != name {
+			c.aliases[variable] = name
+		}
+		c.indexed = len(c.entries)
+	}
+}
+
+// deleteEntry removes an entry. The aliases are built again if it was one,
+// since another spelling of the variable could be left.
+func (c *config) deleteEntry(name string) {
+	delete(c.entries, name)
+	if c.aliases != nil {
+		if variable := variableName(name); c.aliases[variable] == name {
+			c.aliases = nil
+			return
+		}
+		c.indexed = len(c.entries)
+	}
+}
+
+// canonicalName is the name variables are compared by: mysqld accepts
+// skip-name-resolve, Skip_Name_Resolve and skip_name_resolve for the same
+// option, and SHOW VARIABLES uses lowercase and underscores.
+func canonicalName(name string) string {
+	return strings.ToLower(strings.Replace(name, "-", "_", -1))
 }
 
 func (c *config) Type() string {
 	return c.configType
 }
+
+func (c *config) Name() string {
+	if c.name == "" {
+		return c.configType
+	}
+	return c.name
+}
+
+func (c *config) Section() string {
+	return c.section
+}
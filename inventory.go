@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// inventoryHost is a server of the --inventory file. The user and password
+// are used when the dsn has none, before the --login-path and --defaults-file
+// credentials.
+type inventoryHost struct {
+	DSN      string `yaml:"dsn"`
+	Label    string `yaml:"label"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+}
+
+// readInventory reads the servers of an inventory file. .csv files have a
+// header line with the dsn, label, user and password columns (only dsn is
+// required); other files are a YAML list of hosts:
+//
+//   - dsn: h=db1,P=3306
+//     label: primary
+//     user: monitor
+//     password: vault:secret/mysql/monitor
+func readInventory(filename string) ([]inventoryHost, error) {
+	if strings.ToLower(filepath.Ext(filename)) == ".csv" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseInventoryCSV(f)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []inventoryHost
+	if err := yaml.UnmarshalStrict(data, &hosts); err != nil {
+		return nil, fmt.Errorf("Invalid inventory %s: %s", filename, err.Error())
+	}
+	for i, host := range hosts {
+		if host.DSN == "" {
+			return nil, fmt.Errorf("Invalid inventory %s: host %d has no dsn", filename, i+1)
+		}
+	}
+	return hosts, nil
+}
+
+func parseInventoryCSV(r io.Reader) ([]inventoryHost, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid inventory: %s", err.Error())
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "dsn", "label", "user", "password":
+			columns[column] = i
+		default:
+			return nil, fmt.Errorf("Invalid inventory: unknown column %s", column)
+		}
+	}
+	if _, ok := columns["dsn"]; !ok {
+		return nil, fmt.Errorf("Invalid inventory: there is no dsn column")
+	}
+
+	var hosts []inventoryHost
+	for n, record := range records[1:] {
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		host := inventoryHost{DSN: field("dsn"), Label: field("label"), User: field("user"), Password: field("password")}
+		if host.DSN == "" {
+			return nil, fmt.Errorf("Invalid inventory: host %d has no dsn", n+1)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadInventory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatalf("Cannot create the temp dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "hosts.yaml")
+	ioutil.WriteFile(yamlFile, []byte("- dsn: h=db1,P=3307\n  label: primary\n  user: monitor\n  password: secret\n- dsn: root@tcp(db2:3306)/\n"), 0600)
+
+	opts, err := processParams(context.Background(), []string{"--inventory", yamlFile, "--dsn", "h=db3,u=root"})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := []string{"root@tcp(db3:3306)/", "monitor:secret@tcp(db1:3307)/", "root@tcp(db2:3306)/"}
+	if !reflect.DeepEqual(opts.DSNs, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", opts.DSNs, want)
+	}
+	if want := map[string]string{"monitor:secret@tcp(db1:3307)/": "primary"}; !reflect.DeepEqual(opts.dsnLabels, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", opts.dsnLabels, want)
+	}
+
+	got, err := parseInventoryCSV(strings.NewReader("dsn,label\n# replicas\nh=db2,replica1\nh=db3\n"))
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	wantHosts := []inventoryHost{{DSN: "h=db2", Label: "replica1"}, {DSN: "h=db3"}}
+	if !reflect.DeepEqual(got, wantHosts) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, wantHosts)
+	}
+
+	if _, err := parseInventoryCSV(strings.NewReader("host,label\ndb1,primary\n")); err == nil {
+		t.Error("Should return error on unknown columns")
+	}
+}
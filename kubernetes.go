@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// kubernetesAPI is the client-go client of the k8s-pod:// and
+// k8s-configmap:// files and of the sidecar. main creates one for the run and
+// the tests one with a fake clientset.
+type kubernetesAPI struct {
+	once      sync.Once
+	clientset kubernetes.Interface
+	config    *rest.Config // Used to stream the exec requests
+	namespace string       // Namespace of the kubeconfig context, or of the pod in a cluster
+	err       error
+
+	// exec runs a command in a container, if set. The tests set it since the
+	// fake clientset cannot stream.
+	exec func(ctx context.Context, namespace, pod, container string, command ...string) ([]byte, error)
+}
+
+// newKubernetesAPI returns a client that loads the kubeconfig (KUBECONFIG or
+// ~/.kube/config, with its current context), or the service account of the
+// pod when running in a cluster, the first time it is used
+func newKubernetesAPI() *kubernetesAPI {
+	return &kubernetesAPI{}
+}
+
+func (k *kubernetesAPI) client() (kubernetes.Interface, error) {
+	if k == nil {
+		return nil, fmt.Errorf("No Kubernetes client")
+	}
+	k.once.Do(func() {
+		if k.clientset != nil {
+			return
+		}
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+		k.config, k.err = clientConfig.ClientConfig()
+		if k.err != nil {
+			k.err = fmt.Errorf("Cannot load the Kubernetes config: %s", k.err.Error())
+			return
+		}
+		// In a cluster the namespace is POD_NAMESPACE or the one of the
+		// service account
+		if k.namespace, _, k.err = clientConfig.Namespace(); k.err != nil {
+			k.err = fmt.Errorf("Cannot get the Kubernetes namespace: %s", k.err.Error())
+			return
+		}
+		k.clientset, k.err = kubernetes.NewForConfig(k.config)
+	})
+	return k.clientset, k.err
+}
+
+// configMapData returns the data of a ConfigMap
+func (k *kubernetesAPI) configMapData(ctx context.Context, namespace, name string) (map[string]string, error) {
+	clientset, err := k.client()
+	if err != nil {
+		return nil, err
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return cm.Data, nil
+}
+
+// podExec runs a command in a container of a pod, the default one if
+// container is empty, and returns its standard output
+func (k *kubernetesAPI) podExec(ctx context.Context, namespace, pod, container string, command ...string) ([]byte, error) {
+	if k != nil && k.exec != nil {
+		return k.exec(ctx, namespace, pod, container, command...)
+	}
+	clientset, err := k.client()
+	if err != nil {
+		return nil, err
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Namespace(namespace).Resource("pods").Name(pod).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{Container: container, Command: command, Stdout: true, Stderr: true}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(k.config, "POST", req.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return nil, fmt.Errorf("%s in %s/%s: %s: %s", strings.Join(command, " "), namespace, pod, err.Error(), strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// emitEvent creates an event about a pod, shown by kubectl describe pod
+func (k *kubernetesAPI) emitEvent(ctx context.Context, namespace, pod, eventType, reason, message string, now time.Time) error {
+	clientset, err := k.client()
+	if err != nil {
+		return err
+	}
+	timestamp := metav1.NewTime(now)
+	event := &corev1.Event{
+		// Named like the events of the kubelet, pod.timestamp
+		ObjectMeta:     metav1.ObjectMeta{Name: fmt.Sprintf("%s.%x", pod, now.UnixNano()), Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Name: pod, Namespace: namespace},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1.EventSource{Component: sidecarComponent},
+		FirstTimestamp: timestamp,
+		LastTimestamp:  timestamp,
+		Count:          1,
+	}
+	_, err = clientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{})
+	return err
+}
+
+// annotatePod sets annotations on a pod, so the drift can be seen with
+// kubectl get pod -o yaml and used by operators
+func (k *kubernetesAPI) annotatePod(ctx context.Context, namespace, pod string, annotations map[string]string) error {
+	clientset, err := k.client()
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Pods(namespace).Patch(ctx, pod, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
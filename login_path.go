@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// loginKeyLength is the length of the key stored in the first bytes of
+// .mylogin.cnf, after 4 unused bytes
+const loginKeyLength = 20
+
+// clientCredentials are the user and password used for the dsns without them
+type clientCredentials struct {
+	User     string
+	Password string
+}
+
+// loginFileName returns the file mysql_config_editor writes, which the mysql
+// clients also read from MYSQL_TEST_LOGIN_FILE
+func loginFileName() string {
+	if name := os.Getenv("MYSQL_TEST_LOGIN_FILE"); name != "" {
+		return name
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".mylogin.cnf")
+}
+
+// decryptLoginFile decrypts a .mylogin.cnf file. It is an option file whose
+// lines are encrypted one by one with AES-128-ECB, each one preceded by its
+// length. The AES key is the stored key folded to 16 bytes with xor.
+func decryptLoginFile(data []byte) ([]byte, error) {
+	if len(data) < 4+loginKeyLength {
+		return nil, fmt.Errorf("The login file is too short")
+	}
+
+	key := make([]byte, aes.BlockSize)
+	for i, b := range data[4 : 4+loginKeyLength] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var plain bytes.Buffer
+	for rest := data[4+loginKeyLength:]; len(rest) > 0; {
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("The login file is truncated")
+		}
+		length := int(binary.LittleEndian.Uint32(rest[:4]))
+		rest = rest[4:]
+		if length == 0 || length%aes.BlockSize != 0 || length > len(rest) {
+			return nil, fmt.Errorf("Invalid line length %d in the login file", length)
+		}
+
+		line := make([]byte, length)
+		for i := 0; i < length; i += aes.BlockSize {
+			block.Decrypt(line[i:i+aes.BlockSize], rest[i:i+aes.BlockSize])
+		}
+		rest = rest[length:]
+
+		// PKCS#7 padding
+		padding := int(line[length-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, fmt.Errorf("Invalid padding in the login file. Is the file corrupted?")
+		}
+		plain.Write(line[:length-padding])
+	}
+
+	return plain.Bytes(), nil
+}
+
+// readLoginPath returns the credentials of a login path (a group of the
+// login file) created with mysql_config_editor set --login-path=name
+func readLoginPath(filename, name string) (clientCredentials, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return clientCredentials{}, err
+	}
+	plain, err := decryptLoginFile(data)
+	if err != nil {
+		return clientCredentials{}, err
+	}
+
+	options, err := parseOptionFile(filename, bytes.NewReader(plain), false)
+	if err != nil {
+		return clientCredentials{}, err
+	}
+
+	found := false
+	for _, option := range options {
+		found = found || option.Section == name
+	}
+	if !found {
+		return clientCredentials{}, fmt.Errorf("The login path %s is not in %s", name, filename)
+	}
+
+	return credentialsFrom(mergeOptions(options, name)), nil
+}
+
+// credentialsFrom returns the user and password options of a cnf
+func credentialsFrom(cnf *config) clientCredentials {
+	var creds clientCredentials
+	if user, ok := cnf.entries["user"].(string); ok {
+		creds.User = user
+	}
+	if password, ok := cnf.entries["password"].(string); ok {
+		creds.Password = password
+	}
+	return creds
+}
+
+// clientDefaultsFileName is the option file of the user read by the mysql
+// clients
+func clientDefaultsFileName() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".my.cnf")
+}
+
+// getClientCredentials returns the credentials of the MYSQL_USER and
+// MYSQL_PWD environment variables, overridden by the ones of the [client]
+// group of --defaults-file, or of ~/.my.cnf if it exists, and then by the
+// ones of --login-path, in the order the mysql clients read them
+func getClientCredentials(ctx context.Context, opts *options, runCommand commandRunner) (clientCredentials, error) {
+	creds := clientCredentials{User: os.Getenv("MYSQL_USER"), Password: os.Getenv("MYSQL_PWD")}
+
+	filename := opts.DefaultsFile
+	if filename == "" {
+		if _, err := os.Stat(clientDefaultsFileName()); err == nil {
+			filename = clientDefaultsFileName()
+		}
+	}
+	if filename != "" {
+		options, err := readOptionFiles(ctx, filename, cnfReadOptions{Groups: []string{"client"}}, runCommand)
+		if err != nil {
+			return clientCredentials{}, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+		}
+		creds = mergeCredentials(creds, credentialsFrom(mergeOptions(options, "client")))
+	}
+
+	if opts.LoginPath != "" {
+		loginCreds, err := readLoginPath(loginFileName(), opts.LoginPath)
+		if err != nil {
+			return clientCredentials{}, fmt.Errorf("Cannot read the login path %s: %s", opts.LoginPath, err.Error())
+		}
+		creds = mergeCredentials(creds, loginCreds)
+	}
+
+	return creds, nil
+}
+
+// mergeCredentials returns the credentials with the values set in override
+func mergeCredentials(creds, override clientCredentials) clientCredentials {
+	if override.User != "" {
+		creds.User = override.User
+	}
+	if override.Password != "" {
+		creds.Password = override.Password
+	}
+	return creds
+}
+
+// withCredentials sets the user and the password missing in a dsn
+func withCredentials(dsn string, creds clientCredentials) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if cfg.User == "" {
+		cfg.User = creds.User
+	}
+	if cfg.Passwd == "" {
+		cfg.Passwd = creds.Password
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// credentialsDBConnector wraps a dbConnector so the dsns without user or
+// password use the given credentials, which are kept out of the command line
+func credentialsDBConnector(creds clientCredentials, dbConnector func(string) (*sql.DB, error)) func(string) (*sql.DB, error) {
+	return func(dsn string) (*sql.DB, error) {
+		dsn, err := withCredentials(dsn, creds)
+		if err != nil {
+			return nil, err
+		}
+		return dbConnector(dsn)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encryptLoginFile writes a login file like mysql_config_editor does
+func encryptLoginFile(t *testing.T, content string) string {
+	storedKey := []byte("0123456789abcdefghij")
+	key := make([]byte, aes.BlockSize)
+	for i, b := range storedKey {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, _ := aes.NewCipher(key)
+
+	var data bytes.Buffer
+	data.Write([]byte{0, 0, 0, 0})
+	data.Write(storedKey)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		padding := aes.BlockSize - len(line)%aes.BlockSize
+		plain := append([]byte(line), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		encrypted := make([]byte, len(plain))
+		for i := 0; i < len(plain); i += aes.BlockSize {
+			block.Encrypt(encrypted[i:i+aes.BlockSize], plain[i:i+aes.BlockSize])
+		}
+		binary.Write(&data, binary.LittleEndian, uint32(len(encrypted)))
+		data.Write(encrypted)
+	}
+
+	filename := filepath.Join(t.TempDir(), ".mylogin.cnf")
+	if err := ioutil.WriteFile(filename, data.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestReadLoginPath(t *testing.T) {
+	filename := encryptLoginFile(t, "[client]\nuser = \"root\"\n[prod]\nuser = \"monitor\"\npassword = \"s3cr3t\"\nhost = \"db1\"\n")
+
+	got, err := readLoginPath(filename, "prod")
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if want := (clientCredentials{User: "monitor", Password: "s3cr3t"}); got != want {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := readLoginPath(filename, "staging"); err == nil {
+		t.Errorf("Should return an error for a missing login path")
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	creds := clientCredentials{User: "monitor", Password: "s3cr3t"}
+	tests := map[string]string{
+		"tcp(db1:3306)/":           "monitor:s3cr3t@tcp(db1:3306)/",
+		"root@tcp(db1:3306)/":      "root:s3cr3t@tcp(db1:3306)/",
+		"root:pass@tcp(db1:3306)/": "root:pass@tcp(db1:3306)/",
+	}
+	for dsn, want := range tests {
+		got, err := withCredentials(dsn, creds)
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", dsn, err.Error())
+		}
+		if got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+}
+
+func TestGetClientCredentials(t *testing.T) {
+	dir := t.TempDir()
+	defaultsFile := filepath.Join(dir, "client.cnf")
+	ioutil.WriteFile(defaultsFile, []byte("[mysqld]\nuser = mysql\n[client]\nuser = monitor\npassword = fromfile\n"), 0600)
+
+	// The defaults file overrides the environment
+	os.Setenv("MYSQL_USER", "envuser")
+	os.Setenv("MYSQL_PWD", "envpass")
+	defer os.Unsetenv("MYSQL_USER")
+	defer os.Unsetenv("MYSQL_PWD")
+	got, err := getClientCredentials(context.Background(), &options{}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if got.Password != "envpass" {
+		t.Errorf("Got: %s  --  Want: envpass\n", got.Password)
+	}
+
+	got, err = getClientCredentials(context.Background(), &options{DefaultsFile: defaultsFile}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if want := (clientCredentials{User: "monitor", Password: "fromfile"}); got != want {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// The login path overrides the defaults file
+	os.Setenv("MYSQL_TEST_LOGIN_FILE", encryptLoginFile(t, "[prod]\npassword = \"fromlogin\"\n"))
+	defer os.Unsetenv("MYSQL_TEST_LOGIN_FILE")
+	got, err = getClientCredentials(context.Background(), &options{DefaultsFile: defaultsFile, LoginPath: "prod"}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if want := (clientCredentials{User: "monitor", Password: "fromlogin"}); got != want {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
@@ -31,8 +34,8 @@ func TestCompareCNFs(t *testing.T) {
 
 	want := map[string][]interface{}{
 		"key2": []interface{}{2, 3},
-		"key3": []interface{}{true, "<Missing>"},
-		"key4": []interface{}{"<Missing>", true},
+		"key3": []interface{}{true, missing},
+		"key4": []interface{}{missing, true},
 	}
 
 	got := compare([]configReader{mockConfig1, mockConfig2})
@@ -68,7 +71,7 @@ func TestCompareCNFvsMySQL(t *testing.T) {
 
 	want := map[string][]interface{}{
 		"key2": []interface{}{2, 3},
-		"key3": []interface{}{true, "<Missing>"},
+		"key3": []interface{}{true, missing},
 	}
 
 	got := compare([]configReader{mockConfig1, mockConfig2})
@@ -79,6 +82,23 @@ func TestCompareCNFvsMySQL(t *testing.T) {
 
 }
 
+func TestCompareMissingBySourceType(t *testing.T) {
+	// Every source decides on its own if the variables it lacks are missing:
+	// a cnf after two servers doesn't report the variables it doesn't set
+	server1 := &config{configType: "mysql", entries: map[string]interface{}{"key1": "value1", "key2": "2"}}
+	server2 := &config{configType: "mysql", entries: map[string]interface{}{"key1": "value1"}}
+	cnf := &config{configType: "cnf", entries: map[string]interface{}{"key1": "value2"}}
+
+	want := map[string][]interface{}{
+		"key1": {"value1", "value2"},
+		"key2": {"2", missing},
+	}
+	got := compare([]configReader{server1, server2, cnf})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n %#v\n", got, want)
+	}
+}
+
 func TestAddDiff(t *testing.T) {
 
 	diffs := make(map[string][]interface{})
@@ -99,7 +119,7 @@ func TestAddDiff(t *testing.T) {
 
 func TestReadCNFs(t *testing.T) {
 
-	cnf, err := newCNFReader("some_fake_file")
+	cnf, err := newCNFReader(context.Background(), "some_fake_file", cnfReadOptions{}, nil)
 	if err == nil {
 		t.Error("Should return error on invalid files")
 	}
@@ -124,7 +144,7 @@ func TestReadCNFs(t *testing.T) {
 			"datadir":                           "/var/lib/mysql",
 			"local-infile":                      "1",
 			"explicit_defaults_for_timestamp":   "true",
-			"secure-file-priv":                  "\"\"",
+			"secure-file-priv":                  "",
 			"log-error":                         "/var/log/mysql/error.log",
 			"log_output":                        "file",
 			"slow_query_log_use_global_control": "all",
@@ -140,13 +160,18 @@ func TestReadCNFs(t *testing.T) {
 		},
 	}
 
-	cnf, err = newCNFReader("./test/mysqld.cnf")
+	cnf, err = newCNFReader(context.Background(), "./test/mysqld.cnf", cnfReadOptions{Strict: true}, nil)
 	if err != nil {
 		t.Errorf("Shouldn't return error on existent file: %s", err.Error())
 	}
 
-	if !reflect.DeepEqual(cnf, want) {
-		fmt.Printf("Got:\n%#v\nWant: %#v\n", cnf, want)
+	if !reflect.DeepEqual(cnf.Entries(), want.entries) {
+		t.Errorf("Got:\n%#v\nWant: %#v\n", cnf.Entries(), want.entries)
+	}
+
+	wantOrigin := entryOrigin{File: "./test/mysqld.cnf", Section: "mysqld", Line: 33, Text: "max_allowed_packet=128M", Order: 14}
+	if origin, _ := cnf.Origin("max_allowed_packet"); origin != wantOrigin {
+		t.Errorf("Got:\n%#v\nWant: %#v\n", origin, wantOrigin)
 	}
 
 }
@@ -161,7 +186,7 @@ func TestReadMySQL(t *testing.T) {
 
 	columns := []string{"Variable_name", "Value"}
 
-	mock.ExpectQuery("SHOW VARIABLES").WillReturnRows(sqlmock.NewRows(columns).
+	mock.ExpectQuery("SHOW GLOBAL VARIABLES").WillReturnRows(sqlmock.NewRows(columns).
 		AddRow("innodb_buffer_pool_size", "512M").
 		AddRow("log_slow_rate_limit", "100.1234").
 		AddRow("log_slow_verbosity", "full"))
@@ -175,7 +200,7 @@ func TestReadMySQL(t *testing.T) {
 		},
 	}
 
-	cnf, err := newMySQLReader(db)
+	cnf, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{QuerySource: "show"})
 	if err != nil {
 		t.Errorf("Shouldn't return error on mock up db: %s", err.Error())
 	}
@@ -201,7 +226,7 @@ func TestGetConfigs(t *testing.T) {
 
 		columns := []string{"Variable_name", "Value"}
 
-		mock.ExpectQuery("SHOW VARIABLES").WillReturnRows(sqlmock.NewRows(columns).
+		mock.ExpectQuery("SHOW GLOBAL VARIABLES").WillReturnRows(sqlmock.NewRows(columns).
 			AddRow("innodb_buffer_pool_size", "512M").
 			AddRow("log_slow_rate_limit", "100.1234").
 			AddRow("log_slow_verbosity", "full"))
@@ -209,7 +234,7 @@ func TestGetConfigs(t *testing.T) {
 		return db, nil
 	}
 
-	configs, err := getConfigs(opts, mockDBConnector)
+	configs, err := getConfigs(context.Background(), opts, mockDBConnector, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -226,6 +251,15 @@ func TestGetConfigs(t *testing.T) {
 		t.Errorf("Second config should be 'mysql'. Got: %s", configs[1].Type())
 	}
 
+	// --dsn before --cnf makes the server the base
+	opts.compareBase = "dsn"
+	configs, err = getConfigs(context.Background(), opts, mockDBConnector, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(configs) != 2 || configs[0].Type() != "mysql" || configs[1].Type() != "cnf" {
+		t.Errorf("The server must be the first config when the dsn is given first. Got: %#v", configs)
+	}
 }
 
 func TestJsonOutput(t *testing.T) {
@@ -248,7 +282,7 @@ func TestJsonOutput(t *testing.T) {
 		},
 	}
 
-	want := `{"key2":[2,3],"key3":[true,"\u003cMissing\u003e"],"key4":["\u003cMissing\u003e",true]}`
+	want := `{"key2":{"values":[2,3],"status":"different"},"key3":{"values":[true,null],"status":"missing"},"key4":{"values":[null,true],"status":"missing"}}`
 
 	diff := compare([]configReader{mockConfig1, mockConfig2})
 	jsonFormatter := &jsonOutput{}
@@ -256,24 +290,217 @@ func TestJsonOutput(t *testing.T) {
 	got, _ := jsonFormatter.Format(diff)
 
 	if got != want {
-		t.Errorf("Got:\n%#v\nWant:\n %#v\n", got, diff)
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+}
+
+func TestGetOutputFormatter(t *testing.T) {
+	diff := map[string][]interface{}{"key1": {"value1", "value2"}}
+	tests := map[string]string{
+		"json":       `{"key1":{"values":["value1","value2"],"status":"different"}}`,
+		"prettyJson": "{\n\t\"key1\": {\n\t\t\"values\": [\n\t\t\t\"value1\",\n\t\t\t\"value2\"\n\t\t],\n\t\t\"status\": \"different\"\n\t}\n}",
+		"plain":      fmt.Sprintf("%35s: %40s : %40s\n", "key1", "value1", "value2"),
+	}
+	for format, want := range tests {
+		formatter, err := getOutputFormatter(&options{OutputFmt: format}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s -- Shouldn't return error: %s", format, err.Error())
+		}
+		if got, _ := formatter.Format(diff); got != want {
+			t.Errorf("%s -- Got:\n%s\nWant:\n%s\n", format, got, want)
+		}
 	}
 
+	if _, err := getOutputFormatter(&options{OutputFmt: "xml"}, nil, nil); err == nil {
+		t.Errorf("Should return an error for an unknown output format")
+	}
 }
 
 func TestProcessParams(t *testing.T) {
 	args := []string{"--dsn=h=127.1,P=12345,u=user1,p=pass,D=db,t=table", "--cnf=mysqld.conf"}
-	opts, err := processParams(args)
+	opts, err := processParams(context.Background(), args)
 	if err != nil {
 		t.Errorf("Cannot parse params")
 	}
 	if opts.compareBase != "dsn" {
 		t.Errorf("Compare base must be dsn. Got %s", opts.compareBase)
 	}
+	// The pt style dsns are converted to the Go MySQL driver format
+	if want := []string{"user1:pass@tcp(127.1:12345)/db"}; !reflect.DeepEqual([]string(opts.DSNs), want) {
+		t.Errorf("Got: %#v  --  Want: %#v\n", opts.DSNs, want)
+	}
 
 	args = []string{"--cnf=mysqld.conf", "--dsn=h=127.1,P=12345,u=user1,p=pass,D=db,t=table"}
-	opts, err = processParams(args)
+	opts, err = processParams(context.Background(), args)
 	if opts.compareBase != "cnf" {
 		t.Errorf("Compare base must be cnf. Got %s", opts.compareBase)
 	}
 }
+
+func TestReadMySQLQuerySources(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"VARIABLE_NAME", "VARIABLE_VALUE"}
+
+	mock.ExpectQuery("SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_variables").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("log_slow_verbosity", "full"))
+
+	cnf, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{QuerySource: "performance_schema"})
+	if err != nil {
+		t.Errorf("Shouldn't return error reading performance_schema: %s", err.Error())
+	}
+	if got, _ := cnf.Get("log_slow_verbosity"); got != "full" {
+		t.Errorf("Got: %#v  --  Want: %#v\n", got, "full")
+	}
+
+	mock.ExpectQuery(`SELECT @@GLOBAL\.innodb_buffer_pool_size`).
+		WillReturnRows(sqlmock.NewRows([]string{"@@GLOBAL.innodb_buffer_pool_size"}).AddRow("536870912"))
+	mock.ExpectQuery(`SELECT @@GLOBAL\.some_unknown_var`).
+		WillReturnError(&mysql.MySQLError{Number: 1193, Message: "Unknown system variable"})
+
+	cnf, err = newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{
+		QuerySource: "select_at_at",
+		Wanted:      []string{"innodb_buffer_pool_size", "some-unknown-var", "bad;name"},
+	})
+	if err != nil {
+		t.Errorf("Shouldn't return error on unknown variables: %s", err.Error())
+	}
+
+	want := &config{
+		configType: "mysql",
+		name:       "mock",
+		entries: map[string]interface{}{
+			"innodb_buffer_pool_size": "536870912",
+		},
+	}
+	if !reflect.DeepEqual(cnf, want) {
+		t.Errorf("Got:\n%#v\nWant: %#v\n", cnf, want)
+	}
+
+	if _, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{QuerySource: "invalid"}); err == nil {
+		t.Error("Should return error on invalid query sources")
+	}
+
+	mock.ExpectQuery("SHOW SESSION VARIABLES").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("sql_mode", "ANSI_QUOTES"))
+	cnf, err = newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{Scope: "session"})
+	if err != nil {
+		t.Errorf("Shouldn't return error reading the session variables: %s", err.Error())
+	}
+	if got, _ := cnf.Get("sql_mode"); got != "ANSI_QUOTES" {
+		t.Errorf("Got: %#v  --  Want: %#v\n", got, "ANSI_QUOTES")
+	}
+
+	if _, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{Scope: "local"}); err == nil {
+		t.Error("Should return error on invalid scopes")
+	}
+
+}
+
+func TestPlainOutputMissingValue(t *testing.T) {
+	diff := map[string][]interface{}{"key1": []interface{}{"1", missing}}
+
+	dash := "-"
+	want := fmt.Sprintf("%35s: %40s : %40s\n", "key1", "1", "-")
+	got, _ := (&plainOutput{missingText: &dash}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	// An explicit --missing-value "" shows nothing
+	opts, err := processParams(context.Background(), []string{"--missing-value="})
+	if err != nil {
+		t.Fatalf("Cannot parse params: %s", err)
+	}
+	formatter, _ := getOutputFormatter(opts, nil, nil)
+	want = fmt.Sprintf("%35s: %40s : %40s\n", "key1", "1", "")
+	if got, _ := formatter.Format(diff); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestRestartRequiredAnnotation(t *testing.T) {
+	diff := map[string][]interface{}{"datadir": []interface{}{"/var/lib/mysql", "/data/mysql"}}
+
+	want := fmt.Sprintf("%35s: %40s : %40s\n", "datadir", "/var/lib/mysql", "/data/mysql") +
+		fmt.Sprintf("%35s  restart required, it cannot be changed with SET GLOBAL\n", "")
+	got, _ := (&plainOutput{verbose: true}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	// Without --verbose only the values are shown
+	want = fmt.Sprintf("%35s: %40s : %40s\n", "datadir", "/var/lib/mysql", "/data/mysql")
+	if got, _ := (&plainOutput{}).Format(diff); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	wantJSON := `{"datadir":{"values":["/var/lib/mysql","/data/mysql"],"status":"different","restart_required":true}}`
+	if got, _ := (&jsonOutput{verbose: true}).Format(diff); got != wantJSON {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantJSON)
+	}
+}
+
+func TestNullEmptyAndMissing(t *testing.T) {
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{"secure_file_priv": nil, "init_connect": ""}}
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{"secure_file_priv": "", "init_connect": "", "tmpdir": "/tmp"}}
+
+	if value, ok := server.Get("secure_file_priv"); !ok || !isNull(value) || value == nil {
+		t.Errorf("Got: %#v  --  Want: %#v\n", value, sqlNull)
+	}
+	configs := compactConfigs([]configReader{cnf, server}, newInternPool())
+	if value, _ := configs[1].Get("secure_file_priv"); value != sqlNull {
+		t.Errorf("Got: %#v  --  Want: %#v\n", value, sqlNull)
+	}
+
+	diff := (&comparer{}).compare(configs)
+	want := map[string][]interface{}{
+		"secure_file_priv": {"", sqlNull},
+		"tmpdir":           {"/tmp", missing},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("Got:\n%#v\nWant:\n%#v\n", diff, want)
+	}
+
+	got, _ := (&plainOutput{}).Format(map[string][]interface{}{"secure_file_priv": diff["secure_file_priv"]})
+	wantPlain := fmt.Sprintf("%35s: %40s : %40s\n", "secure_file_priv", "''", "NULL")
+	if got != wantPlain {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantPlain)
+	}
+
+	got, _ = (&jsonOutput{verbose: true}).Format(diff)
+	wantJSON := `{"secure_file_priv":{"values":["",null],"status":"different","states":["empty","null"],"restart_required":true,"impact":"security","severity":"high"},` +
+		`"tmpdir":{"values":["/tmp",null],"status":"missing","restart_required":true}}`
+	if got != wantJSON {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantJSON)
+	}
+
+	// Without --verbose every variable has its values and status, and the
+	// states when a value is empty or NULL, so NULL is not a missing value
+	got, _ = (&jsonOutput{}).Format(diff)
+	wantJSON = `{"secure_file_priv":{"values":["",null],"status":"different","states":["empty","null"]},` +
+		`"tmpdir":{"values":["/tmp",null],"status":"missing"}}`
+	if got != wantJSON {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantJSON)
+	}
+	got, _ = (&jsonOutput{}).Format(map[string][]interface{}{"init_connect": {"NULL", "ON"}})
+	if want := `{"init_connect":{"values":["NULL","ON"],"status":"different"}}`; got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	pairs := (&comparer{}).comparePairwise(configs).Pairs
+	if states := pairs[0].States["secure_file_priv"]; !reflect.DeepEqual(states, []string{"empty", "null"}) {
+		t.Errorf("Got: %#v  --  Want: %#v\n", states, []string{"empty", "null"})
+	}
+
+	got, _ = (&sqlOutput{}).Format(map[string][]interface{}{"init_connect": {sqlNull, "SET NAMES utf8mb4"}})
+	if !strings.Contains(got, "SET GLOBAL init_connect = NULL;") {
+		t.Errorf("Got:\n%s\nWant: SET GLOBAL init_connect = NULL;\n", got)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+)
+
+// mariaDBRenames maps the MariaDB names of variables renamed by MySQL to the
+// MySQL names. MariaDB servers also report them with the MySQL name, so they
+// are compared with the MySQL servers and the cnf files of both.
+var mariaDBRenames = map[string]string{
+	"tx_isolation":                 "transaction_isolation",
+	"tx_read_only":                 "transaction_read_only",
+	"log_slave_updates":            "log_replica_updates",
+	"slave_compressed_protocol":    "replica_compressed_protocol",
+	"slave_exec_mode":              "replica_exec_mode",
+	"slave_load_tmpdir":            "replica_load_tmpdir",
+	"slave_max_allowed_packet":     "replica_max_allowed_packet",
+	"slave_net_timeout":            "replica_net_timeout",
+	"slave_skip_errors":            "replica_skip_errors",
+	"slave_sql_verify_checksum":    "replica_sql_verify_checksum",
+	"slave_transaction_retries":    "replica_transaction_retries",
+	"slave_type_conversions":       "replica_type_conversions",
+	"master_verify_checksum":       "source_verify_checksum",
+	"sql_slave_skip_counter":       "sql_replica_skip_counter",
+	"skip_slave_start":             "skip_replica_start",
+	"slave_parallel_workers":       "replica_parallel_workers",
+	"rpl_semi_sync_master_enabled": "rpl_semi_sync_source_enabled",
+	"rpl_semi_sync_slave_enabled":  "rpl_semi_sync_replica_enabled",
+}
+
+// mariaDBVariablePrefixes are the prefixes of the MariaDB only variables:
+// storage engines and plugins not available in MySQL
+var mariaDBVariablePrefixes = []string{"aria_", "spider_", "s3_", "columnstore_", "mroonga_", "system_versioning_"}
+
+// mariaDBVariables are MariaDB only variables without one of the prefixes
+var mariaDBVariables = map[string]bool{
+	"analyze_sample_percentage":           true,
+	"binlog_annotate_row_events":          true,
+	"binlog_commit_wait_count":            true,
+	"binlog_commit_wait_usec":             true,
+	"deadlock_search_depth_long":          true,
+	"deadlock_search_depth_short":         true,
+	"deadlock_timeout_long":               true,
+	"deadlock_timeout_short":              true,
+	"encrypt_binlog":                      true,
+	"encrypt_tmp_disk_tables":             true,
+	"encrypt_tmp_files":                   true,
+	"expensive_subquery_limit":            true,
+	"extra_max_connections":               true,
+	"extra_port":                          true,
+	"gtid_binlog_pos":                     true,
+	"gtid_binlog_state":                   true,
+	"gtid_cleanup_batch_size":             true,
+	"gtid_current_pos":                    true,
+	"gtid_domain_id":                      true,
+	"gtid_ignore_duplicates":              true,
+	"gtid_pos_auto_engines":               true,
+	"gtid_slave_pos":                      true,
+	"gtid_strict_mode":                    true,
+	"histogram_size":                      true,
+	"histogram_type":                      true,
+	"innodb_defragment":                   true,
+	"innodb_encrypt_log":                  true,
+	"innodb_encrypt_tables":               true,
+	"innodb_encrypt_temporary_tables":     true,
+	"innodb_encryption_threads":           true,
+	"innodb_instant_alter_column_allowed": true,
+	"join_cache_level":                    true,
+	"log_slow_rate_limit":                 true,
+	"max_statement_time":                  true,
+	"optimizer_use_condition_selectivity": true,
+	"progress_report_time":                true,
+	"query_cache_strip_comments":          true,
+	"slave_ddl_exec_mode":                 true,
+	"slave_domain_parallel_threads":       true,
+	"slave_parallel_max_queued":           true,
+	"slave_parallel_mode":                 true,
+	"slave_parallel_threads":              true,
+	"slave_run_triggers_for_rbr":          true,
+	"use_stat_tables":                     true,
+	"userstat":                            true,
+}
+
+// isMariaDBVersion returns true for the versions reported by MariaDB servers,
+// like 10.11.6-MariaDB-log
+func isMariaDBVersion(version string) bool {
+	return strings.Contains(strings.ToLower(version), "mariadb")
+}
+
+// isMariaDBVariable returns true for the variables that only MariaDB has
+func isMariaDBVariable(name string) bool {
+	name = strings.Replace(name, "-", "_", -1)
+	if mariaDBVariables[name] {
+		return true
+	}
+	for _, prefix := range mariaDBVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagMariaDB sets the mariadb type to the servers that are MariaDB, and adds
+// the MySQL names of its renamed variables
+func tagMariaDB(cfg *config) {
+	version, ok := cfg.entries["version"]
+	if !ok || !isMariaDBVersion(valueString(version)) {
+		return
+	}
+
+	cfg.configType = "mariadb"
+	for name, mysqlName := range mariaDBRenames {
+		if value, ok := cfg.entries[name]; ok {
+			if _, ok := cfg.entries[mysqlName]; !ok {
+				cfg.entries[mysqlName] = value
+			}
+		}
+	}
+}
+
+// missingByFlavor returns true if the variable is not in a config only
+// because it is a MySQL server and the variable only exists in MariaDB
+func missingByFlavor(key, configType string) bool {
+	return configType == "mysql" && isMariaDBVariable(key)
+}
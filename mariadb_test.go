@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagMariaDB(t *testing.T) {
+	cfg := &config{configType: "mysql", name: "maria1:3306", entries: map[string]interface{}{
+		"version":      "10.11.6-MariaDB-log",
+		"tx_isolation": "READ-COMMITTED",
+	}}
+	tagMariaDB(cfg)
+
+	want := &config{configType: "mariadb", name: "maria1:3306", entries: map[string]interface{}{
+		"version":               "10.11.6-MariaDB-log",
+		"tx_isolation":          "READ-COMMITTED",
+		"transaction_isolation": "READ-COMMITTED",
+	}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg, want)
+	}
+
+	mysql := &config{configType: "mysql", entries: map[string]interface{}{"version": "8.0.36"}}
+	if tagMariaDB(mysql); mysql.configType != "mysql" {
+		t.Errorf("MySQL servers must not be tagged as mariadb")
+	}
+}
+
+func TestCompareMariaDBvsMySQL(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "mariadb.cnf", entries: map[string]interface{}{
+		"aria_pagecache_buffer_size": "128M",
+		"gtid_strict_mode":           "ON",
+		"max_connections":            "500",
+	}}
+	mysql := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{
+		"version":         "8.0.36",
+		"max_connections": "500",
+	}}
+	mariadb := &config{configType: "mariadb", name: "maria1:3306", entries: map[string]interface{}{
+		"version":                    "10.11.6-MariaDB",
+		"aria_pagecache_buffer_size": "134217728",
+		"gtid_strict_mode":           "ON",
+		"max_connections":            "500",
+		"transaction_isolation":      "REPEATABLE-READ",
+	}}
+
+	got := compare([]configReader{cnf, mysql, mariadb})
+	want := map[string][]interface{}{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
+
+func TestReadMariaDBGroups(t *testing.T) {
+	got := cnfReadOptions{ServerVersion: "10.11.6-MariaDB"}.groups()
+	want := []string{"mysqld", "mysqld-10.11", "mariadb", "mariadb-10.11", "mariadbd", "mariadbd-10.11"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// globalBuffers are the variables of the memory allocated once by the server
+var globalBuffers = []string{"innodb_buffer_pool_size", "innodb_log_buffer_size", "key_buffer_size", "query_cache_size"}
+
+// connectionBuffers are the variables of the memory every connection can
+// allocate. They are the worst case, since most of them are only allocated
+// by the queries that need them.
+var connectionBuffers = []string{"sort_buffer_size", "join_buffer_size", "read_buffer_size", "read_rnd_buffer_size", "thread_stack", "binlog_cache_size", "tmp_table_size"}
+
+// defaultMemoryTolerance is how much the memory budgets can differ from the
+// first source's without --tolerance
+const defaultMemoryTolerance = "10%"
+
+// memoryBudget is the approximate memory a config allows: the global buffers
+// plus the per connection buffers times max_connections
+type memoryBudget struct {
+	Source         string   `json:"source"`
+	Global         float64  `json:"global"`
+	PerConnection  float64  `json:"per_connection"`
+	MaxConnections float64  `json:"max_connections"`
+	Total          float64  `json:"total"`
+	Defaults       []string `json:"defaults,omitempty"` // Variables not set, whose default is used
+	Different      bool     `json:"different"`          // The total is not within the tolerance of the first source
+}
+
+// getMemoryBudget computes the budget of a config. The variables not set take
+// the documented default of the version of the config, or of the given one.
+func getMemoryBudget(cfg configReader, version string) memoryBudget {
+	if v, ok := cfg.Get("version"); ok {
+		version = valueString(v)
+	}
+	budget := memoryBudget{Source: cfg.Name()}
+	value := func(name string) float64 {
+		if v, ok := cfg.Get(name); ok && !isAutoSized(name, v) {
+			if typed := parseTypedValue(name, v); typed.isNumber() {
+				return typed.number
+			}
+		}
+		info, _ := getVariableInfo(name)
+		if def, ok := defaultForVersion(info, version); ok {
+			budget.Defaults = append(budget.Defaults, name)
+			return parseTypedValue(name, def).number
+		}
+		return 0
+	}
+
+	for _, name := range globalBuffers {
+		budget.Global += value(name)
+	}
+	for _, name := range connectionBuffers {
+		budget.PerConnection += value(name)
+	}
+	budget.MaxConnections = value("max_connections")
+	budget.Total = budget.Global + budget.PerConnection*budget.MaxConnections
+	return budget
+}
+
+// compareMemoryBudgets returns the budget of every config, flagging the ones
+// whose total is not within the tolerance of the first one's
+func compareMemoryBudgets(configs []configReader, t tolerance) []memoryBudget {
+	version := serverVersion(configs)
+	if version == "" {
+		version = "8.0"
+	}
+
+	budgets := make([]memoryBudget, len(configs))
+	for i, cfg := range configs {
+		budgets[i] = getMemoryBudget(cfg, version)
+		if i > 0 {
+			base := typedValue{kind: floatKind, number: budgets[0].Total}
+			total := typedValue{kind: floatKind, number: budgets[i].Total}
+			budgets[i].Different = budgets[i].Total != budgets[0].Total && !t.match(base, total)
+		}
+	}
+	return budgets
+}
+
+// runMemoryDiff compares the memory the configs allow the servers to use,
+// which can be very different even when only a few variables differ
+func runMemoryDiff(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	value := opts.Tolerance
+	if value == "" {
+		value = defaultMemoryTolerance
+	}
+	t, err := parseTolerance(value)
+	if err != nil {
+		return "", err
+	}
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+	budgets := compareMemoryBudgets(configs, t)
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(budgets, "", "\t")
+		} else {
+			output, err = json.Marshal(budgets)
+		}
+		return string(output), err
+	case "plain":
+		return formatMemoryBudgets(budgets), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for memory-diff", opts.OutputFmt)
+	}
+}
+
+func formatMemoryBudgets(budgets []memoryBudget) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf("%-40s %10s %14s %8s %10s\n", "source", "global", "per connection", "max conn", "total"))
+	different := 0
+	for i, budget := range budgets {
+		line := fmt.Sprintf("%-40s %10s %14s %8.0f %10s", budget.Source, formatBytes(budget.Global), formatBytes(budget.PerConnection), budget.MaxConnections, formatBytes(budget.Total))
+		if budget.Different {
+			different++
+			if budgets[0].Total > 0 {
+				line += fmt.Sprintf("  %+.0f%% of %s", (budget.Total/budgets[0].Total-1)*100, budgets[0].Source)
+			} else {
+				line += "  different"
+			}
+		} else if i > 0 && budget.Total != budgets[0].Total {
+			line += "  within the tolerance"
+		}
+		buffer.WriteString(line + "\n")
+	}
+	if len(budgets) > 1 {
+		buffer.WriteString(fmt.Sprintf("%d of %d sources have a different memory budget than %s\n", different, len(budgets)-1, budgets[0].Source))
+	}
+
+	return buffer.String()
+}
+
+// formatBytes shows a number of bytes with the K, M, G or T suffix of the cnf
+// files
+func formatBytes(value float64) string {
+	for _, suffix := range []string{"T", "G", "M", "K"} {
+		multiplier := float64(sizeMultipliers[suffix])
+		if value >= multiplier {
+			if math.Mod(value, multiplier) == 0 {
+				return fmt.Sprintf("%.0f%s", value/multiplier, suffix)
+			}
+			return fmt.Sprintf("%.1f%s", value/multiplier, suffix)
+		}
+	}
+	return fmt.Sprintf("%.0f", value)
+}
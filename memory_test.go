@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"innodb_buffer_pool_size": "8G",
+		"max_connections":         "100",
+		"sort_buffer_size":        "2M",
+	}}
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"version":                 "8.0.36",
+		"innodb_buffer_pool_size": "8589934592",
+		"max_connections":         "1000",
+		"sort_buffer_size":        "2097152",
+	}}
+
+	budget := getMemoryBudget(cnf, "8.0")
+	// 16M of innodb_log_buffer_size and 8M of key_buffer_size, and the
+	// defaults of the other per connection buffers
+	if want := float64(8<<30 + 24<<20); budget.Global != want {
+		t.Errorf("Got: %.0f  --  Want: %.0f\n", budget.Global, want)
+	}
+	if want := float64(2<<20 + 262144 + 131072 + 262144 + 1048576 + 32768 + 16777216); budget.PerConnection != want {
+		t.Errorf("Got: %.0f  --  Want: %.0f\n", budget.PerConnection, want)
+	}
+	wantDefaults := []string{"innodb_log_buffer_size", "key_buffer_size", "join_buffer_size", "read_buffer_size", "read_rnd_buffer_size", "thread_stack", "binlog_cache_size", "tmp_table_size"}
+	if !reflect.DeepEqual(budget.Defaults, wantDefaults) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", budget.Defaults, wantDefaults)
+	}
+
+	tolerance, _ := parseTolerance(defaultMemoryTolerance)
+	budgets := compareMemoryBudgets([]configReader{cnf, server}, tolerance)
+	if budgets[0].Different || !budgets[1].Different {
+		t.Errorf("Only the server with 10 times the connections should be different. Got: %#v", budgets)
+	}
+
+	if got := formatBytes(8 << 30); got != "8G" {
+		t.Errorf("Got: %s  --  Want: %s\n", got, "8G")
+	}
+	if got := formatBytes(1536 << 10); got != "1.5M" {
+		t.Errorf("Got: %s  --  Want: %s\n", got, "1.5M")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// variableInfo has what we know about a server variable that cannot be
+// deduced from its value.
+type variableInfo struct {
+	Type          string // integer, size, numeric, boolean, enumeration, set, list, string, file or directory
+	Scope         string // global, session or both
+	Description   string
+	Defaults      map[string]string // Documented default per major.minor version
+	Dynamic       bool              // Can be changed at runtime with SET GLOBAL
+	Unlimited     bool              // The max values (2^32-1, 2^64-1) mean "no limit"
+	ZeroUnlimited bool              // 0 also means "no limit"
+	AutoSize      string            // Value that asks the server to size the variable, like -1
+	Unit          string            // Of the durations: microseconds, milliseconds, seconds or days
+
+	// PlatformDefaults are the effective values, per OS, when the variable
+	// is not set or is empty
+	PlatformDefaults map[string]string
+}
+
+// getVariableInfo returns the metadata for a variable. cnf style names (with
+// dashes) are accepted, and so are both names of the renamed variables.
+func getVariableInfo(name string) (variableInfo, bool) {
+	name = strings.Replace(name, "-", "_", -1)
+	info, ok := variablesMetadata[name]
+	if !ok {
+		if previous, renamed := previousNames[name]; renamed {
+			info, ok = variablesMetadata[previous]
+		}
+	}
+	if !ok && strings.HasPrefix(name, "performance_schema_") {
+		// The performance_schema sizing variables are autosized with -1
+		return variableInfo{Dynamic: false, AutoSize: "-1"}, true
+	}
+	return info, ok
+}
+
+// maxSentinels are the max values for 32 and 64 bits variables, used by the
+// server as "no limit"
+var maxSentinels = map[string]bool{
+	"4294967295":           true,
+	"18446744073709551615": true,
+	"9223372036854775807":  true,
+}
+
+// unlimitedNormalizer returns UNLIMITED for the values that mean "no limit"
+// for the variable, so "0" in a cnf, 4294967295 on a 32 bits server and
+// 18446744073709551615 on a 64 bits one are the same.
+func unlimitedNormalizer(name string, value interface{}) interface{} {
+	info, ok := getVariableInfo(name)
+	if !ok || !info.Unlimited {
+		return value
+	}
+	str := strings.TrimSpace(valueString(value))
+	if maxSentinels[str] || (info.ZeroUnlimited && str == "0") {
+		return "UNLIMITED"
+	}
+	return value
+}
+
+// isAutoSized returns true if the value asks the server to size the variable.
+// Since the server reports the computed value, it is equal to any other value.
+func isAutoSized(name string, value interface{}) bool {
+	info, ok := getVariableInfo(name)
+	return ok && info.AutoSize != "" && strings.TrimSpace(valueString(value)) == info.AutoSize
+}
+
+// isDynamic returns false only for variables known to require a restart.
+func isDynamic(name string) bool {
+	info, ok := getVariableInfo(name)
+	return !ok || info.Dynamic
+}
+
+// isPlatformDefault returns true if the value is what the server uses on the
+// platform when the variable is not set. Empty values are defaults too.
+func isPlatformDefault(name, platform string, value interface{}) bool {
+	info, ok := getVariableInfo(name)
+	if !ok || info.PlatformDefaults == nil {
+		return false
+	}
+	str := strings.TrimSpace(valueString(value))
+	return str == "" || strings.EqualFold(str, info.PlatformDefaults[platform])
+}
+
+// versionAtLeast returns true if a version string like 8.0.36-28 or
+// 5.7.44-log is greater or equal than major.minor
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	gotMinor, err := strconv.Atoi(strings.TrimFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err != nil {
+		return false
+	}
+
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
@@ -1,60 +1,36 @@
 package main
 
 import (
-	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 )
 
+// normalizer changes a value before it is parsed by parseTypedValue, like the
+// custom normalizers of the --config file
 type normalizer func(interface{}) interface{}
-type normalizers []normalizer
 
-func Normalize(str interface{}) interface{} {
-	normalizers := normalizers{
-		sizesNormalizer,
-		numbersNormalizer,
-		setsNormalizer,
-	}
-	for _, normalizer := range normalizers {
-		str = normalizer(str)
-	}
-
-	return str
+var sizeMultipliers = map[string]int64{
+	"K": 1024,
+	"M": 1048576,
+	"G": 1073741824,
+	"T": 1099511627776,
+	"P": 1125899906842624,
+	"E": 1152921504606846976,
 }
 
+// sizesNormalizer expands the sizes like 1G to their number of bytes, parsed
+// like the integer values. Other values are returned as they are.
 func sizesNormalizer(value interface{}) interface{} {
-	re := regexp.MustCompile(`(?i)^(\d*?)([KMGT])$`)
-
-	replaceMap := map[string]int64{
-		"K": 1024,
-		"M": 1048576,
-		"G": 1073741824,
-		"T": 1099511627776,
+	str := valueString(value)
+	if str == "" || !strings.ContainsAny(str[len(str)-1:], "KMGTPEkmgtpe") {
+		return value
 	}
-	if groups := re.FindStringSubmatch(fmt.Sprintf("%s", value)); len(groups) > 0 {
-		numPart := groups[1]
-		multiplier := replaceMap[strings.ToUpper(groups[2])]
-		i, _ := strconv.ParseInt(numPart, 10, 64)
-
-		return fmt.Sprintf("%d", i*multiplier)
-	}
-
-	return value
-}
-
-func numbersNormalizer(value interface{}) interface{} {
-	float1, err := strconv.ParseFloat(fmt.Sprintf("%s", value), 64)
-	if err == nil {
-		return fmt.Sprintf("%.0f", float1)
+	if v, ok := parseInteger(str); ok {
+		return v.text
 	}
 	return value
 }
 
+// setsNormalizer sorts the members of a set, parsed like the set values
 func setsNormalizer(value interface{}) interface{} {
-	splitedValues := strings.Split(fmt.Sprintf("%s", value), ",")
-	sort.Strings(splitedValues)
-
-	return strings.Join(splitedValues, ",")
+	return parseSet(valueString(value)).text
 }
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type outputFormatter interface {
+	Format(map[string][]interface{}) (string, error)
+}
+
+// reportSection is a list reported after the differences, like the rule
+// violations. The JSON outputs become an object with the differences and
+// every list under its key. The plain output adds the lines under the title,
+// if there are any.
+type reportSection struct {
+	Key   string
+	Title string
+	Items interface{}
+	Lines []string
+}
+
+// formatReportSections adds the sections to the formatted differences. The
+// other outputs only have the differences.
+func formatReportSections(format, output string, sections []reportSection) (string, error) {
+	switch format {
+	case "json", "prettyJson":
+		result := map[string]interface{}{"differences": json.RawMessage(output)}
+		for _, section := range sections {
+			result[section.Key] = section.Items
+		}
+		var data []byte
+		var err error
+		if format == "prettyJson" {
+			data, err = json.MarshalIndent(result, "", "\t")
+		} else {
+			data, err = json.Marshal(result)
+		}
+		return string(data), err
+	case "plain":
+		var buffer bytes.Buffer
+		buffer.WriteString(output)
+		for _, section := range sections {
+			if len(section.Lines) == 0 {
+				continue
+			}
+			buffer.WriteString("# " + section.Title + "\n")
+			for _, line := range section.Lines {
+				buffer.WriteString(line + "\n")
+			}
+		}
+		return buffer.String(), nil
+	default:
+		return output, nil
+	}
+}
+
+// missingValue is the value stored in the diffs for variables that are not
+// set in a config. It is a type of its own so it cannot be mistaken for a real
+// value: it is shown as <Missing> (or the --missing-value text) in the text
+// formats and as null in JSON.
+type missingValue struct{}
+
+var missing = missingValue{}
+
+const defaultMissingText = "<Missing>"
+
+func (missingValue) String() string {
+	return defaultMissingText
+}
+
+func (missingValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func isMissing(value interface{}) bool {
+	_, ok := value.(missingValue)
+	return ok
+}
+
+// nullValue is the value of the variables that are NULL in a server, like
+// secure_file_priv when import and export are disabled. It is not the same as
+// an empty value nor as a missing variable, and it is shown as NULL, like
+// SHOW VARIABLES does. In JSON it is null, with the null state that tells it
+// apart from the missing values and from the text 'NULL'.
+type nullValue struct{}
+
+var sqlNull = nullValue{}
+
+func (nullValue) String() string {
+	return "NULL"
+}
+
+func (nullValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func isNull(value interface{}) bool {
+	_, ok := value.(nullValue)
+	return ok || value == nil
+}
+
+// nullable returns sqlNull for the nil values read from the servers
+func nullable(value interface{}) interface{} {
+	if value == nil {
+		return sqlNull
+	}
+	return value
+}
+
+// valueState tells a value apart from the empty, NULL and missing ones: set,
+// empty, null or missing
+func valueState(value interface{}) string {
+	switch {
+	case isMissing(value):
+		return "missing"
+	case isNull(value):
+		return "null"
+	case valueString(value) == "":
+		return "empty"
+	default:
+		return "set"
+	}
+}
+
+// diffDetail is the verbose version of a diff entry: the values plus
+// everything we know about where they come from.
+type diffDetail struct {
+	Values  []interface{} `json:"values"`
+	Status  string        `json:"status"` // different, missing if some config doesn't set it, or equal (--report-identical)
+	Origins []entryOrigin `json:"origins,omitempty"`
+
+	// States tell the empty, NULL and missing values apart: set, empty, null
+	// or missing per value. Only when some value is empty or NULL.
+	States []string `json:"states,omitempty"`
+
+	// RestartRequired is set for the variables that cannot be changed with
+	// SET GLOBAL: fixing the difference needs a restart
+	RestartRequired bool `json:"restart_required,omitempty"`
+
+	// Impact and Severity classify the variable, see impactRules. Empty for
+	// the variables without impact category.
+	Impact   string `json:"impact,omitempty"`
+	Severity string `json:"severity,omitempty"`
+
+	// DefaultChange is set with --default-changes when the default of the
+	// variable is different in the new version
+	DefaultChange *defaultChange `json:"default_change,omitempty"`
+
+	// Delta and Ratio compare the second number to the first one, with
+	// --show-delta. See numericDelta.
+	Delta *float64 `json:"delta,omitempty"`
+	Ratio *float64 `json:"ratio,omitempty"`
+}
+
+// getDiffDetails adds the extra information available in the configs to
+// every diff entry.
+func getDiffDetails(diff map[string][]interface{}, configs []configReader, defaultChanges map[string]defaultChange) map[string]diffDetail {
+	details := make(map[string]diffDetail, len(diff))
+	for key, values := range diff {
+		detail := diffDetail{Values: values, Status: "different", RestartRequired: !isDynamic(key)}
+		if impact, severity := variableImpact(key); impact != "" {
+			detail.Impact, detail.Severity = impact, severity
+		}
+		detail.States = valueStates(values)
+		for _, value := range values {
+			if isMissing(value) {
+				detail.Status = "missing"
+			}
+		}
+		for _, cfg := range configs {
+			if origin, ok := cfg.Origin(key); ok {
+				detail.Origins = append(detail.Origins, origin)
+			}
+		}
+		if change, ok := defaultChanges[key]; ok {
+			detail.DefaultChange = &change
+		}
+		details[key] = detail
+	}
+	return details
+}
+
+// valueStates returns the state of every value, or nil if none of them is
+// empty or NULL: the missing values need no state
+func valueStates(values []interface{}) []string {
+	states := make([]string, len(values))
+	needed := false
+	for i, value := range values {
+		states[i] = valueState(value)
+		needed = needed || states[i] == "empty" || states[i] == "null"
+	}
+	if !needed {
+		return nil
+	}
+	return states
+}
+
+// identicalDetails adds the identical variables to the details, with the
+// equal status
+func identicalDetails(details map[string]diffDetail, identical map[string][]interface{}) {
+	for key, values := range identical {
+		details[key] = diffDetail{Values: values, Status: "equal", States: valueStates(values)}
+	}
+}
+
+type jsonOutput struct {
+	pretty         bool
+	verbose        bool
+	configs        []configReader           // Used to add details in verbose mode
+	defaultChanges map[string]defaultChange // Added to the details in verbose mode
+	identical      map[string][]interface{} // Variables with the same value, with --report-identical
+	showDelta      bool                     // Add the delta and the ratio of the numbers
+}
+
+func (o *jsonOutput) Format(diff map[string][]interface{}) (string, error) {
+	var output []byte
+	var err error
+
+	// Every variable has the same shape whatever its values: the values and
+	// the status, plus the states when some value is empty or NULL. The
+	// verbose mode adds everything else we know about it.
+	details := getDiffDetails(diff, o.configs, o.defaultChanges)
+	if !o.verbose {
+		for key, detail := range details {
+			details[key] = diffDetail{Values: detail.Values, Status: detail.Status, States: detail.States}
+		}
+	}
+	identicalDetails(details, o.identical)
+	if o.showDelta {
+		deltaDetails(details)
+	}
+
+	if o.pretty {
+		output, err = json.MarshalIndent(details, "", "\t")
+	} else {
+		output, err = json.Marshal(details)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+type plainOutput struct {
+	verbose        bool
+	missingText    *string                  // Shown for missing values instead of <Missing>, if set
+	configs        []configReader           // Used to add details in verbose mode
+	defaultChanges map[string]defaultChange // Variables with a new default in the target version
+	identical      map[string][]interface{} // Variables with the same value, with --report-identical
+	showDelta      bool                     // Show the delta and the ratio of the numbers
+}
+
+// text shows the missing values with the --missing-value text, the NULL
+// values as NULL and the empty values as a pair of quotes
+func (o *plainOutput) text(value interface{}) interface{} {
+	switch valueState(value) {
+	case "missing":
+		if o.missingText != nil {
+			return *o.missingText
+		}
+	case "null":
+		return sqlNull
+	case "empty":
+		return "''"
+	}
+	return value
+}
+
+func (o *plainOutput) Format(diff map[string][]interface{}) (string, error) {
+	var buffer bytes.Buffer
+	for key, val := range diff {
+		buffer.WriteString(fmt.Sprintf("%35s: %40s : %40s\n", key, o.text(val[0]), o.text(val[1])))
+		if change, ok := o.defaultChanges[key]; ok {
+			buffer.WriteString(fmt.Sprintf("%35s  default changes from %s to %s\n", "", change.From, change.To))
+		}
+		if delta, ok := getNumericDelta(key, val); ok && o.showDelta {
+			buffer.WriteString(fmt.Sprintf("%35s  delta: %s\n", "", delta.text(key)))
+		}
+		if !o.verbose {
+			continue
+		}
+		if !isDynamic(key) {
+			buffer.WriteString(fmt.Sprintf("%35s  restart required, it cannot be changed with SET GLOBAL\n", ""))
+		}
+		if impact, severity := variableImpact(key); impact != "" {
+			buffer.WriteString(fmt.Sprintf("%35s  impact: %s (%s severity)\n", "", impact, severity))
+		}
+		for _, cfg := range o.configs {
+			if origin, ok := cfg.Origin(key); ok {
+				buffer.WriteString(fmt.Sprintf("%35s  set at %s\n", "", origin))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(o.identical))
+	for key := range o.identical {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := o.identical[key]
+		buffer.WriteString(fmt.Sprintf("%35s: %40s : %40s  (equal)\n", key, o.text(val[0]), o.text(val[1])))
+	}
+
+	return buffer.String(), nil
+}
+
+func getOutputFormatter(opts *options, cmp *comparer, configs []configReader) (outputFormatter, error) {
+	defaultChanges, err := defaultChangesByVariable(opts.DefaultChanges)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.OutputFmt {
+	case "prettyJson":
+		return &jsonOutput{pretty: true, verbose: opts.Verbose, configs: configs, defaultChanges: defaultChanges, showDelta: opts.ShowDelta}, nil
+	case "json":
+		return &jsonOutput{verbose: opts.Verbose, configs: configs, defaultChanges: defaultChanges, showDelta: opts.ShowDelta}, nil
+	case "plain":
+		output := &plainOutput{verbose: opts.Verbose, configs: configs, defaultChanges: defaultChanges, showDelta: opts.ShowDelta}
+		// --missing-value can be an empty string
+		if opts.MissingValue != "" || opts.missingValueSet {
+			output.missingText = &opts.MissingValue
+		}
+		return output, nil
+	case "patch":
+		section := cnfReadOptions{Groups: opts.Sections}.groups()[0]
+		return &patchOutput{configs: configs, comparer: cmp, section: section}, nil
+	case "sql":
+		output := &sqlOutput{persist: opts.Persist}
+		for _, cfg := range configs {
+			if cfg.Type() != "mysql" {
+				continue
+			}
+			if version, ok := cfg.Get("version"); ok {
+				output.serverVersions = append(output.serverVersions, valueString(version))
+			}
+		}
+		return output, nil
+	default:
+		return nil, errors.New("The specified output format doesn't exist")
+	}
+}
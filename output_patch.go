@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cnfEdit is a change that must be done in a cnf file so it matches the base
+// config. Old is empty for new lines and New is empty for removed lines.
+type cnfEdit struct {
+	File    string `json:"file"`
+	Section string `json:"section"`
+	Line    int    `json:"line"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+// getCNFEdits returns the edits needed in every cnf file (except the base
+// config) to get the same values the base config has. If the base config is
+// the only cnf file, like when a cnf is compared with a server, the edits are
+// the ones it needs to get the values of the second config. The values are
+// equal as the comparer sees them, and the new options are added to the
+// section. The NULL values can't be written in a cnf file, so they are left
+// out.
+func getCNFEdits(diff map[string][]interface{}, configs []configReader, cmp *comparer, section string) []cnfEdit {
+	var edits []cnfEdit
+	if len(configs) < 2 {
+		return nil
+	}
+	base, targets := configs[0], configs[1:]
+	if base.Type() == "cnf" && !hasCNF(targets) {
+		base, targets = configs[1], configs[:1]
+	}
+	if cmp == nil {
+		cmp = &comparer{}
+	}
+	cmp = cmp.withServerVersion(configs)
+	if section == "" {
+		section = "mysqld"
+	}
+
+	keys := make([]string, 0, len(diff))
+	for key := range diff {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, cfg := range targets {
+		if cfg.Type() != "cnf" {
+			continue
+		}
+
+		for _, key := range keys {
+			want, inBase := base.Get(key)
+			if inBase && isNull(want) {
+				continue
+			}
+			got, inCfg := cfg.Get(key)
+			origin, hasOrigin := cfg.Origin(key)
+
+			switch {
+			case inBase && inCfg && hasOrigin:
+				if cmp.equal(key, want, got) {
+					continue
+				}
+				edits = append(edits, cnfEdit{File: origin.File, Section: origin.Section, Line: origin.Line,
+					Old: origin.Text, New: cnfLine(key, want)})
+			case inBase && !inCfg:
+				file, line := lastLine(cfg, section)
+				if file == "" {
+					continue
+				}
+				edits = append(edits, cnfEdit{File: file, Section: section, Line: line, New: cnfLine(key, want)})
+			case !inBase && inCfg && hasOrigin:
+				edits = append(edits, cnfEdit{File: origin.File, Section: origin.Section, Line: origin.Line,
+					Old: origin.Text})
+			}
+		}
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].File != edits[j].File {
+			return edits[i].File < edits[j].File
+		}
+		return edits[i].Line < edits[j].Line
+	})
+
+	return edits
+}
+
+// hasCNF returns true if any of the configs is a cnf file
+func hasCNF(configs []configReader) bool {
+	for _, cfg := range configs {
+		if cfg.Type() == "cnf" {
+			return true
+		}
+	}
+	return false
+}
+
+// cnfValueEscaper escapes the characters the option files read as escape
+// sequences inside the quoted values
+var cnfValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// cnfLine returns the option as it should be written in a cnf file. The values
+// with spaces, comment signs, quotes or backslashes are quoted, so they are
+// read back as they are.
+func cnfLine(key string, value interface{}) string {
+	if value == "true" {
+		return key
+	}
+	str := valueString(value)
+	// An empty value, not a line to complete
+	if str == "" {
+		return fmt.Sprintf(`%s = ""`, key)
+	}
+	if strings.ContainsAny(str, " \t#\"'\\") {
+		return fmt.Sprintf(`%s = "%s"`, key, cnfValueEscaper.Replace(str))
+	}
+	return fmt.Sprintf("%s = %s", key, str)
+}
+
+// lastLine returns the file and line of the last option read in a section, so
+// new options can be added after it.
+func lastLine(cfg configReader, section string) (string, int) {
+	var last entryOrigin
+	for _, key := range cfg.Keys() {
+		origin, ok := cfg.Origin(key)
+		if !ok || origin.Section != section {
+			continue
+		}
+		if last.File == "" || origin.Order > last.Order || (origin.Order == last.Order && origin.File == last.File && origin.Line > last.Line) {
+			last = origin
+		}
+	}
+	return last.File, last.Line
+}
+
+// patchOutput shows the cnf edits in a format similar to unified diffs
+type patchOutput struct {
+	configs  []configReader
+	comparer *comparer // Tells the values that need an edit
+	section  string    // Where the new options are added, the first --section
+}
+
+func (o *patchOutput) Format(diff map[string][]interface{}) (string, error) {
+	var buffer bytes.Buffer
+
+	currentFile := ""
+	for _, edit := range getCNFEdits(diff, o.configs, o.comparer, o.section) {
+		if edit.File != currentFile {
+			currentFile = edit.File
+			buffer.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", edit.File, edit.File))
+		}
+		if edit.Old == "" {
+			buffer.WriteString(fmt.Sprintf("@@ [%s] after line %d @@\n", edit.Section, edit.Line))
+		} else {
+			buffer.WriteString(fmt.Sprintf("@@ [%s] line %d @@\n-%s\n", edit.Section, edit.Line, edit.Old))
+		}
+		if edit.New != "" {
+			buffer.WriteString(fmt.Sprintf("+%s\n", edit.New))
+		}
+	}
+
+	return buffer.String(), nil
+}
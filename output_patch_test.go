@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatchOutput(t *testing.T) {
+	base := &config{
+		configType: "cnf",
+		entries: map[string]interface{}{
+			"key1": "1G",
+			"key2": "value2",
+			"key3": "true",
+		},
+	}
+
+	cnf := &config{
+		configType: "cnf",
+		entries: map[string]interface{}{
+			"key1": "512M",
+			"key2": "value2",
+			"key4": "4",
+		},
+		origins: map[string]entryOrigin{
+			"key1": {File: "my.cnf", Section: "mysqld", Line: 2, Text: "key1=512M"},
+			"key2": {File: "my.cnf", Section: "mysqld", Line: 3, Text: "key2=value2"},
+			"key4": {File: "my.cnf", Section: "mysqld", Line: 5, Text: "key4 = 4"},
+		},
+	}
+
+	want := `--- my.cnf
++++ my.cnf
+@@ [mysqld] line 2 @@
+-key1=512M
++key1 = 1G
+@@ [mysqld] after line 5 @@
++key3
+@@ [mysqld] line 5 @@
+-key4 = 4
+`
+	configs := []configReader{base, cnf}
+	got, _ := (&patchOutput{configs: configs}).Format(compare(configs))
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestPatchOutputUsesComparer(t *testing.T) {
+	base := &config{
+		configType: "cnf",
+		entries: map[string]interface{}{
+			"max_connections":  "500",
+			"sort_buffer_size": "1M",
+			"tmpdir":           "/tmp",
+		},
+	}
+
+	// z-base.cnf is read first and includes a-tuned.cnf
+	cnf := &config{
+		configType: "cnf",
+		entries: map[string]interface{}{
+			"max_connections":  "510",
+			"sort_buffer_size": "2M",
+		},
+		origins: map[string]entryOrigin{
+			"max_connections":  {File: "z-base.cnf", Section: "server", Line: 2, Text: "max_connections = 510", Order: 0},
+			"sort_buffer_size": {File: "a-tuned.cnf", Section: "server", Line: 4, Text: "sort_buffer_size = 2M", Order: 1},
+		},
+	}
+
+	cmp := &comparer{tolerance: tolerance{value: 5, percent: true}}
+	diff := map[string][]interface{}{
+		"max_connections":  {"500", "510"},
+		"sort_buffer_size": {"1M", "2M"},
+		"tmpdir":           {"/tmp", missing},
+	}
+
+	want := `--- a-tuned.cnf
++++ a-tuned.cnf
+@@ [server] line 4 @@
+-sort_buffer_size = 2M
++sort_buffer_size = 1M
+@@ [server] after line 4 @@
++tmpdir = /tmp
+`
+	configs := []configReader{base, cnf}
+	got, _ := (&patchOutput{configs: configs, comparer: cmp, section: "server"}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestPatchOutputCNFvsServer(t *testing.T) {
+	server := &config{
+		configType: "mysql",
+		entries: map[string]interface{}{
+			"max_allowed_packet": "134217728",
+			"ssl_ca":             nil,
+			"version":            "8.0.36",
+		},
+	}
+	cnf := &config{
+		configType: "cnf",
+		entries: map[string]interface{}{
+			"max_allowed_packet": "64M",
+			"ssl_ca":             "/etc/mysql/ca.pem",
+		},
+		origins: map[string]entryOrigin{
+			"max_allowed_packet": {File: "my.cnf", Section: "mysqld", Line: 3, Text: "max_allowed_packet = 64M"},
+			"ssl_ca":             {File: "my.cnf", Section: "mysqld", Line: 4, Text: "ssl_ca = /etc/mysql/ca.pem"},
+		},
+	}
+
+	// The NULL ssl_ca of the server can't be written in the cnf file
+	want := `--- my.cnf
++++ my.cnf
+@@ [mysqld] line 3 @@
+-max_allowed_packet = 64M
++max_allowed_packet = 134217728
+`
+	// The cnf file is patched whether it is the base or not
+	for _, configs := range [][]configReader{{server, cnf}, {cnf, server}} {
+		got, _ := (&patchOutput{configs: configs}).Format(compare(configs))
+		if got != want {
+			t.Errorf("%s first -- Got:\n%s\nWant:\n%s\n", configs[0].Type(), got, want)
+		}
+	}
+}
+
+func TestCNFLine(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"true", "skip_name_resolve"},
+		{"", `skip_name_resolve = ""`},
+		{"ON", "skip_name_resolve = ON"},
+		{[]byte("/var/lib/mysql"), "skip_name_resolve = /var/lib/mysql"},
+		{"SET NAMES utf8mb4", `skip_name_resolve = "SET NAMES utf8mb4"`},
+		{"#secret", `skip_name_resolve = "#secret"`},
+		{`C:\mysql\data`, `skip_name_resolve = "C:\\mysql\\data"`},
+		{`say "hi"`, `skip_name_resolve = "say \"hi\""`},
+	}
+	for _, test := range tests {
+		got := cnfLine("skip_name_resolve", test.value)
+		if got != test.want {
+			t.Errorf("%#v -- Got: %s  --  Want: %s\n", test.value, got, test.want)
+		}
+		// The quoted values are read back as they are
+		value, problems := parseOptionValue(strings.TrimPrefix(got, "skip_name_resolve = "))
+		if test.value != "true" && test.value != "" && (value != valueString(test.value) || len(problems) > 0) {
+			t.Errorf("%#v is read back as %#v %v", test.value, value, problems)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sqlOutput generates the statements needed to make the compared servers
+// match the values of the base config (the first one).
+type sqlOutput struct {
+	persist        bool     // Prefer SET PERSIST/PERSIST_ONLY on 8.0+ servers
+	serverVersions []string // Versions of the servers being fixed
+}
+
+func (o *sqlOutput) Format(diff map[string][]interface{}) (string, error) {
+	var buffer bytes.Buffer
+
+	usePersist := o.persist && o.canPersist()
+	if o.persist && !usePersist {
+		buffer.WriteString("-- SET PERSIST requires MySQL 8.0 or newer on all servers. Falling back to SET GLOBAL.\n")
+	}
+	if !usePersist {
+		buffer.WriteString("-- SET GLOBAL changes are lost on restart. Remember to update the cnf files too.\n")
+	}
+
+	keys := make([]string, 0, len(diff))
+	for key := range diff {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := canonicalName(key)
+		if !validVariableName.MatchString(name) {
+			buffer.WriteString(fmt.Sprintf("-- %q is not a valid variable name\n", key))
+			continue
+		}
+		want := diff[key][0]
+		if isMissing(want) {
+			buffer.WriteString(fmt.Sprintf("-- %s is not set in the base config\n", name))
+			continue
+		}
+		value := sqlValue(want)
+
+		set := "SET GLOBAL"
+		if usePersist {
+			set = "SET PERSIST"
+		}
+		info, known := getVariableInfo(name)
+		switch {
+		case !known:
+			// It could be an option that can only be set in the cnf files
+			buffer.WriteString(fmt.Sprintf("-- %s is unknown, verify it can be changed at runtime: %s %s = %s;\n", name, set, name, value))
+		case usePersist && info.Dynamic:
+			buffer.WriteString(fmt.Sprintf("SET PERSIST %s = %s;\n", name, value))
+		case usePersist:
+			buffer.WriteString(fmt.Sprintf("SET PERSIST_ONLY %s = %s; -- requires a restart\n", name, value))
+		case info.Dynamic:
+			buffer.WriteString(fmt.Sprintf("SET GLOBAL %s = %s;\n", name, value))
+		default:
+			buffer.WriteString(fmt.Sprintf("-- %s requires a restart. Set %s = %s in the cnf file\n", name, key, valueString(want)))
+		}
+	}
+
+	return buffer.String(), nil
+}
+
+func (o *sqlOutput) canPersist() bool {
+	if len(o.serverVersions) == 0 {
+		return false
+	}
+	for _, version := range o.serverVersions {
+		if !versionAtLeast(version, 8, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// sqlValue returns the value as a SQL literal. Sizes like 1G are expanded since
+// SET doesn't accept suffixes. The NULL values stay NULL, not 'NULL'.
+func sqlValue(value interface{}) string {
+	if isNull(value) {
+		return "NULL"
+	}
+	str := valueString(sizesNormalizer(value))
+
+	if _, err := strconv.ParseFloat(str, 64); err == nil {
+		return str
+	}
+	switch strings.ToUpper(str) {
+	case "ON", "TRUE":
+		return "ON"
+	case "OFF", "FALSE":
+		return "OFF"
+	}
+
+	str = strings.Replace(str, `\`, `\\`, -1)
+	str = strings.Replace(str, `'`, `\'`, -1)
+	return "'" + str + "'"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSQLOutput(t *testing.T) {
+	diff := map[string][]interface{}{
+		"innodb_buffer_pool_size": []interface{}{"1G", "536870912"},
+		"innodb_log_file_size":    []interface{}{"512M", "50331648"},
+		"log_output":              []interface{}{"file", "TABLE"},
+		"slow-query-log":          []interface{}{"ON", "OFF"},
+		"key4":                    []interface{}{missing, "1"},
+	}
+
+	want := `-- SET GLOBAL changes are lost on restart. Remember to update the cnf files too.
+SET GLOBAL innodb_buffer_pool_size = 1073741824;
+-- innodb_log_file_size requires a restart. Set innodb_log_file_size = 512M in the cnf file
+-- key4 is not set in the base config
+SET GLOBAL log_output = 'file';
+SET GLOBAL slow_query_log = ON;
+`
+	got, _ := (&sqlOutput{}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	want = `SET PERSIST innodb_buffer_pool_size = 1073741824;
+SET PERSIST_ONLY innodb_log_file_size = 536870912; -- requires a restart
+-- key4 is not set in the base config
+SET PERSIST log_output = 'file';
+SET PERSIST slow_query_log = ON;
+`
+	got, _ = (&sqlOutput{persist: true, serverVersions: []string{"8.0.36", "8.4.0-log"}}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	// One 5.7 server is enough to fall back to SET GLOBAL
+	got, _ = (&sqlOutput{persist: true, serverVersions: []string{"8.0.36", "5.7.44-log"}}).Format(diff)
+	if got[:len("-- SET PERSIST requires")] != "-- SET PERSIST requires" {
+		t.Errorf("Should fall back to SET GLOBAL on 5.7 servers. Got:\n%s\n", got)
+	}
+}
+
+func TestSQLOutputUnknownVariables(t *testing.T) {
+	diff := map[string][]interface{}{
+		"innodb_page_size":  []interface{}{"16K", "8192"},
+		"my_plugin_setting": []interface{}{"2", "1"},
+		"x; DROP TABLE t":   []interface{}{"1", "2"},
+	}
+
+	// The statements of the unknown variables are only suggested
+	want := `-- SET GLOBAL changes are lost on restart. Remember to update the cnf files too.
+-- innodb_page_size requires a restart. Set innodb_page_size = 16K in the cnf file
+-- my_plugin_setting is unknown, verify it can be changed at runtime: SET GLOBAL my_plugin_setting = 2;
+-- "x; DROP TABLE t" is not a valid variable name
+`
+	got, _ := (&sqlOutput{}).Format(diff)
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
+
+func TestSQLValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"1G", "1073741824"},
+		{[]byte("64M"), "67108864"},
+		{2, "2"},
+		{true, "ON"},
+		{false, "OFF"},
+		{"it's", `'it\'s'`},
+		{"NULL", "'NULL'"},
+		{sqlNull, "NULL"},
+		{nil, "NULL"},
+	}
+	for _, test := range tests {
+		if got := sqlValue(test.value); got != test.want {
+			t.Errorf("%#v -- Got: %s  --  Want: %s\n", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// pairwiseDiff are the differences between two of the configs
+type pairwiseDiff struct {
+	First  string                   `json:"first"`
+	Second string                   `json:"second"`
+	Diffs  map[string][]interface{} `json:"diffs"`
+
+	// States of the values of the variables with empty or NULL values, see
+	// valueStates
+	States map[string][]string `json:"states,omitempty"`
+}
+
+// pairwiseResult is what --pairwise prints with the json formats. Matrix has
+// the number of different variables of every pair, in the order of Sources.
+type pairwiseResult struct {
+	Sources []string       `json:"sources"`
+	Pairs   []pairwiseDiff `json:"pairs"`
+	Matrix  [][]int        `json:"matrix"`
+}
+
+// comparePairwise compares every config with all the others, not only with
+// the first one, so it can tell if the replicas agree with each other.
+func (c *comparer) comparePairwise(configs []configReader) pairwiseResult {
+	result := pairwiseResult{Matrix: make([][]int, len(configs))}
+	c = c.withServerVersion(configs)
+
+	prepared := make([]preparedConfig, len(configs))
+	for i, cfg := range configs {
+		result.Sources = append(result.Sources, cfg.Name())
+		result.Matrix[i] = make([]int, len(configs))
+		prepared[i] = c.prepareConfig(cfg)
+	}
+
+	for i := range configs {
+		for j := i + 1; j < len(configs); j++ {
+			diffs := make(map[string][]interface{})
+			c.addDiffs(diffs, prepared[i], prepared[j])
+			pair := pairwiseDiff{First: configs[i].Name(), Second: configs[j].Name(), Diffs: diffs}
+			for key, values := range diffs {
+				if states := valueStates(values); states != nil {
+					if pair.States == nil {
+						pair.States = make(map[string][]string)
+					}
+					pair.States[key] = states
+				}
+			}
+			result.Pairs = append(result.Pairs, pair)
+			result.Matrix[i][j], result.Matrix[j][i] = len(diffs), len(diffs)
+		}
+	}
+
+	return result
+}
+
+// formatPairwise prints the differences of every pair with the output format,
+// followed by the matrix with the number of differences of the pairs
+func formatPairwise(opts *options, cmp *comparer, configs []configReader, result pairwiseResult) (string, error) {
+	switch opts.OutputFmt {
+	case "json":
+		output, err := json.Marshal(result)
+		return string(output), err
+	case "prettyJson":
+		output, err := json.MarshalIndent(result, "", "\t")
+		return string(output), err
+	}
+
+	var buffer bytes.Buffer
+	pair := 0
+	for i := range configs {
+		for j := i + 1; j < len(configs); j++ {
+			diffs := result.Pairs[pair].Diffs
+			pair++
+
+			buffer.WriteString(fmt.Sprintf("# %s <-> %s\n", configs[i].Name(), configs[j].Name()))
+			if len(diffs) == 0 {
+				buffer.WriteString("No differences\n")
+				continue
+			}
+			formatter, err := getOutputFormatter(opts, cmp, []configReader{configs[i], configs[j]})
+			if err != nil {
+				return "", err
+			}
+			output, err := formatter.Format(diffs)
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(output)
+			if len(output) > 0 && output[len(output)-1] != '\n' {
+				buffer.WriteString("\n")
+			}
+		}
+	}
+
+	buffer.WriteString("\n" + formatMatrix(result))
+	return buffer.String(), nil
+}
+
+// formatMatrix prints the sources, numbered, and the number of differences
+// of every pair:
+//
+//	[1] db1:3306
+//	[2] db2:3306
+//
+//	     [1] [2]
+//	[1]    -   3
+//	[2]    3   -
+func formatMatrix(result pairwiseResult) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("# Differences between every pair\n")
+	labels := make([]string, len(result.Sources))
+	width := 0
+	for i, source := range result.Sources {
+		labels[i] = fmt.Sprintf("[%d]", i+1)
+		buffer.WriteString(labels[i] + " " + source + "\n")
+		if len(labels[i]) > width {
+			width = len(labels[i])
+		}
+	}
+	for _, row := range result.Matrix {
+		for _, count := range row {
+			if n := len(fmt.Sprint(count)); n > width {
+				width = n
+			}
+		}
+	}
+
+	buffer.WriteString("\n" + strings.Repeat(" ", width))
+	for _, label := range labels {
+		buffer.WriteString(fmt.Sprintf(" %*s", width, label))
+	}
+	buffer.WriteString("\n")
+	for i, row := range result.Matrix {
+		buffer.WriteString(fmt.Sprintf("%-*s", width, labels[i]))
+		for j, count := range row {
+			cell := fmt.Sprint(count)
+			if i == j {
+				cell = "-"
+			}
+			buffer.WriteString(fmt.Sprintf(" %*s", width, cell))
+		}
+		buffer.WriteString("\n")
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComparePairwise(t *testing.T) {
+	configs := []configReader{
+		&config{configType: "mysql", name: "db1", entries: map[string]interface{}{"max_connections": "500", "sort_buffer_size": "262144"}},
+		&config{configType: "mysql", name: "db2", entries: map[string]interface{}{"max_connections": "1000", "sort_buffer_size": "262144"}},
+		&config{configType: "mysql", name: "db3", entries: map[string]interface{}{"max_connections": "1000", "sort_buffer_size": "524288"}},
+	}
+
+	result := (&comparer{}).comparePairwise(configs)
+	wantMatrix := [][]int{{0, 1, 2}, {1, 0, 1}, {2, 1, 0}}
+	if !reflect.DeepEqual(result.Matrix, wantMatrix) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", result.Matrix, wantMatrix)
+	}
+	wantDiffs := map[string][]interface{}{"sort_buffer_size": {"262144", "524288"}}
+	if len(result.Pairs) != 3 || result.Pairs[2].First != "db2" || !reflect.DeepEqual(result.Pairs[2].Diffs, wantDiffs) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", result.Pairs, wantDiffs)
+	}
+
+	want := `# Differences between every pair
+[1] db1
+[2] db2
+[3] db3
+
+    [1] [2] [3]
+[1]   -   1   2
+[2]   1   -   1
+[3]   2   1   -
+`
+	if got := formatMatrix(result); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	output, err := formatPairwise(&options{OutputFmt: "plain"}, &comparer{}, configs, result)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if !strings.HasPrefix(output, "# db1 <-> db2\n") || !strings.Contains(output, "# db2 <-> db3\n") {
+		t.Errorf("Every pair should have a header. Got:\n%s", output)
+	}
+}
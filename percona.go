@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+)
+
+// perconaVariablePrefixes are the prefixes of the Percona Server only
+// variables: its thread pool, audit log and storage engines
+var perconaVariablePrefixes = []string{"thread_pool_", "audit_log_", "tokudb_", "rocksdb_", "userstat"}
+
+// perconaVariables are Percona Server only variables without one of the
+// prefixes
+var perconaVariables = map[string]bool{
+	"enforce_storage_engine":              true,
+	"expand_fast_index_creation":          true,
+	"extra_max_connections":               true,
+	"extra_port":                          true,
+	"innodb_corrupt_table_action":         true,
+	"innodb_empty_free_list_algorithm":    true,
+	"innodb_parallel_doublewrite_path":    true,
+	"innodb_print_lock_wait_timeout_info": true,
+	"innodb_show_locks_held":              true,
+	"innodb_show_verbose_locks":           true,
+	"log_slow_filter":                     true,
+	"log_slow_rate_limit":                 true,
+	"log_slow_rate_type":                  true,
+	"log_slow_sp_statements":              true,
+	"log_slow_verbosity":                  true,
+	"proxy_protocol_networks":             true,
+	"slow_query_log_always_write_time":    true,
+	"slow_query_log_use_global_control":   true,
+	"thread_statistics":                   true,
+}
+
+// isPerconaVariable returns true for the variables that only Percona Server
+// has
+func isPerconaVariable(name string) bool {
+	name = strings.Replace(name, "-", "_", -1)
+	if perconaVariables[name] {
+		return true
+	}
+	for _, prefix := range perconaVariablePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPerconaServer returns true for the servers whose version_comment is like
+// "Percona Server (GPL), Release 28, Revision 47601f19"
+func isPerconaServer(cfg configReader) bool {
+	if cfg.Type() != "mysql" {
+		return false
+	}
+	comment, ok := cfg.Get("version_comment")
+	return ok && strings.Contains(strings.ToLower(valueString(comment)), "percona")
+}
+
+// vendorOf returns the vendors of the variables that community MySQL doesn't
+// have, if any
+func vendorOf(name string) string {
+	var vendors []string
+	if isPerconaVariable(name) {
+		vendors = append(vendors, "Percona Server")
+	}
+	if isMariaDBVariable(name) {
+		vendors = append(vendors, "MariaDB")
+	}
+	return strings.Join(vendors, ", ")
+}
+
+// missingByVendor returns true if the variable is not in a config only
+// because it is a community MySQL server and the variable only exists in
+// Percona Server, and these are ignored
+func (c *comparer) missingByVendor(key string, cfg preparedConfig) bool {
+	return c.ignoreVendor && cfg.community && isPerconaVariable(key)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPerconaVariables(t *testing.T) {
+	percona := &config{configType: "mysql", name: "percona", entries: map[string]interface{}{
+		"version":          "8.0.36-28",
+		"version_comment":  "Percona Server (GPL), Release 28, Revision 47601f19",
+		"max_connections":  "500",
+		"thread_pool_size": "16",
+		"userstat":         "OFF",
+	}}
+	community := &config{configType: "mysql", name: "community", entries: map[string]interface{}{
+		"version":         "8.0.36",
+		"version_comment": "MySQL Community Server - GPL",
+		"max_connections": "500",
+	}}
+
+	if !isPerconaServer(percona) || isPerconaServer(community) {
+		t.Error("Only the first server is a Percona Server")
+	}
+
+	got := (&comparer{}).compare([]configReader{percona, community})
+	want := map[string][]interface{}{
+		"version":          {"8.0.36-28", "8.0.36"},
+		"version_comment":  {"Percona Server (GPL), Release 28, Revision 47601f19", "MySQL Community Server - GPL"},
+		"thread_pool_size": {"16", missing},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	got = (&comparer{ignoreVendor: true}).compare([]configReader{community, percona})
+	delete(want, "thread_pool_size")
+	want["version"] = []interface{}{"8.0.36", "8.0.36-28"}
+	want["version_comment"] = []interface{}{"MySQL Community Server - GPL", "Percona Server (GPL), Release 28, Revision 47601f19"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if got := vendorOf("userstat"); got != "Percona Server, MariaDB" {
+		t.Errorf("Got: %s  --  Want: Percona Server, MariaDB\n", got)
+	}
+}
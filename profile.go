@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// profileControl is a check of a --profile: the assertions, like the ones of
+// the --rules files, that every source must meet to pass it
+type profileControl struct {
+	ID         string
+	Title      string
+	Assertions []string
+}
+
+// cisControls are the checks of the CIS Oracle MySQL Community Server 8.0
+// Benchmark that can be verified with the variables. The ones about the
+// accounts, the privileges and the files of the host need other tools.
+var cisControls = []profileControl{
+	{ID: "local-infile", Title: "LOAD DATA LOCAL is disabled", Assertions: []string{"local_infile = OFF"}},
+	{ID: "symbolic-links", Title: "Symbolic links to the tables are disabled", Assertions: []string{"symbolic_links = OFF"}},
+	{ID: "secure-file-priv", Title: "Imports and exports are limited to a directory", Assertions: []string{`secure_file_priv != ""`}},
+	{ID: "strict-sql-mode", Title: "sql_mode has STRICT_ALL_TABLES", Assertions: []string{"sql_mode contains STRICT_ALL_TABLES"}},
+	{ID: "error-log", Title: "The error log is written to a file", Assertions: []string{`log_error != ""`, "log_error != stderr"}},
+	{ID: "error-log-verbosity", Title: "The error log has the warnings", Assertions: []string{"log_error_verbosity >= 2"}},
+	{ID: "log-raw", Title: "The passwords are rewritten in the logs", Assertions: []string{"log_raw = OFF"}},
+	{ID: "password-lifetime", Title: "The passwords expire within a year", Assertions: []string{"default_password_lifetime > 0", "default_password_lifetime <= 365"}},
+	{ID: "authentication-plugin", Title: "The default authentication plugin is not mysql_native_password", Assertions: []string{"default_authentication_plugin != mysql_native_password"}},
+	{ID: "secure-transport", Title: "The connections must use TLS", Assertions: []string{"require_secure_transport = ON"}},
+	{ID: "tls-version", Title: "Only TLSv1.2 and above are allowed", Assertions: []string{"tls_version not contains TLSv1", "tls_version not contains TLSv1.1"}},
+}
+
+// profiles are the built-in profiles of --profile
+var profiles = map[string][]profileControl{
+	"cis": cisControls,
+}
+
+// Results of the profile controls. A control is unknown if a source doesn't
+// have one of its variables and it has no documented default.
+const (
+	controlPass    = "pass"
+	controlFail    = "fail"
+	controlUnknown = "unknown"
+)
+
+// controlResult is the result of a profile control for a source. Details
+// are the assertions not met, with the values.
+type controlResult struct {
+	Source  string   `json:"source"`
+	Control string   `json:"control"`
+	Title   string   `json:"title"`
+	Status  string   `json:"status"`
+	Details []string `json:"details,omitempty"`
+}
+
+// profileControls returns the controls of a profile and its parsed assertions
+func profileControls(name string) ([]profileControl, [][]assertion, error) {
+	controls, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for profile := range profiles {
+			names = append(names, profile)
+		}
+		sort.Strings(names)
+		return nil, nil, fmt.Errorf("Invalid profile %s. Use one of: %s", name, strings.Join(names, ", "))
+	}
+
+	assertions := make([][]assertion, len(controls))
+	for i, control := range controls {
+		for _, text := range control.Assertions {
+			a, err := parseAssertion(text)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %s", control.ID, err.Error())
+			}
+			assertions[i] = append(assertions[i], a)
+		}
+	}
+	return controls, assertions, nil
+}
+
+// profileVariables returns the variables the controls check, to read them
+// from the servers
+func profileVariables(assertions [][]assertion) []string {
+	var names []string
+	for _, list := range assertions {
+		for _, a := range list {
+			names = append(names, a.Variable)
+		}
+	}
+	return names
+}
+
+// checkProfile runs the controls against every config. Like checkRules, the
+// variables not set in a cnf are checked with their documented default, of
+// 8.0 if there is no server.
+func (c *comparer) checkProfile(configs []configReader, controls []profileControl, assertions [][]assertion) []controlResult {
+	version := serverVersion(configs)
+	if version == "" {
+		version = "8.0"
+	}
+
+	results := []controlResult{}
+	for _, cfg := range configs {
+		if cfg.Type() == "documented-defaults" {
+			continue
+		}
+		prepared := c.prepareConfig(cfg)
+		for i, control := range controls {
+			result := controlResult{Source: cfg.Name(), Control: control.ID, Title: control.Title, Status: controlPass}
+			for _, a := range assertions[i] {
+				name := c.variableName(a.Variable)
+				value, isDefault, ok := c.assertedValue(prepared, name, version)
+				if !ok {
+					if result.Status == controlPass {
+						result.Status = controlUnknown
+					}
+					result.Details = append(result.Details, fmt.Sprintf("%s is not set", name))
+					continue
+				}
+				if !c.meets(name, a, value) {
+					result.Status = controlFail
+					detail := fmt.Sprintf("%s is %s", name, quoteEmpty(valueString(value.raw)))
+					if isDefault {
+						detail += " (default)"
+					}
+					result.Details = append(result.Details, detail+", "+a.String())
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func formatControlResults(format string, results []controlResult) (string, error) {
+	switch format {
+	case "json", "prettyJson":
+		var output []byte
+		var err error
+		if format == "prettyJson" {
+			output, err = json.MarshalIndent(results, "", "\t")
+		} else {
+			output, err = json.Marshal(results)
+		}
+		return string(output), err
+	case "plain":
+		var buffer bytes.Buffer
+		counts := make(map[string]int)
+		source := ""
+		for i, result := range results {
+			if result.Source != source {
+				source = result.Source
+				if i > 0 {
+					buffer.WriteString("\n")
+				}
+				buffer.WriteString(fmt.Sprintf("# %s\n", source))
+			}
+			counts[result.Status]++
+			buffer.WriteString(fmt.Sprintf("%-8s %-22s %s\n", strings.ToUpper(result.Status), result.Control, result.Title))
+			for _, detail := range result.Details {
+				buffer.WriteString(fmt.Sprintf("%31s %s\n", "", detail))
+			}
+		}
+		buffer.WriteString(fmt.Sprintf("%d passed, %d failed, %d unknown\n", counts[controlPass], counts[controlFail], counts[controlUnknown]))
+		return buffer.String(), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for --profile", format)
+	}
+}
+
+// runProfile runs the controls of the --profile against every source,
+// instead of comparing them, so it can be run against a single source
+func runProfile(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	controls, assertions, err := profileControls(opts.Profile)
+	if err != nil {
+		return "", err
+	}
+	opts.extraVariables = append(opts.extraVariables, profileVariables(assertions)...)
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return "", err
+	}
+	return formatControlResults(opts.OutputFmt, cmp.checkProfile(configs, controls, assertions))
+}
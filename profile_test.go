@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	controls, assertions, err := profileControls("cis")
+	if err != nil {
+		t.Fatalf("Shouldn't return error on the built-in profiles: %s", err.Error())
+	}
+	if _, _, err := profileControls("pci"); err == nil {
+		t.Errorf("Should return error on an unknown profile")
+	}
+
+	cmp, err := newComparer(&options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"local-infile":              "ON",
+		"secure_file_priv":          "/var/lib/mysql-files",
+		"sql_mode":                  "STRICT_ALL_TABLES,NO_ENGINE_SUBSTITUTION",
+		"log_error":                 "/var/log/mysql/error.log",
+		"default_password_lifetime": "90",
+		"require_secure_transport":  "ON",
+	}}
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"version":                       "8.0.36",
+		"local_infile":                  "OFF",
+		"symbolic_links":                "OFF",
+		"secure_file_priv":              "",
+		"sql_mode":                      "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES",
+		"log_error":                     "stderr",
+		"log_error_verbosity":           "2",
+		"log_raw":                       "OFF",
+		"default_password_lifetime":     "0",
+		"default_authentication_plugin": "mysql_native_password",
+		"require_secure_transport":      "OFF",
+		"tls_version":                   "TLSv1.1,TLSv1.2",
+	}}
+
+	want := []controlResult{
+		{Source: "my.cnf", Control: "local-infile", Title: "LOAD DATA LOCAL is disabled", Status: "fail", Details: []string{"local_infile is ON, required OFF"}},
+		{Source: "my.cnf", Control: "symbolic-links", Title: "Symbolic links to the tables are disabled", Status: "pass"},
+		{Source: "my.cnf", Control: "secure-file-priv", Title: "Imports and exports are limited to a directory", Status: "pass"},
+		{Source: "my.cnf", Control: "strict-sql-mode", Title: "sql_mode has STRICT_ALL_TABLES", Status: "pass"},
+		{Source: "my.cnf", Control: "error-log", Title: "The error log is written to a file", Status: "pass"},
+		{Source: "my.cnf", Control: "error-log-verbosity", Title: "The error log has the warnings", Status: "pass"},
+		{Source: "my.cnf", Control: "log-raw", Title: "The passwords are rewritten in the logs", Status: "pass"},
+		{Source: "my.cnf", Control: "password-lifetime", Title: "The passwords expire within a year", Status: "pass"},
+		{Source: "my.cnf", Control: "authentication-plugin", Title: "The default authentication plugin is not mysql_native_password", Status: "pass"},
+		{Source: "my.cnf", Control: "secure-transport", Title: "The connections must use TLS", Status: "pass"},
+		{Source: "my.cnf", Control: "tls-version", Title: "Only TLSv1.2 and above are allowed", Status: "pass"},
+		{Source: "db1", Control: "local-infile", Title: "LOAD DATA LOCAL is disabled", Status: "pass"},
+		{Source: "db1", Control: "symbolic-links", Title: "Symbolic links to the tables are disabled", Status: "pass"},
+		{Source: "db1", Control: "secure-file-priv", Title: "Imports and exports are limited to a directory", Status: "fail", Details: []string{"secure_file_priv is '', must not be ''"}},
+		{Source: "db1", Control: "strict-sql-mode", Title: "sql_mode has STRICT_ALL_TABLES", Status: "fail", Details: []string{"sql_mode is ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES, must contain STRICT_ALL_TABLES"}},
+		{Source: "db1", Control: "error-log", Title: "The error log is written to a file", Status: "fail", Details: []string{"log_error is stderr, must not be stderr"}},
+		{Source: "db1", Control: "error-log-verbosity", Title: "The error log has the warnings", Status: "pass"},
+		{Source: "db1", Control: "log-raw", Title: "The passwords are rewritten in the logs", Status: "pass"},
+		{Source: "db1", Control: "password-lifetime", Title: "The passwords expire within a year", Status: "fail", Details: []string{"default_password_lifetime is 0, must be above 0"}},
+		{Source: "db1", Control: "authentication-plugin", Title: "The default authentication plugin is not mysql_native_password", Status: "fail", Details: []string{"default_authentication_plugin is mysql_native_password, must not be mysql_native_password"}},
+		{Source: "db1", Control: "secure-transport", Title: "The connections must use TLS", Status: "fail", Details: []string{"require_secure_transport is OFF, required ON"}},
+		{Source: "db1", Control: "tls-version", Title: "Only TLSv1.2 and above are allowed", Status: "fail", Details: []string{"tls_version is TLSv1.1,TLSv1.2, must not contain TLSv1.1"}},
+	}
+	got := cmp.checkProfile([]configReader{cnf, server}, controls, assertions)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// driftMetrics returns the drift of the run in the Prometheus text format:
+// the number of differing variables of every source against the base config
+// (see compareSources) and in any source.
+func driftMetrics(configs []configReader, sourceDiffs []map[string][]interface{}, now time.Time) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("# HELP mysql_config_diff_variables Number of variables that differ from the base config.\n")
+	buffer.WriteString("# TYPE mysql_config_diff_variables gauge\n")
+	for i := 1; i < len(configs) && i < len(sourceDiffs); i++ {
+		buffer.WriteString(fmt.Sprintf("mysql_config_diff_variables{base=\"%s\",source=\"%s\"} %d\n",
+			escapeLabel(configs[0].Name()), escapeLabel(configs[i].Name()), len(sourceDiffs[i])))
+	}
+
+	buffer.WriteString("# HELP mysql_config_diff_total Number of variables that differ in any source.\n")
+	buffer.WriteString("# TYPE mysql_config_diff_total gauge\n")
+	buffer.WriteString(fmt.Sprintf("mysql_config_diff_total %d\n", len(mergeSourceDiffs(sourceDiffs))))
+
+	buffer.WriteString("# HELP mysql_config_diff_last_run_timestamp_seconds Time of the last comparison.\n")
+	buffer.WriteString("# TYPE mysql_config_diff_last_run_timestamp_seconds gauge\n")
+	buffer.WriteString(fmt.Sprintf("mysql_config_diff_last_run_timestamp_seconds %d\n", now.Unix()))
+
+	return buffer.String()
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// pushMetrics replaces the metrics of the job/instance group in a Prometheus
+// Pushgateway, so cron runs feed the same dashboards as long running modes.
+func pushMetrics(ctx context.Context, client *http.Client, gateway, job, instance, metrics string) error {
+	pushURL := fmt.Sprintf("%s/metrics/job/%s", strings.TrimSuffix(gateway, "/"), url.PathEscape(job))
+	if instance != "" {
+		pushURL += "/instance/" + url.PathEscape(instance)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", pushURL, strings.NewReader(metrics))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Pushgateway returned %s", resp.Status)
+	}
+
+	return nil
+}
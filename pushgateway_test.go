@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushMetrics(t *testing.T) {
+	base := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{"key1": "1", "key2": "2"}}
+	db1 := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{"key1": "1", "key2": "3"}}
+	db2 := &config{configType: "mysql", name: "db2:3306", entries: map[string]interface{}{"key1": "1", "key2": "2"}}
+	configs := []configReader{base, db1, db2}
+
+	metrics := driftMetrics(configs, (&comparer{}).compareSources(configs), time.Unix(1500000000, 0))
+	want := `# HELP mysql_config_diff_variables Number of variables that differ from the base config.
+# TYPE mysql_config_diff_variables gauge
+mysql_config_diff_variables{base="my.cnf",source="db1:3306"} 1
+mysql_config_diff_variables{base="my.cnf",source="db2:3306"} 0
+# HELP mysql_config_diff_total Number of variables that differ in any source.
+# TYPE mysql_config_diff_total gauge
+mysql_config_diff_total 1
+# HELP mysql_config_diff_last_run_timestamp_seconds Time of the last comparison.
+# TYPE mysql_config_diff_last_run_timestamp_seconds gauge
+mysql_config_diff_last_run_timestamp_seconds 1500000000
+`
+	if metrics != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", metrics, want)
+	}
+
+	var gotPath, gotMethod, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotPath, gotMethod, gotBody = r.URL.Path, r.Method, string(body)
+	}))
+	defer server.Close()
+
+	if err := pushMetrics(context.Background(), server.Client(), server.URL, "config-diff", "cron01", metrics); err != nil {
+		t.Errorf("Shouldn't return error pushing the metrics: %s", err.Error())
+	}
+	if gotPath != "/metrics/job/config-diff/instance/cron01" || gotMethod != "PUT" || gotBody != metrics {
+		t.Errorf("Unexpected push: %s %s\n%s", gotMethod, gotPath, gotBody)
+	}
+}
+
+func TestDriftMetricsMatchDiff(t *testing.T) {
+	// max_connections = 151 is the 8.0 default the cnf without it gets
+	base := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{"max_connections": "151", "sync_binlog": "1"}}
+	cnf := &config{configType: "cnf", name: "other.cnf", entries: map[string]interface{}{"sync_binlog": "0"}}
+	server := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{"max_connections": "151", "sync_binlog": "1", "version": "8.0.36"}}
+	configs := []configReader{base, cnf, server}
+
+	cmp := &comparer{absentAsDefault: true}
+	metrics := driftMetrics(configs, cmp.compareSources(configs), time.Unix(1500000000, 0))
+	for _, want := range []string{
+		`mysql_config_diff_variables{base="my.cnf",source="other.cnf"} 1`,
+		`mysql_config_diff_variables{base="my.cnf",source="db1:3306"} 0`,
+		fmt.Sprintf("mysql_config_diff_total %d", len(cmp.compare(configs))),
+	} {
+		if !strings.Contains(metrics, want+"\n") {
+			t.Errorf("Missing %s in:\n%s", want, metrics)
+		}
+	}
+}
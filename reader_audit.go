@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const errNoSuchTable = 1146
+
+// readAuditFilters adds the audit log filters defined in the server to the
+// entries, so they are compared like any other variable:
+//
+//	audit_filter:<name>       the filter definition (JSON, keys sorted)
+//	audit_user:<user>@<host>  the filter assigned to the account
+//
+// Oracle's audit_log plugin and the Percona audit_log_filter component use
+// the same tables with different column names. Servers without the tables
+// (no filter based auditing) have no entries. The audit_log_* variables are
+// read together with the other variables.
+func readAuditFilters(ctx context.Context, db *sql.DB, entries map[string]interface{}) error {
+	filters, err := queryAuditTable(ctx, db, "SELECT * FROM mysql.audit_log_filter")
+	if err != nil {
+		return err
+	}
+	for _, row := range filters {
+		name := rowValue(row, "name")
+		entries["audit_filter:"+name] = canonicalJSON(rowValue(row, "filter"))
+	}
+
+	users, err := queryAuditTable(ctx, db, "SELECT * FROM mysql.audit_log_user")
+	if err != nil {
+		return err
+	}
+	for _, row := range users {
+		account := rowValue(row, "user", "username") + "@" + rowValue(row, "host", "userhost")
+		entries["audit_user:"+account] = rowValue(row, "filtername")
+	}
+
+	return nil
+}
+
+func queryAuditTable(ctx context.Context, db *sql.DB, query string) ([]map[string]string, error) {
+	rows, err := queryRows(ctx, db, query)
+	if myErr, ok := err.(*mysql.MySQLError); ok && myErr.Number == errNoSuchTable {
+		return nil, nil
+	}
+	return rows, err
+}
+
+// rowValue returns the value of the first of the columns found in the row.
+// Column names are compared ignoring the case.
+func rowValue(row map[string]string, names ...string) string {
+	for _, name := range names {
+		for column, value := range row {
+			if strings.EqualFold(column, name) {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// canonicalJSON re-encodes a JSON document so the same filter written with a
+// different key order or spacing gives the same string. Invalid documents are
+// returned unchanged.
+func canonicalJSON(document string) string {
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return document
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return document
+	}
+	return string(canonical)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestReadAuditFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SHOW GLOBAL VARIABLES").WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("audit_log_policy", "ALL"))
+	mock.ExpectQuery("SELECT \\* FROM mysql.audit_log_filter").WillReturnRows(
+		sqlmock.NewRows([]string{"filter_id", "name", "filter"}).
+			AddRow("1", "log_all", `{ "filter": {"log": true} }`).
+			AddRow("2", "log_conn", `{"filter": {"class": {"name": "connection"}, "log": true}}`))
+	mock.ExpectQuery("SELECT \\* FROM mysql.audit_log_user").WillReturnRows(
+		sqlmock.NewRows([]string{"USER", "HOST", "FILTERNAME"}).
+			AddRow("%", "", "log_conn").
+			AddRow("app", "10.%", "log_all"))
+
+	cfg, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{AuditFilters: true})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	want := map[string]interface{}{
+		"audit_log_policy":      "ALL",
+		"audit_filter:log_all":  `{"filter":{"log":true}}`,
+		"audit_filter:log_conn": `{"filter":{"class":{"name":"connection"},"log":true}}`,
+		"audit_user:%@":         "log_conn",
+		"audit_user:app@10.%":   "log_all",
+	}
+	if got := cfg.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
+
+func TestReadAuditFiltersWithoutTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	noTable := &mysql.MySQLError{Number: errNoSuchTable, Message: "Table 'mysql.audit_log_filter' doesn't exist"}
+	mock.ExpectQuery("SELECT \\* FROM mysql.audit_log_filter").WillReturnError(noTable)
+	mock.ExpectQuery("SELECT \\* FROM mysql.audit_log_user").WillReturnError(noTable)
+
+	entries := map[string]interface{}{}
+	if err := readAuditFilters(context.Background(), db, entries); err != nil {
+		t.Errorf("Missing audit tables must be ignored. Got: %s", err.Error())
+	}
+	if len(entries) != 0 {
+		t.Errorf("Got:\n%#v\nWant no entries\n", entries)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// azureAPIVersion is the version of the Microsoft.DBforMySQL flexibleServers
+// API used to list the server parameters
+const azureAPIVersion = "2023-12-30"
+
+// azureParameter is a server parameter (a configuration, in the API) of a
+// flexible server
+type azureParameter struct {
+	Name       string `json:"name"`
+	Properties struct {
+		Value  *string `json:"value"`
+		Source string  `json:"source"` // system-default or user-override
+	} `json:"properties"`
+}
+
+// azureParameterList is a page of the server parameters
+type azureParameterList struct {
+	Value    []azureParameter `json:"value"`
+	NextLink string           `json:"nextLink"`
+}
+
+// newAzureClient returns an Azure Resource Manager client with the default
+// credentials chain of the Azure SDK: environment, workload identity, managed
+// identity, az cli login...
+func newAzureClient(clientOptions *arm.ClientOptions) (*arm.Client, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	return arm.NewClient("pt-mysql-config-diff", "v1.0.0", cred, clientOptions)
+}
+
+// azureSubscription returns the --azure-subscription, or the subscription of
+// the AZURE_SUBSCRIPTION_ID environment variable
+func azureSubscription(opts *options) (string, error) {
+	if opts.AzureSubscription != "" {
+		return opts.AzureSubscription, nil
+	}
+	if subscription := os.Getenv("AZURE_SUBSCRIPTION_ID"); subscription != "" {
+		return subscription, nil
+	}
+	return "", errors.New("The Azure subscription is not set. Use --azure-subscription or AZURE_SUBSCRIPTION_ID")
+}
+
+// newAzureReader reads the server parameters of an Azure Database for MySQL
+// flexible server, given as resource-group/server, with the Azure SDK. Azure
+// returns every parameter with its current value, like SHOW VARIABLES.
+func newAzureReader(ctx context.Context, server, subscription string, client *arm.Client) (configReader, error) {
+	pos := strings.Index(server, "/")
+	if pos <= 0 || pos == len(server)-1 {
+		return nil, fmt.Errorf("Invalid Azure server %s. Use resource-group/server", server)
+	}
+
+	endpoint := runtime.JoinPaths(client.Endpoint(),
+		"subscriptions", url.PathEscape(subscription),
+		"resourceGroups", url.PathEscape(server[:pos]),
+		"providers/Microsoft.DBforMySQL/flexibleServers", url.PathEscape(server[pos+1:]),
+		"configurations") + "?api-version=" + azureAPIVersion
+
+	cfg := &config{configType: "azure", name: "azure:" + server, entries: make(map[string]interface{})}
+	for endpoint != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+
+		var page azureParameterList
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, fmt.Errorf("Invalid Azure response: %s", err.Error())
+		}
+		for _, parameter := range page.Value {
+			if parameter.Properties.Value != nil {
+				cfg.entries[parameter.Name] = *parameter.Properties.Value
+			}
+		}
+		endpoint = page.NextLink
+	}
+
+	return cfg, nil
+}
+
+func getAzures(ctx context.Context, opts *options) ([]configReader, error) {
+	if len(opts.AzureServers) == 0 {
+		return nil, nil
+	}
+
+	subscription, err := azureSubscription(opts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newAzureClient(nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot create the Azure client: %s", err.Error())
+	}
+
+	var configs []configReader
+	for _, server := range opts.AzureServers {
+		cfg, err := newAzureReader(ctx, server, subscription, client)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the parameters of the Azure server %s: %s", server, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
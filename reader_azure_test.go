@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// staticAzureToken is a credential that always returns the same token
+type staticAzureToken string
+
+func (t staticAzureToken) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(t)}, nil
+}
+
+func TestReadAzure(t *testing.T) {
+	var server *httptest.Server
+	var gotPaths []string
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"value": [{"name": "init_connect", "properties": {"value": null, "source": "system-default"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"value": [
+  {"name": "max_connections", "properties": {"value": "1000", "source": "user-override", "dataType": "Integer"}},
+  {"name": "innodb_buffer_pool_size", "properties": {"value": "6442450944", "source": "system-default", "dataType": "Integer"}}
+], "nextLink": "` + server.URL + r.URL.Path + `?api-version=` + azureAPIVersion + `&page=2"}`))
+	}))
+	defer server.Close()
+
+	client, err := arm.NewClient("pt-mysql-config-diff", "v1.0.0", staticAzureToken("token1"), &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: cloud.Configuration{Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {Endpoint: server.URL, Audience: "https://management.azure.com"},
+			}},
+			Transport: server.Client(),
+		},
+		DisableRPRegistration: true,
+	})
+	if err != nil {
+		t.Fatalf("Cannot create the client: %s", err.Error())
+	}
+
+	cfg, err := newAzureReader(context.Background(), "prod-rg/mysql-prod", "sub1", client)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid response: %s", err.Error())
+	}
+
+	wantPath := "/subscriptions/sub1/resourceGroups/prod-rg/providers/Microsoft.DBforMySQL/flexibleServers/mysql-prod/configurations?api-version=" + azureAPIVersion
+	if len(gotPaths) != 2 || gotPaths[0] != wantPath {
+		t.Errorf("Got: %v  --  Want: %s and the next page\n", gotPaths, wantPath)
+	}
+
+	want := map[string]interface{}{
+		"max_connections":         "1000",
+		"innodb_buffer_pool_size": "6442450944",
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+
+	if _, err := newAzureReader(context.Background(), "mysql-prod", "sub1", client); err == nil {
+		t.Error("Should return an error without the resource group")
+	}
+}
+
+func TestAzureSubscription(t *testing.T) {
+	t.Setenv("AZURE_SUBSCRIPTION_ID", "sub2")
+	if got, _ := azureSubscription(&options{AzureSubscription: "sub1"}); got != "sub1" {
+		t.Errorf("Got: %s  --  Want: sub1\n", got)
+	}
+	if got, _ := azureSubscription(&options{}); got != "sub2" {
+		t.Errorf("Got: %s  --  Want: sub2\n", got)
+	}
+	t.Setenv("AZURE_SUBSCRIPTION_ID", "")
+	if _, err := azureSubscription(&options{}); err == nil {
+		t.Error("Should return an error without subscription")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// cloudSQLInstance is the part of gcloud sql instances describe we use
+type cloudSQLInstance struct {
+	Settings struct {
+		DatabaseFlags []struct {
+			Name  string
+			Value string
+		}
+	}
+}
+
+// parseCloudSQLInstanceName accepts the instance connection name
+// (project:region:instance) or project:instance
+func parseCloudSQLInstanceName(name string) (string, string, error) {
+	parts := strings.Split(name, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[len(parts)-1] == "" {
+		return "", "", fmt.Errorf("Invalid Cloud SQL instance %s. Use project:instance or project:region:instance", name)
+	}
+	return parts[0], parts[len(parts)-1], nil
+}
+
+// newCloudSQLFlagsReader reads the database flags of a Cloud SQL instance with
+// the gcloud cli (Cloud SQL Admin API). Flag names are MySQL variable names,
+// some of them with dashes, so they are normalized to the SHOW VARIABLES
+// names. Flags without value, like skip_show_database, are ON.
+func newCloudSQLFlagsReader(ctx context.Context, name string, runCommand commandRunner) (configReader, error) {
+	project, instance, err := parseCloudSQLInstanceName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := runCommand(ctx, "gcloud", "sql", "instances", "describe", instance, "--project", project, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	var described cloudSQLInstance
+	if err := json.Unmarshal(output, &described); err != nil {
+		return nil, fmt.Errorf("Invalid gcloud output: %s", err.Error())
+	}
+
+	cfg := &config{configType: "cloudsql", name: "cloudsql:" + name, entries: make(map[string]interface{})}
+	for _, flag := range described.Settings.DatabaseFlags {
+		value := flag.Value
+		if value == "" {
+			value = "ON"
+		}
+		cfg.entries[strings.ToLower(strings.Replace(flag.Name, "-", "_", -1))] = value
+	}
+
+	return cfg, nil
+}
+
+func getCloudSQLFlags(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, name := range opts.CloudSQLInstances {
+		cfg, err := newCloudSQLFlagsReader(ctx, name, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the Cloud SQL flags of %s: %s", name, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
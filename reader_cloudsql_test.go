@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCloudSQLFlags(t *testing.T) {
+	output := `{
+  "databaseVersion": "MYSQL_8_0_31",
+  "settings": {
+    "tier": "db-custom-4-16384",
+    "databaseFlags": [
+      {"name": "max_connections", "value": "1000"},
+      {"name": "log_bin_trust_function_creators", "value": "on"},
+      {"name": "skip_show_database"},
+      {"name": "innodb-print-all-deadlocks", "value": "on"}
+    ]
+  }
+}`
+
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		return []byte(output), nil
+	}
+
+	cfg, err := newCloudSQLFlagsReader(context.Background(), "my-project:us-central1:db1", runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid output: %s", err.Error())
+	}
+
+	wantCommand := "gcloud sql instances describe db1 --project my-project --format json"
+	if gotCommand != wantCommand {
+		t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+	}
+
+	want := map[string]interface{}{
+		"max_connections":                 "1000",
+		"log_bin_trust_function_creators": "on",
+		"skip_show_database":              "ON",
+		"innodb_print_all_deadlocks":      "on",
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+
+	if _, err := newCloudSQLFlagsReader(context.Background(), "db1", runCommand); err == nil {
+		t.Error("Should return an error without the project")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// commandLineVariablesQuery reads the variables set on the mysqld command
+// line (MySQL 8.0+), like the ones of the ExecStart of a systemd drop-in
+const commandLineVariablesQuery = "SELECT i.VARIABLE_NAME, g.VARIABLE_VALUE FROM performance_schema.variables_info i " +
+	"JOIN performance_schema.global_variables g USING (VARIABLE_NAME) WHERE i.VARIABLE_SOURCE = 'COMMAND_LINE'"
+
+// commandLineSkipped are the mysqld arguments that are not variables
+var commandLineSkipped = map[string]bool{
+	"defaults-file":         true,
+	"defaults-extra-file":   true,
+	"defaults-group-suffix": true,
+	"no-defaults":           true,
+	"print-defaults":        true,
+}
+
+// parseCommandLine returns the --options of a mysqld command line, as it is
+// in /proc/<pid>/cmdline (NUL separated) or as printed by ps. Options without
+// value are true, like in the cnf files, and the short options are ignored.
+func parseCommandLine(filename, cmdline string) *config {
+	cfg := &config{configType: "cmdline", name: filename, entries: make(map[string]interface{}), origins: make(map[string]entryOrigin)}
+
+	var args []string
+	if strings.Contains(cmdline, "\x00") {
+		args = strings.Split(cmdline, "\x00")
+	} else {
+		args = strings.Fields(cmdline)
+	}
+
+	for i, arg := range args {
+		// The first one is the mysqld binary
+		if i == 0 || !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		name, value := arg[2:], "true"
+		if pos := strings.Index(name, "="); pos >= 0 {
+			name, value = name[:pos], name[pos+1:]
+		}
+		name = optionName(name)
+		if name == "" || commandLineSkipped[strings.Replace(name, "_", "-", -1)] {
+			continue
+		}
+		cfg.entries[name] = value
+		cfg.origins[name] = entryOrigin{File: filename, Source: "COMMAND_LINE", Text: arg}
+	}
+
+	return cfg
+}
+
+// readCommandLineVariables reads the variables set on the command line of a
+// server, from performance_schema.variables_info
+func readCommandLineVariables(ctx context.Context, db *sql.DB, name string) (configReader, error) {
+	cfg := &config{configType: "cmdline", name: "cmdline:" + name, entries: make(map[string]interface{})}
+	if err := readVariables(ctx, db, commandLineVariablesQuery, cfg.entries); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func getCommandLines(ctx context.Context, opts *options, dsns []string, dbConnector func(string) (*sql.DB, error), runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, filename := range opts.MysqldCommandLines {
+		data, err := readFile(ctx, filename, remoteFileOptions(opts), runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+		}
+		configs = append(configs, parseCommandLine(filename, string(data)))
+	}
+
+	if !opts.CommandLineVariables {
+		return configs, nil
+	}
+	for _, dsn := range dsns {
+		db, err := dbConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot connect to the db %s", err.Error())
+		}
+		cfg, err := readCommandLineVariables(ctx, db, dsnName(dsn))
+		db.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the command line variables of %s: %s", dsnName(dsn), err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
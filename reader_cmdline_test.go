@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	want := map[string]interface{}{
+		"innodb_buffer_pool_size": "4G",
+		"skip-name-resolve":       "true",
+		"datadir":                 "/var/lib/mysql",
+	}
+
+	cmdlines := map[string]string{
+		"proc": "/usr/sbin/mysqld\x00--defaults-file=/etc/my.cnf\x00--innodb_buffer_pool_size=4G\x00--loose-skip-name-resolve\x00-u\x00mysql\x00--datadir=/var/lib/mysql\x00",
+		"ps":   "/usr/sbin/mysqld --defaults-file=/etc/my.cnf --innodb_buffer_pool_size=4G --loose-skip-name-resolve --datadir=/var/lib/mysql\n",
+	}
+	for format, cmdline := range cmdlines {
+		cfg := parseCommandLine("cmdline", cmdline)
+		if !reflect.DeepEqual(cfg.Entries(), want) {
+			t.Errorf("%s -- Got:\n%#v\nWant:\n%#v\n", format, cfg.Entries(), want)
+		}
+		origin, _ := cfg.Origin("skip-name-resolve")
+		if got := origin.String(); got != "COMMAND_LINE cmdline" {
+			t.Errorf("%s -- Got: %s  --  Want: COMMAND_LINE cmdline\n", format, got)
+		}
+	}
+}
+
+func TestReadCommandLineVariables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta(commandLineVariablesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).AddRow("max_connections", "500"))
+
+	cfg, err := readCommandLineVariables(context.Background(), db, "db1:3306")
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := &config{configType: "cmdline", name: "cmdline:db1:3306", entries: map[string]interface{}{"max_connections": "500"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg, want)
+	}
+}
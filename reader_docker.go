@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// defaultDockerCNF is the option file of the official mysql images. The
+// Debian based images use /etc/mysql/my.cnf.
+const defaultDockerCNF = "/etc/my.cnf"
+
+// dockerVariablesScript reads the variables with the mysql client of the
+// container, through its socket, as root with the password of the official
+// images environment (MYSQL_ROOT_PASSWORD) unless MYSQL_PWD is set.
+const dockerVariablesScript = `MYSQL_PWD="${MYSQL_PWD:-$MYSQL_ROOT_PASSWORD}" exec mysql -uroot -N -B -r -e "SHOW GLOBAL VARIABLES"`
+
+// dockerCNFName returns the docker:// file name of a --docker value like
+// container or container:/etc/mysql/my.cnf
+func dockerCNFName(value string) string {
+	container, path := value, defaultDockerCNF
+	if pos := strings.Index(value, ":"); pos >= 0 {
+		container, path = value[:pos], value[pos+1:]
+	}
+	return "docker://" + container + path
+}
+
+// newDockerMySQLReader reads the variables of the server running in a
+// container, without connecting to it from the host
+func newDockerMySQLReader(ctx context.Context, container string, runCommand commandRunner) (configReader, error) {
+	output, err := runCommand(ctx, "docker", "exec", container, "sh", "-c", dockerVariablesScript)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{configType: "mysql", name: "docker:" + container, entries: make(map[string]interface{})}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line == "" {
+			continue
+		}
+		pos := strings.Index(line, "\t")
+		if pos < 0 {
+			return nil, fmt.Errorf("Invalid mysql client output: %s", line)
+		}
+		cfg.entries[line[:pos]] = line[pos+1:]
+	}
+
+	return cfg, nil
+}
+
+func getDockerMySQLs(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	if !opts.DockerMySQL {
+		return nil, nil
+	}
+
+	var configs []configReader
+	for _, value := range opts.Docker {
+		container := strings.SplitN(value, ":", 2)[0]
+		cfg, err := newDockerMySQLReader(ctx, container, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the variables of the container %s: %s", container, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
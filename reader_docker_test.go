@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDockerCNF(t *testing.T) {
+	tests := map[string]string{
+		"db1":                   "docker://db1/etc/my.cnf",
+		"db1:/etc/mysql/my.cnf": "docker://db1/etc/mysql/my.cnf",
+	}
+	for value, want := range tests {
+		if got := dockerCNFName(value); got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+
+	files := map[string]string{
+		"exec db1 cat /etc/my.cnf":                  "[mysqld]\nport = 3306\n!includedir /etc/mysql/conf.d/\n",
+		"exec db1 ls -1p -- /etc/mysql/conf.d/":     "docker.cnf\n",
+		"exec db1 cat /etc/mysql/conf.d/docker.cnf": "[mysqld]\nskip-name-resolve\n",
+	}
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(files[strings.Join(args, " ")]), nil
+	}
+
+	cnf, err := newCNFReader(context.Background(), dockerCNFName("db1"), cnfReadOptions{}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := map[string]interface{}{"port": "3306", "skip-name-resolve": "true"}
+	if !reflect.DeepEqual(cnf.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cnf.Entries(), want)
+	}
+}
+
+func TestReadDockerMySQL(t *testing.T) {
+	var gotArgs []string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte("max_connections\t151\ninit_connect\t\nversion\t8.0.36\n"), nil
+	}
+
+	configs, err := getDockerMySQLs(context.Background(), &options{Docker: []string{"db1:/etc/mysql/my.cnf"}, DockerMySQL: true}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	if want := []string{"exec", "db1", "sh", "-c", dockerVariablesScript}; !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", gotArgs, want)
+	}
+	want := map[string]interface{}{"max_connections": "151", "init_connect": "", "version": "8.0.36"}
+	if len(configs) != 1 || configs[0].Name() != "docker:db1" || !reflect.DeepEqual(configs[0].Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", configs, want)
+	}
+}
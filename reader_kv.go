@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// defaultConsulAddr is the agent address used without CONSUL_HTTP_ADDR, like
+// the consul cli
+const defaultConsulAddr = "http://127.0.0.1:8500"
+
+// consulKey is an entry of the Consul KV API. The values are base64 encoded
+// in the JSON, []byte decodes them.
+type consulKey struct {
+	Key   string
+	Value []byte
+}
+
+// kvEntries returns the settings stored in the folder of a prefix and its
+// subfolders: the last part of every key is the variable name. The keys ending
+// with / are folders. The stores return every key starting with the prefix,
+// so the ones of other folders, like mysql/production for mysql/prod, are
+// skipped. A variable set by two keys is an error.
+func kvEntries(prefix string, keys map[string]string) (map[string]interface{}, error) {
+	folder := strings.TrimSuffix(prefix, "/")
+	if folder != "" {
+		folder += "/"
+	}
+
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	entries := make(map[string]interface{})
+	seen := make(map[string]string) // Key of every variable
+	for _, key := range names {
+		if !strings.HasPrefix(key, folder) || strings.HasSuffix(key, "/") {
+			continue
+		}
+		name := path.Base(key)
+		if other, ok := seen[variableName(name)]; ok {
+			return nil, fmt.Errorf("The keys %s and %s set the same variable %s", other, key, name)
+		}
+		seen[variableName(name)] = key
+		entries[name] = keys[key]
+	}
+	return entries, nil
+}
+
+// newConsulReader reads the settings under a consul://prefix of the Consul
+// KV store. The agent is the one of CONSUL_HTTP_ADDR, and CONSUL_HTTP_TOKEN
+// is sent if set, like the consul cli does.
+func newConsulReader(ctx context.Context, client *http.Client, addr, token, source string) (configReader, error) {
+	prefix := strings.TrimPrefix(source, "consul://")
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(addr, "/")+"/v1/kv/"+(&url.URL{Path: prefix}).EscapedPath()+"?recurse=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("There are no keys under %s", prefix)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var found []consulKey
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return nil, fmt.Errorf("Invalid Consul response: %s", err.Error())
+	}
+	keys := make(map[string]string, len(found))
+	for _, key := range found {
+		keys[key.Key] = string(key.Value)
+	}
+
+	entries, err := kvEntries(prefix, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &config{configType: "kv", name: source, entries: entries}, nil
+}
+
+// etcdKVs is the part of etcdctl get --write-out=json we use. The keys and
+// values are base64 encoded, []byte decodes them.
+type etcdKVs struct {
+	KVs []struct {
+		Key   []byte
+		Value []byte
+	}
+}
+
+// etcdTLS are the certificates to connect to etcd, see the --etcd-* flags
+type etcdTLS struct {
+	CACert string
+	Cert   string
+	Key    string
+}
+
+// etcdArgs returns the etcdctl arguments to connect to the endpoints of an
+// etcd://host:port,host:port/prefix source. Without endpoints, etcdctl uses
+// ETCDCTL_ENDPOINTS or its default. The endpoints use https if a CA is set.
+func etcdArgs(endpoints string, tls etcdTLS) []string {
+	var args []string
+	if endpoints != "" {
+		scheme := "http://"
+		if tls.CACert != "" {
+			scheme = "https://"
+		}
+		var urls []string
+		for _, endpoint := range strings.Split(endpoints, ",") {
+			if !strings.Contains(endpoint, "://") {
+				endpoint = scheme + endpoint
+			}
+			urls = append(urls, endpoint)
+		}
+		args = append(args, "--endpoints", strings.Join(urls, ","))
+	}
+	for _, flag := range [][2]string{{"--cacert", tls.CACert}, {"--cert", tls.Cert}, {"--key", tls.Key}} {
+		if flag[1] != "" {
+			args = append(args, flag[0], flag[1])
+		}
+	}
+	return args
+}
+
+// newEtcdReader reads the settings under the prefix of an
+// etcd://endpoints/prefix source with etcdctl (v3 API)
+func newEtcdReader(ctx context.Context, source string, tls etcdTLS, runCommand commandRunner) (configReader, error) {
+	rest := strings.TrimPrefix(source, "etcd://")
+	slash := strings.Index(rest, "/")
+	if slash < 0 || slash == len(rest)-1 {
+		return nil, fmt.Errorf("Invalid etcd source %s. Use etcd://host:port/prefix or etcd:///prefix", source)
+	}
+	endpoints, prefix := rest[:slash], rest[slash:]
+
+	args := append([]string{"get", "--prefix", "--write-out", "json"}, etcdArgs(endpoints, tls)...)
+	output, err := runCommand(ctx, "etcdctl", append(args, "--", prefix)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var found etcdKVs
+	if err := json.Unmarshal(output, &found); err != nil {
+		return nil, fmt.Errorf("Invalid etcdctl output: %s", err.Error())
+	}
+	if len(found.KVs) == 0 {
+		return nil, fmt.Errorf("There are no keys under %s", prefix)
+	}
+	keys := make(map[string]string, len(found.KVs))
+	for _, kv := range found.KVs {
+		keys[string(kv.Key)] = string(kv.Value)
+	}
+
+	entries, err := kvEntries(prefix, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &config{configType: "kv", name: source, entries: entries}, nil
+}
+
+// getKVs reads the --kv sources
+func getKVs(ctx context.Context, opts *options, client *http.Client, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, source := range opts.KVs {
+		var cfg configReader
+		var err error
+		switch {
+		case strings.HasPrefix(source, "consul://"):
+			addr := os.Getenv("CONSUL_HTTP_ADDR")
+			if addr == "" {
+				addr = defaultConsulAddr
+			}
+			cfg, err = newConsulReader(ctx, client, addr, os.Getenv("CONSUL_HTTP_TOKEN"), source)
+		case strings.HasPrefix(source, "etcd://"):
+			cfg, err = newEtcdReader(ctx, source, etcdTLS{CACert: opts.EtcdCACert, Cert: opts.EtcdCert, Key: opts.EtcdKey}, runCommand)
+		default:
+			err = fmt.Errorf("Unknown store. Use consul://prefix or etcd://endpoints/prefix")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", source, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConsulReader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/kv/mysql/prod" || r.URL.Query().Get("recurse") != "true" || r.Header.Get("X-Consul-Token") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]consulKey{
+			{Key: "mysql/prod/"},
+			{Key: "mysql/prod/max_connections", Value: []byte("500")},
+			{Key: "mysql/prod/innodb/innodb_buffer_pool_size", Value: []byte("4G")},
+			{Key: "mysql/production/max_connections", Value: []byte("1000")},
+		})
+	}))
+	defer server.Close()
+
+	cfg, err := newConsulReader(context.Background(), server.Client(), server.URL, "secret", "consul://mysql/prod")
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := &config{configType: "kv", name: "consul://mysql/prod", entries: map[string]interface{}{
+		"max_connections":         "500",
+		"innodb_buffer_pool_size": "4G",
+	}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg, want)
+	}
+
+	if _, err := newConsulReader(context.Background(), server.Client(), server.URL, "secret", "consul://mysql/staging"); err == nil {
+		t.Errorf("Should return an error for an empty prefix")
+	}
+}
+
+func TestEtcdReader(t *testing.T) {
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		// max_connections = 500 and innodb_buffer_pool_size = 4G, base64
+		// encoded, and the max_connections of /mysql/production, that
+		// etcdctl get --prefix returns too
+		return []byte(`{"header": {"revision": 12}, "kvs": [
+			{"key": "L215c3FsL3Byb2QvbWF4X2Nvbm5lY3Rpb25z", "value": "NTAw"},
+			{"key": "L215c3FsL3Byb2QvaW5ub2RiX2J1ZmZlcl9wb29sX3NpemU=", "value": "NEc="},
+			{"key": "L215c3FsL3Byb2R1Y3Rpb24vbWF4X2Nvbm5lY3Rpb25z", "value": "MTAwMA=="}]}`), nil
+	}
+
+	cfg, err := newEtcdReader(context.Background(), "etcd://etcd1:2379,etcd2:2379/mysql/prod", etcdTLS{CACert: "ca.pem", Cert: "client.pem", Key: "client-key.pem"}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := &config{configType: "kv", name: "etcd://etcd1:2379,etcd2:2379/mysql/prod", entries: map[string]interface{}{
+		"max_connections":         "500",
+		"innodb_buffer_pool_size": "4G",
+	}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg, want)
+	}
+	wantCommand := "etcdctl get --prefix --write-out json --endpoints https://etcd1:2379,https://etcd2:2379 " +
+		"--cacert ca.pem --cert client.pem --key client-key.pem -- /mysql/prod"
+	if gotCommand != wantCommand {
+		t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+	}
+
+	newEtcdReader(context.Background(), "etcd:///mysql/prod", etcdTLS{}, runCommand)
+	if wantCommand := "etcdctl get --prefix --write-out json -- /mysql/prod"; gotCommand != wantCommand {
+		t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+	}
+}
+
+func TestKVEntries(t *testing.T) {
+	keys := map[string]string{
+		"/mysql/prod/max_connections":        "500",
+		"/mysql/prod/innodb/innodb_log_file": "ib_logfile",
+		"/mysql/production/max_connections":  "1000",
+		"/mysql/prod-old/sync_binlog":        "0",
+	}
+	for _, prefix := range []string{"/mysql/prod", "/mysql/prod/"} {
+		got, err := kvEntries(prefix, keys)
+		want := map[string]interface{}{"max_connections": "500", "innodb_log_file": "ib_logfile"}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s -- Got:\n%#v %v\nWant:\n%#v\n", prefix, got, err, want)
+		}
+	}
+
+	// Two keys of the same variable in different folders
+	keys["/mysql/prod/connections/max-connections"] = "600"
+	if _, err := kvEntries("/mysql/prod", keys); err == nil || !strings.Contains(err.Error(), "/mysql/prod/max_connections") {
+		t.Errorf("Should return an error for the keys of the same variable. Got: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// mysqldHelpArgs print the compiled-in defaults of a mysqld binary: without
+// --no-defaults the option files of the host would be applied
+var mysqldHelpArgs = []string{"--no-defaults", "--verbose", "--help"}
+
+const (
+	mysqldHelpVariables = "Variables (--variable-name=value)"
+	mysqldNoDefault     = "(No default value)"
+)
+
+var mysqldVersionRe = regexp.MustCompile(`\sVer\s+(\S+)`)
+
+// mysqldHelp returns the output of mysqld --no-defaults --verbose --help. name
+// is a mysqld binary, local or in another host or container
+// (docker://container/usr/sbin/mysqld), or a file with the saved output.
+func mysqldHelp(ctx context.Context, name string, remote remoteOptions, runCommand commandRunner) ([]byte, error) {
+	if file, ok, err := parseHostFile(name, remote.Kubernetes); ok {
+		if err != nil {
+			return nil, err
+		}
+		return file.run(ctx, runCommand, append([]string{file.path}, mysqldHelpArgs...)...)
+	}
+	if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() && info.Mode()&0111 != 0 {
+		return runCommand(ctx, name, mysqldHelpArgs...)
+	}
+	return readFile(ctx, name, remote, runCommand)
+}
+
+// parseMysqldHelp reads the variables table at the end of the help. The
+// option names have dashes, they are returned with the SHOW VARIABLES names.
+func parseMysqldHelp(output string) (map[string]interface{}, error) {
+	lines := strings.Split(output, "\n")
+	entries := make(map[string]interface{})
+
+	start := -1
+	for i, line := range lines {
+		if match := mysqldVersionRe.FindStringSubmatch(line); match != nil && len(entries) == 0 {
+			entries["version"] = match[1]
+		}
+		if strings.HasPrefix(line, mysqldHelpVariables) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("The variables table was not found. Is it the output of mysqld --verbose --help?")
+	}
+
+	// Skip the header, up to the ---- line under it
+	i := start + 1
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], "---"); i++ {
+	}
+
+	for _, line := range lines[i+1:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			break
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0]))
+		if value == mysqldNoDefault {
+			value = ""
+		}
+		entries[strings.Replace(fields[0], "-", "_", -1)] = value
+	}
+
+	return entries, nil
+}
+
+// newMysqldDefaultsReader returns the compiled-in defaults of a mysqld binary,
+// to find the cnf settings that only repeat them
+func newMysqldDefaultsReader(ctx context.Context, name string, remote remoteOptions, runCommand commandRunner) (configReader, error) {
+	output, err := mysqldHelp(ctx, name, remote, runCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseMysqldHelp(string(output))
+	if err != nil {
+		return nil, err
+	}
+
+	return &config{configType: "mysqld-defaults", name: "mysqld-defaults:" + name, entries: entries}, nil
+}
+
+func getMysqldDefaults(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, name := range opts.MysqldDefaults {
+		cfg, err := newMysqldDefaultsReader(ctx, name, remoteFileOptions(opts), runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the compiled defaults of %s: %s", name, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const mysqldHelpOutput = `/usr/sbin/mysqld  Ver 8.0.36 for Linux on x86_64 (MySQL Community Server - GPL)
+Copyright (c) 2000, 2024, Oracle and/or its affiliates.
+
+Starts the MySQL database server.
+
+Usage: /usr/sbin/mysqld [OPTIONS]
+
+  --innodb-buffer-pool-size=#
+                      The size of the memory buffer InnoDB uses to cache data
+                      and indexes of its tables.
+
+Variables (--variable-name=value)
+and boolean options {FALSE|TRUE}                             Value (after reading options)
+------------------------------------------------------------ -------------
+innodb-buffer-pool-size                                      134217728
+init-file                                                    (No default value)
+log-bin                                                      binlog
+skip-name-resolve                                            FALSE
+sql-mode                                                     ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE
+
+To see what values a running MySQL server is using, type
+'mysqladmin variables' instead of 'mysqld --verbose --help'.
+`
+
+func TestParseMysqldHelp(t *testing.T) {
+	got, err := parseMysqldHelp(mysqldHelpOutput)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := map[string]interface{}{
+		"version":                 "8.0.36",
+		"innodb_buffer_pool_size": "134217728",
+		"init_file":               "",
+		"log_bin":                 "binlog",
+		"skip_name_resolve":       "FALSE",
+		"sql_mode":                "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := parseMysqldHelp("Usage: mysqld [OPTIONS]\n"); err == nil {
+		t.Errorf("Should return an error without the variables table")
+	}
+}
+
+func TestMysqldDefaultsReader(t *testing.T) {
+	var gotCommands []string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommands = append(gotCommands, name+" "+strings.Join(args, " "))
+		return []byte(mysqldHelpOutput), nil
+	}
+
+	binary := filepath.Join(t.TempDir(), "mysqld")
+	ioutil.WriteFile(binary, []byte("\x7fELF"), 0755)
+	saved := filepath.Join(t.TempDir(), "mysqld-help.txt")
+	ioutil.WriteFile(saved, []byte(mysqldHelpOutput), 0644)
+
+	for _, name := range []string{binary, "docker://db/usr/sbin/mysqld", saved} {
+		cfg, err := newMysqldDefaultsReader(context.Background(), name, remoteOptions{}, runCommand)
+		if err != nil {
+			t.Fatalf("%s -- Shouldn't return error: %s", name, err.Error())
+		}
+		if value, _ := cfg.Get("innodb_buffer_pool_size"); value != "134217728" {
+			t.Errorf("%s -- Got: %v  --  Want: 134217728\n", name, value)
+		}
+	}
+
+	want := []string{
+		binary + " --no-defaults --verbose --help",
+		"docker exec db /usr/sbin/mysqld --no-defaults --verbose --help",
+	}
+	if !reflect.DeepEqual(gotCommands, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", gotCommands, want)
+	}
+}
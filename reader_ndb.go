@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ndbDataNodeSection is the config.ini section of the data nodes. Every
+// [ndbd] section is a node and gets the values of [ndbd default] plus its own.
+const ndbDataNodeSection = "ndbd"
+
+// ndbReportedQuery returns the configuration the data nodes are running with.
+// It must be run on a SQL node of the cluster.
+const ndbReportedQuery = `SELECT v.node_id, p.param_name, v.config_value
+FROM ndbinfo.config_values v JOIN ndbinfo.config_params p ON v.config_param = p.param_number`
+
+// newNDBConfigReader reads a NDB Cluster config.ini file and returns one
+// config per data node, named file:node<NodeId>. Parameter names are case
+// insensitive in NDB so they are stored in lowercase.
+// [ndb_mgmd], [mysqld], [api] and the other sections are not compared.
+func newNDBConfigReader(filename string) ([]configReader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseNDBConfig(filename, file)
+}
+
+func parseNDBConfig(filename string, r io.Reader) ([]configReader, error) {
+	defaults := make(map[string]cnfOption)
+	var nodes []map[string]cnfOption
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		line := strings.TrimSpace(text)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: invalid section header: %s", filename, lineNumber, line)
+			}
+			section = strings.ToLower(strings.Join(strings.Fields(line[1:end]), " "))
+			if section == ndbDataNodeSection {
+				nodes = append(nodes, make(map[string]cnfOption))
+			}
+			continue
+		}
+
+		pos := strings.IndexAny(line, "=:")
+		if pos < 0 {
+			return nil, fmt.Errorf("%s:%d: malformed line: %s", filename, lineNumber, line)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("%s:%d: parameter found before the first section: %s", filename, lineNumber, line)
+		}
+
+		value, _ := parseOptionValue(line[pos+1:])
+		option := cnfOption{
+			Name:    strings.ToLower(strings.TrimSpace(line[:pos])),
+			Value:   value,
+			Section: section,
+			File:    filename,
+			Line:    lineNumber,
+			Text:    text,
+		}
+
+		switch section {
+		case ndbDataNodeSection + " default":
+			defaults[option.Name] = option
+		case ndbDataNodeSection:
+			nodes[len(nodes)-1][option.Name] = option
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	configs := make([]configReader, 0, len(nodes))
+	for i, node := range nodes {
+		cfg := &config{
+			configType: "ndb-config",
+			entries:    make(map[string]interface{}),
+			origins:    make(map[string]entryOrigin),
+		}
+		for _, options := range []map[string]cnfOption{defaults, node} {
+			for name, option := range options {
+				cfg.entries[name] = option.Value
+				cfg.origins[name] = entryOrigin{File: option.File, Section: option.Section, Line: option.Line, Text: option.Text}
+			}
+		}
+
+		id := fmt.Sprintf("#%d", i+1)
+		for _, key := range []string{"nodeid", "id"} {
+			if value, ok := node[key]; ok {
+				id = value.Value
+				break
+			}
+		}
+		cfg.name = fmt.Sprintf("%s:node%s", filename, id)
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
+
+// readNDBReported reads, through a SQL node, the configuration every data
+// node reports. Configs are named <sql node>:node<id> and sorted by node id.
+func readNDBReported(ctx context.Context, db *sql.DB, name string) ([]configReader, error) {
+	rows, err := db.QueryContext(ctx, ndbReportedQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make(map[int]*config)
+	for rows.Next() {
+		var id int
+		var param, value string
+		if err := rows.Scan(&id, &param, &value); err != nil {
+			return nil, err
+		}
+		node, ok := nodes[id]
+		if !ok {
+			node = &config{configType: "ndb-node", name: fmt.Sprintf("%s:node%d", name, id), entries: make(map[string]interface{})}
+			nodes[id] = node
+		}
+		node.entries[strings.ToLower(param)] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	configs := make([]configReader, 0, len(ids))
+	for _, id := range ids {
+		configs = append(configs, nodes[id])
+	}
+
+	return configs, nil
+}
+
+func getNDBConfigs(filenames []string) ([]configReader, error) {
+	var configs []configReader
+
+	for _, filename := range filenames {
+		nodes, err := newNDBConfigReader(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+		}
+		configs = append(configs, nodes...)
+	}
+
+	return configs, nil
+}
+
+// getNDBReported reads the reported configuration of the data nodes using the
+// first --dsn, that must be a SQL node of the cluster
+func getNDBReported(ctx context.Context, dsns []string, dbConnector func(string) (*sql.DB, error)) ([]configReader, error) {
+	if len(dsns) == 0 {
+		return nil, errors.New("A --dsn of a SQL node is needed to read the data nodes configuration")
+	}
+
+	db, err := dbConnector(dsns[0])
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to the db %s", err.Error())
+	}
+	defer db.Close()
+
+	configs, err := readNDBReported(ctx, db, dsnName(dsns[0]))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read the data nodes configuration: %s", err.Error())
+	}
+
+	return configs, nil
+}
+
+// runNDBDiff compares every data node of the --ndb-config files with the
+// configuration the node reports, to find nodes that were not restarted after
+// a config change or that run with a different config.ini.
+func runNDBDiff(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if len(opts.NDBConfigs) == 0 {
+		return "", errors.New("ndb-diff needs --ndb-config")
+	}
+	opts.ndbReported = true
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return "", err
+	}
+
+	reported := make(map[string]configReader)
+	for _, cfg := range configs {
+		if cfg.Type() == "ndb-node" {
+			reported[cfg.Name()[strings.LastIndex(cfg.Name(), ":")+1:]] = cfg
+		}
+	}
+
+	var buffer bytes.Buffer
+	for _, cfg := range configs {
+		if cfg.Type() != "ndb-config" {
+			continue
+		}
+		node := cfg.Name()[strings.LastIndex(cfg.Name(), ":")+1:]
+		running, ok := reported[node]
+		if !ok {
+			buffer.WriteString(fmt.Sprintf("# %s is not reported by the cluster\n", cfg.Name()))
+			continue
+		}
+
+		pair := []configReader{cfg, running}
+		formatter, err := getOutputFormatter(opts, cmp, pair)
+		if err != nil {
+			return "", err
+		}
+		output, err := formatter.Format(cmp.compare(pair))
+		if err != nil {
+			return "", err
+		}
+
+		buffer.WriteString(fmt.Sprintf("# %s -> %s\n", cfg.Name(), running.Name()))
+		buffer.WriteString(output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			buffer.WriteString("\n")
+		}
+	}
+
+	return buffer.String(), nil
+}
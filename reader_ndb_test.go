@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const ndbConfigIni = `[ndbd default]
+NoOfReplicas=2
+DataMemory=80M
+
+[ndb_mgmd]
+HostName=mgm1
+NodeId=1
+
+[ndbd]
+HostName=data1
+NodeId=2
+
+[ndbd]
+HostName=data2
+NodeId=3
+DataMemory=1G
+
+[mysqld]
+HostName=sql1
+`
+
+func TestParseNDBConfig(t *testing.T) {
+	configs, err := parseNDBConfig("config.ini", strings.NewReader(ndbConfigIni))
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+	if len(configs) != 2 {
+		t.Fatalf("There must be a config per data node. Got %d", len(configs))
+	}
+
+	want := map[string]interface{}{"noofreplicas": "2", "datamemory": "1G", "hostname": "data2", "nodeid": "3"}
+	if configs[1].Name() != "config.ini:node3" || !reflect.DeepEqual(configs[1].Entries(), want) {
+		t.Errorf("Got:\n%s %#v\nWant:\n%s %#v\n", configs[1].Name(), configs[1].Entries(), "config.ini:node3", want)
+	}
+	if origin, _ := configs[0].Origin("datamemory"); origin.Line != 3 || origin.Section != "ndbd default" {
+		t.Errorf("Got:\n%#v\nWant line 3 of [ndbd default]\n", origin)
+	}
+
+	if _, err := parseNDBConfig("config.ini", strings.NewReader("DataMemory=80M\n")); err == nil {
+		t.Error("Should return error on parameters without section")
+	}
+}
+
+func TestNDBDiff(t *testing.T) {
+	dbConnector := func(dsn string) (*sql.DB, error) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		mock.ExpectQuery("FROM ndbinfo.config_values").WillReturnRows(
+			sqlmock.NewRows([]string{"node_id", "param_name", "config_value"}).
+				AddRow(2, "NoOfReplicas", "2").
+				AddRow(2, "DataMemory", "83886080").
+				AddRow(2, "HostName", "data1").
+				AddRow(2, "NodeId", "2").
+				AddRow(2, "MaxNoOfTables", "128").
+				AddRow(3, "NoOfReplicas", "2").
+				AddRow(3, "DataMemory", "83886080").
+				AddRow(3, "HostName", "data2").
+				AddRow(3, "NodeId", "3"))
+		return db, nil
+	}
+
+	configs, err := parseNDBConfig("config.ini", strings.NewReader(ndbConfigIni))
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid file: %s", err.Error())
+	}
+
+	opts := &options{OutputFmt: "plain", NDBConfigs: []string{"config.ini"}, DSNs: []string{"u:p@tcp(sql1:3306)/"}}
+	got, err := runNDBDiff(context.Background(), opts, func(ctx context.Context) ([]configReader, error) {
+		reported, err := getNDBReported(ctx, opts.DSNs, dbConnector)
+		return append(configs, reported...), err
+	})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	want := "# config.ini:node2 -> sql1:3306:node2\n" +
+		"# config.ini:node3 -> sql1:3306:node3\n" +
+		fmt.Sprintf("%35s: %40s : %40s\n", "datamemory", "1G", "83886080")
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+}
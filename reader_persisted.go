@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// persistedVariablesQuery reads the variables set with SET PERSIST and SET
+// PERSIST_ONLY (MySQL 8.0+), the content of mysqld-auto.cnf
+const persistedVariablesQuery = "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.persisted_variables"
+
+// persistedSensitiveGroup has the encrypted values of the sensitive variables
+// in the version 2 files. They cannot be compared.
+const persistedSensitiveGroup = "mysql_sensitive_variables"
+
+// persistedValue is a variable of mysqld-auto.cnf
+type persistedValue struct {
+	Value    *string
+	Metadata struct {
+		Timestamp int64 // Microseconds since the epoch
+		User      string
+		Host      string
+	}
+}
+
+// newPersistedFileReader reads a mysqld-auto.cnf file. The version 1 files
+// have the variables in mysql_server, and the static ones in
+// mysql_server_static_options; the version 2 files (8.0.29+) group all of
+// them in mysql_server, by kind. The origins have who persisted every value
+// and when.
+func newPersistedFileReader(ctx context.Context, filename string, remote remoteOptions, runCommand commandRunner) (configReader, error) {
+	data, err := readFile(ctx, filename, remote, runCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		MySQLServer map[string]json.RawMessage `json:"mysql_server"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("Invalid mysqld-auto.cnf: %s", err.Error())
+	}
+
+	cfg := &config{configType: "persisted", name: filename, entries: make(map[string]interface{}), origins: make(map[string]entryOrigin)}
+	if err := addPersistedValues(cfg, filename, document.MySQLServer); err != nil {
+		return nil, fmt.Errorf("Invalid mysqld-auto.cnf: %s", err.Error())
+	}
+
+	return cfg, nil
+}
+
+func addPersistedValues(cfg *config, filename string, group map[string]json.RawMessage) error {
+	for name, raw := range group {
+		if name == persistedSensitiveGroup {
+			continue
+		}
+
+		var value persistedValue
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		if value.Value == nil {
+			// A group of variables
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &nested); err != nil {
+				continue
+			}
+			if err := addPersistedValues(cfg, filename, nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		cfg.entries[name] = *value.Value
+		origin := entryOrigin{File: filename, Source: "PERSISTED"}
+		if value.Metadata.User != "" {
+			origin.SetUser = value.Metadata.User + "@" + value.Metadata.Host
+		}
+		if value.Metadata.Timestamp > 0 {
+			origin.SetTime = time.Unix(0, value.Metadata.Timestamp*int64(time.Microsecond)).UTC().Format("2006-01-02 15:04:05")
+		}
+		cfg.origins[name] = origin
+	}
+
+	return nil
+}
+
+// readPersistedVariables reads performance_schema.persisted_variables of a
+// server, to compare what it will use after a restart
+func readPersistedVariables(ctx context.Context, db *sql.DB, name string) (configReader, error) {
+	cfg := &config{configType: "persisted", name: "persisted:" + name, entries: make(map[string]interface{})}
+	if err := readVariables(ctx, db, persistedVariablesQuery, cfg.entries); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func getPersistedVariables(ctx context.Context, opts *options, dsns []string, dbConnector func(string) (*sql.DB, error), runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, filename := range opts.MysqldAutoCNFs {
+		cfg, err := newPersistedFileReader(ctx, filename, remoteFileOptions(opts), runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	if !opts.PersistedVariables {
+		return configs, nil
+	}
+	for _, dsn := range dsns {
+		db, err := dbConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot connect to the db %s", err.Error())
+		}
+		cfg, err := readPersistedVariables(ctx, db, dsnName(dsn))
+		db.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the persisted variables of %s: %s", dsnName(dsn), err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestPersistedFileReader(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"v1": `{"Version": 1, "mysql_server": {
+			"max_connections": {"Value": "500", "Metadata": {"Timestamp": 1709287200000000, "User": "dba", "Host": "localhost"}},
+			"mysql_server_static_options": {"innodb_log_file_size": {"Value": "1073741824", "Metadata": {"Timestamp": 0, "User": "", "Host": ""}}}}}`,
+		"v2": `{"Version": 2, "mysql_server": {
+			"mysql_dynamic_variables": {"max_connections": {"Value": "500", "Metadata": {"Timestamp": 1709287200000000, "User": "dba", "Host": "localhost"}}},
+			"mysql_static_variables": {"innodb_log_file_size": {"Value": "1073741824", "Metadata": {"Timestamp": 0, "User": "", "Host": ""}}},
+			"mysql_sensitive_variables": {"master_key_id": 1, "mysql_sensitive_dynamic_variables": {"x": {"Value": "encrypted"}}}}}`,
+	}
+	want := map[string]interface{}{"max_connections": "500", "innodb_log_file_size": "1073741824"}
+
+	for version, content := range files {
+		filename := filepath.Join(dir, version+"-mysqld-auto.cnf")
+		ioutil.WriteFile(filename, []byte(content), 0644)
+
+		cfg, err := newPersistedFileReader(context.Background(), filename, remoteOptions{}, execCommand)
+		if err != nil {
+			t.Fatalf("%s -- Shouldn't return error: %s", version, err.Error())
+		}
+		if !reflect.DeepEqual(cfg.Entries(), want) {
+			t.Errorf("%s -- Got:\n%#v\nWant:\n%#v\n", version, cfg.Entries(), want)
+		}
+		origin, _ := cfg.Origin("max_connections")
+		if got := origin.String(); got != "PERSISTED "+filename+" by dba@localhost at 2024-03-01 10:00:00" {
+			t.Errorf("%s -- Got: %s  --  Want: PERSISTED by dba@localhost at 2024-03-01 10:00:00\n", version, got)
+		}
+	}
+}
+
+func TestReadPersistedVariables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.persisted_variables").
+		WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).AddRow("max_connections", "500"))
+
+	cfg, err := readPersistedVariables(context.Background(), db, "db1:3306")
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := &config{configType: "persisted", name: "persisted:db1:3306", entries: map[string]interface{}{"max_connections": "500"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg, want)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type rdsOptionGroups struct {
+	OptionGroupsList []struct {
+		OptionGroupName    string
+		EngineName         string
+		MajorEngineVersion string
+		Options            []struct {
+			OptionName     string
+			OptionVersion  string
+			Port           *int
+			OptionSettings []struct {
+				Name  string
+				Value string
+			}
+		}
+	}
+}
+
+// rdsParameters is the output of describe-db-parameters and
+// describe-db-cluster-parameters. The aws cli gets all the pages.
+type rdsParameters struct {
+	Parameters []struct {
+		ParameterName  string
+		ParameterValue *string
+		Source         string // engine-default, system or user
+		ApplyType      string
+	}
+}
+
+// awsArgs returns the common arguments for the aws cli
+func awsArgs(opts *options, args ...string) []string {
+	if opts.AWSRegion != "" {
+		args = append(args, "--region", opts.AWSRegion)
+	}
+	if opts.AWSProfile != "" {
+		args = append(args, "--profile", opts.AWSProfile)
+	}
+	return append(args, "--output", "json")
+}
+
+// newRDSOptionGroupReader reads the options of an RDS option group using the
+// aws cli. Every option is stored as "option:<NAME>" = ON plus one entry for
+// its version, port and every one of its settings (<NAME>.<SETTING>), so the
+// options installed on two "identical" instances can be compared.
+func newRDSOptionGroupReader(ctx context.Context, name string, opts *options, runCommand commandRunner) (configReader, error) {
+	output, err := runCommand(ctx, "aws", awsArgs(opts, "rds", "describe-option-groups", "--option-group-name", name)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups rdsOptionGroups
+	if err := json.Unmarshal(output, &groups); err != nil {
+		return nil, fmt.Errorf("Invalid aws cli output: %s", err.Error())
+	}
+	if len(groups.OptionGroupsList) == 0 {
+		return nil, fmt.Errorf("Option group %s not found", name)
+	}
+
+	cfg := &config{configType: "rds-option-group", name: "rds-option-group:" + name, entries: make(map[string]interface{})}
+
+	for _, option := range groups.OptionGroupsList[0].Options {
+		cfg.entries["option:"+option.OptionName] = "ON"
+		if option.OptionVersion != "" {
+			cfg.entries[option.OptionName+".version"] = option.OptionVersion
+		}
+		if option.Port != nil {
+			cfg.entries[option.OptionName+".port"] = fmt.Sprintf("%d", *option.Port)
+		}
+		for _, setting := range option.OptionSettings {
+			cfg.entries[option.OptionName+"."+setting.Name] = setting.Value
+		}
+	}
+
+	return cfg, nil
+}
+
+func getRDSOptionGroups(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, name := range opts.RDSOptionGroups {
+		cfg, err := newRDSOptionGroupReader(ctx, name, opts, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the RDS option group %s: %s", name, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
+
+// describeRDSParameters runs a describe-db-parameters like command
+func describeRDSParameters(ctx context.Context, opts *options, runCommand commandRunner, args ...string) (rdsParameters, error) {
+	var parameters rdsParameters
+
+	output, err := runCommand(ctx, "aws", awsArgs(opts, args...)...)
+	if err != nil {
+		return parameters, err
+	}
+	if err := json.Unmarshal(output, &parameters); err != nil {
+		return parameters, fmt.Errorf("Invalid aws cli output: %s", err.Error())
+	}
+	return parameters, nil
+}
+
+// addRDSParameters stores the parameters that have a value. Values can be
+// formulas like {DBInstanceClassMemory*3/4}, that are kept as they are since
+// they depend on the instance class. With onlyUser, only the parameters
+// changed by the user replace the stored ones.
+func addRDSParameters(entries map[string]interface{}, parameters rdsParameters, onlyUser bool) {
+	for _, parameter := range parameters.Parameters {
+		if parameter.ParameterValue == nil {
+			continue
+		}
+		if _, ok := entries[parameter.ParameterName]; ok && onlyUser && parameter.Source != "user" {
+			continue
+		}
+		entries[parameter.ParameterName] = *parameter.ParameterValue
+	}
+}
+
+// newRDSParameterGroupReader reads the parameters of a DB parameter group
+// using the aws cli. Parameters without a value (the MySQL default is used)
+// are not stored, like the variables not set in a cnf file.
+func newRDSParameterGroupReader(ctx context.Context, name string, opts *options, runCommand commandRunner) (configReader, error) {
+	parameters, err := describeRDSParameters(ctx, opts, runCommand, "rds", "describe-db-parameters", "--db-parameter-group-name", name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{configType: "rds-parameter-group", name: "rds-parameter-group:" + name, entries: make(map[string]interface{})}
+	addRDSParameters(cfg.entries, parameters, false)
+
+	return cfg, nil
+}
+
+// newAuroraReader reads the effective parameters of an Aurora instance from
+// its DB cluster parameter group and its DB (instance) parameter group, given
+// as cluster-group:instance-group. The instance group has precedence for the
+// parameters the user changed in it, its engine defaults don't override the
+// cluster values.
+func newAuroraReader(ctx context.Context, groups string, opts *options, runCommand commandRunner) (configReader, error) {
+	pos := strings.Index(groups, ":")
+	if pos <= 0 || pos == len(groups)-1 {
+		return nil, fmt.Errorf("Invalid Aurora parameter groups %s. Use cluster-group:instance-group", groups)
+	}
+	clusterGroup, instanceGroup := groups[:pos], groups[pos+1:]
+
+	cluster, err := describeRDSParameters(ctx, opts, runCommand, "rds", "describe-db-cluster-parameters", "--db-cluster-parameter-group-name", clusterGroup)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := describeRDSParameters(ctx, opts, runCommand, "rds", "describe-db-parameters", "--db-parameter-group-name", instanceGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &config{configType: "aurora", name: "aurora:" + groups, entries: make(map[string]interface{})}
+	addRDSParameters(cfg.entries, cluster, false)
+	addRDSParameters(cfg.entries, instance, true)
+
+	return cfg, nil
+}
+
+func getAuroras(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, groups := range opts.AuroraGroups {
+		cfg, err := newAuroraReader(ctx, groups, opts, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the Aurora parameter groups %s: %s", groups, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
+
+func getRDSParameterGroups(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, name := range opts.RDSParameterGroups {
+		cfg, err := newRDSParameterGroupReader(ctx, name, opts, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the RDS parameter group %s: %s", name, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
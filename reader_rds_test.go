@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRDSOptionGroup(t *testing.T) {
+	output := `{
+    "OptionGroupsList": [
+        {
+            "OptionGroupName": "audit",
+            "EngineName": "mysql",
+            "MajorEngineVersion": "8.0",
+            "Options": [
+                {
+                    "OptionName": "MARIADB_AUDIT_PLUGIN",
+                    "OptionVersion": "1.3",
+                    "OptionSettings": [
+                        {"Name": "SERVER_AUDIT_EVENTS", "Value": "CONNECT,QUERY"},
+                        {"Name": "SERVER_AUDIT_FILE_ROTATIONS", "Value": "9"}
+                    ]
+                },
+                {
+                    "OptionName": "MEMCACHED",
+                    "Port": 11211,
+                    "OptionSettings": []
+                }
+            ]
+        }
+    ]
+}`
+
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		return []byte(output), nil
+	}
+
+	cfg, err := newRDSOptionGroupReader(context.Background(), "audit", &options{AWSRegion: "us-east-1"}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid output: %s", err.Error())
+	}
+
+	wantCommand := "aws rds describe-option-groups --option-group-name audit --region us-east-1 --output json"
+	if gotCommand != wantCommand {
+		t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+	}
+
+	want := map[string]interface{}{
+		"option:MARIADB_AUDIT_PLUGIN":                      "ON",
+		"MARIADB_AUDIT_PLUGIN.version":                     "1.3",
+		"MARIADB_AUDIT_PLUGIN.SERVER_AUDIT_EVENTS":         "CONNECT,QUERY",
+		"MARIADB_AUDIT_PLUGIN.SERVER_AUDIT_FILE_ROTATIONS": "9",
+		"option:MEMCACHED":                                 "ON",
+		"MEMCACHED.port":                                   "11211",
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+}
+
+func TestReadRDSParameterGroup(t *testing.T) {
+	output := `{
+    "Parameters": [
+        {"ParameterName": "innodb_buffer_pool_size", "ParameterValue": "{DBInstanceClassMemory*3/4}", "Source": "system", "ApplyType": "static"},
+        {"ParameterName": "max_connections", "ParameterValue": "500", "Source": "user", "ApplyType": "dynamic"},
+        {"ParameterName": "sql_mode", "Source": "engine-default", "ApplyType": "dynamic"}
+    ]
+}`
+
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		return []byte(output), nil
+	}
+
+	cfg, err := newRDSParameterGroupReader(context.Background(), "prod-mysql80", &options{AWSProfile: "prod"}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid output: %s", err.Error())
+	}
+
+	wantCommand := "aws rds describe-db-parameters --db-parameter-group-name prod-mysql80 --profile prod --output json"
+	if gotCommand != wantCommand {
+		t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+	}
+
+	want := map[string]interface{}{
+		"innodb_buffer_pool_size": "{DBInstanceClassMemory*3/4}",
+		"max_connections":         "500",
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+	if cfg.Name() != "rds-parameter-group:prod-mysql80" {
+		t.Errorf("Got: %s  --  Want: rds-parameter-group:prod-mysql80\n", cfg.Name())
+	}
+}
+
+func TestReadAurora(t *testing.T) {
+	outputs := map[string]string{
+		"describe-db-cluster-parameters": `{"Parameters": [
+			{"ParameterName": "binlog_format", "ParameterValue": "ROW", "Source": "user"},
+			{"ParameterName": "innodb_print_all_deadlocks", "ParameterValue": "1", "Source": "user"},
+			{"ParameterName": "time_zone", "Source": "engine-default"}
+		]}`,
+		"describe-db-parameters": `{"Parameters": [
+			{"ParameterName": "innodb_print_all_deadlocks", "ParameterValue": "0", "Source": "engine-default"},
+			{"ParameterName": "max_connections", "ParameterValue": "{DBInstanceClassMemory/12582880}", "Source": "system"},
+			{"ParameterName": "slow_query_log", "ParameterValue": "1", "Source": "user"},
+			{"ParameterName": "binlog_format", "ParameterValue": "MIXED", "Source": "user"}
+		]}`,
+	}
+
+	var gotCommands []string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommands = append(gotCommands, name+" "+strings.Join(args, " "))
+		return []byte(outputs[args[1]]), nil
+	}
+
+	cfg, err := newAuroraReader(context.Background(), "prod-cluster:prod-instance", &options{}, runCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid output: %s", err.Error())
+	}
+
+	wantCommands := []string{
+		"aws rds describe-db-cluster-parameters --db-cluster-parameter-group-name prod-cluster --output json",
+		"aws rds describe-db-parameters --db-parameter-group-name prod-instance --output json",
+	}
+	if !reflect.DeepEqual(gotCommands, wantCommands) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", gotCommands, wantCommands)
+	}
+
+	want := map[string]interface{}{
+		"binlog_format":              "MIXED",
+		"innodb_print_all_deadlocks": "1",
+		"max_connections":            "{DBInstanceClassMemory/12582880}",
+		"slow_query_log":             "1",
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+
+	if _, err := newAuroraReader(context.Background(), "prod-cluster", &options{}, runCommand); err == nil {
+		t.Error("Should return an error without the instance group")
+	}
+}
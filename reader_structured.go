@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// newStructuredReader reads a flat JSON or YAML document of variable: value
+// pairs, like the settings rendered by configuration management before they
+// are templated into a my.cnf. Booleans are ON/OFF and null values are not
+// set.
+func newStructuredReader(ctx context.Context, filename, format string, remote remoteOptions, runCommand commandRunner) (configReader, error) {
+	data, err := readFile(ctx, filename, remote, runCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]interface{})
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, &document)
+	case "yaml":
+		err = yaml.Unmarshal(data, &document)
+	default:
+		return nil, fmt.Errorf("Unknown format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s document: %s", format, err.Error())
+	}
+
+	cfg := &config{configType: format, name: filename, entries: make(map[string]interface{})}
+	for key, value := range document {
+		if value == nil {
+			continue
+		}
+		text, err := structuredValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err.Error())
+		}
+		cfg.entries[key] = text
+	}
+
+	return cfg, nil
+}
+
+// structuredValue returns the value as it would be written in a cnf file
+func structuredValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "ON", nil
+		}
+		return "OFF", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("Only scalar values are supported, the document must be flat")
+	}
+}
+
+func getStructuredConfigs(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, format := range []string{"json", "yaml"} {
+		filenames := opts.ConfigJSON
+		if format == "yaml" {
+			filenames = opts.ConfigYAML
+		}
+		for _, filename := range filenames {
+			cfg, err := newStructuredReader(ctx, filename, format, remoteFileOptions(opts), runCommand)
+			if err != nil {
+				return nil, fmt.Errorf("Cannot read %s: %s", filename, err.Error())
+			}
+			configs = append(configs, cfg)
+		}
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStructuredReader(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"json": `{"max_connections": 500, "innodb_buffer_pool_size": "4G", "slow_query_log": true, "long_query_time": 0.5, "init_file": null}`,
+		"yaml": "max_connections: 500\ninnodb_buffer_pool_size: 4G\nslow_query_log: true\nlong_query_time: 0.5\ninit_file:\n",
+	}
+	want := map[string]interface{}{
+		"max_connections":         "500",
+		"innodb_buffer_pool_size": "4G",
+		"slow_query_log":          "ON",
+		"long_query_time":         "0.5",
+	}
+
+	for format, content := range files {
+		filename := filepath.Join(dir, "mysql."+format)
+		ioutil.WriteFile(filename, []byte(content), 0644)
+
+		cfg, err := newStructuredReader(context.Background(), filename, format, remoteOptions{}, execCommand)
+		if err != nil {
+			t.Fatalf("%s -- Shouldn't return error: %s", format, err.Error())
+		}
+		got := make(map[string]interface{})
+		for _, key := range cfg.Keys() {
+			got[key], _ = cfg.Get(key)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s -- Got:\n%#v\nWant:\n%#v\n", format, got, want)
+		}
+	}
+
+	nested := filepath.Join(dir, "nested.yaml")
+	ioutil.WriteFile(nested, []byte("mysqld:\n  max_connections: 500\n"), 0644)
+	if _, err := newStructuredReader(context.Background(), nested, "yaml", remoteOptions{}, execCommand); err == nil {
+		t.Errorf("Should return an error for nested documents")
+	}
+}
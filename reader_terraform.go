@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const terraformParameterGroup = "aws_db_parameter_group"
+
+// terraformState is the part of a Terraform (>= 0.12) state file we need
+type terraformState struct {
+	Resources []struct {
+		Type      string
+		Name      string
+		Instances []struct {
+			Attributes struct {
+				Parameter []struct {
+					Name  string
+					Value string
+				}
+			}
+		}
+	}
+}
+
+var (
+	hclResourceRe = regexp.MustCompile(`^resource\s+"([^"]+)"\s+"([^"]+)"\s*{`)
+	hclBlockRe    = regexp.MustCompile(`^parameter\s*{`)
+	hclAttrRe     = regexp.MustCompile(`^(\w+)\s*=\s*"((?:[^"\\]|\\.)*)"`)
+)
+
+// newTerraformReader reads the parameters declared in an
+// aws_db_parameter_group resource, from a .tf file or from a state file.
+// The source is file[:resource name]. The resource name can be omitted if
+// there is only one parameter group in the file.
+func newTerraformReader(source string) (configReader, error) {
+	filename, resource := source, ""
+	if pos := strings.LastIndex(source, ":"); pos > 0 {
+		filename, resource = source[:pos], source[pos+1:]
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups map[string]map[string]interface{}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		groups, err = parseTerraformState(data)
+	} else {
+		groups, err = parseTerraformHCL(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+
+	if resource == "" {
+		if len(groups) != 1 {
+			names := []string{}
+			for name := range groups {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("%s has %d parameter groups, choose one with %s:<name>. Found: %s",
+				filename, len(groups), filename, strings.Join(names, ", "))
+		}
+		for name := range groups {
+			resource = name
+		}
+	}
+
+	entries, ok := groups[resource]
+	if !ok {
+		return nil, fmt.Errorf("%s.%s not found in %s", terraformParameterGroup, resource, filename)
+	}
+
+	return &config{configType: "terraform", name: source, entries: entries}, nil
+}
+
+func parseTerraformState(data []byte) (map[string]map[string]interface{}, error) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]map[string]interface{})
+	for _, resource := range state.Resources {
+		if resource.Type != terraformParameterGroup {
+			continue
+		}
+		entries := make(map[string]interface{})
+		for _, instance := range resource.Instances {
+			for _, parameter := range instance.Attributes.Parameter {
+				entries[parameter.Name] = parameter.Value
+			}
+		}
+		groups[resource.Name] = entries
+	}
+
+	return groups, nil
+}
+
+// parseTerraformHCL is a minimal HCL reader that only understands what we need:
+// resource blocks and the name/value attributes of their parameter blocks.
+func parseTerraformHCL(data []byte) (map[string]map[string]interface{}, error) {
+	groups := make(map[string]map[string]interface{})
+
+	var entries map[string]interface{}
+	var resource, name, value string
+	depth, resourceDepth, parameterDepth := 0, -1, -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if header := hclResourceRe.FindStringSubmatch(line); header != nil && depth == 0 && header[1] == terraformParameterGroup {
+			entries = make(map[string]interface{})
+			resource = header[2]
+			resourceDepth = depth
+		} else if hclBlockRe.MatchString(line) && resourceDepth >= 0 && depth == resourceDepth+1 {
+			name, value = "", ""
+			parameterDepth = depth
+		} else if attr := hclAttrRe.FindStringSubmatch(line); attr != nil && parameterDepth >= 0 {
+			switch attr[1] {
+			case "name":
+				name = attr[2]
+			case "value":
+				value = attr[2]
+			}
+		}
+
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+
+		if parameterDepth >= 0 && depth <= parameterDepth {
+			if name != "" {
+				entries[name] = value
+			}
+			parameterDepth = -1
+		}
+		if resourceDepth >= 0 && depth <= resourceDepth {
+			groups[resource] = entries
+			resourceDepth = -1
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces")
+	}
+
+	return groups, nil
+}
+
+func getTerraforms(sources []string) ([]configReader, error) {
+	var configs []configReader
+
+	for _, source := range sources {
+		cfg, err := newTerraformReader(source)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", source, err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
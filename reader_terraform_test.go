@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadTerraform(t *testing.T) {
+	want := map[string]interface{}{
+		"character_set_server":           "utf8mb4",
+		"innodb_flush_log_at_trx_commit": "2",
+	}
+
+	cfg, err := newTerraformReader("./test/parameter_group.tf")
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid .tf file: %s", err.Error())
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+
+	if _, err := newTerraformReader("./test/terraform.tfstate"); err == nil {
+		t.Error("Should return error if the resource is ambiguous")
+	}
+
+	want["innodb_flush_log_at_trx_commit"] = "1"
+	cfg, err = newTerraformReader("./test/terraform.tfstate:default")
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid state file: %s", err.Error())
+	}
+	if !reflect.DeepEqual(cfg.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cfg.Entries(), want)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// rulesFile is the content of the --rules file:
+//
+//	rules:
+//	  server_id:
+//	    ignore: true
+//	  innodb_buffer_pool_size:
+//	    tolerance: 5%
+//	  sync_binlog:
+//	    required: 1
+//	  max_connections:
+//	    min: 100
+//	    max: 2000
+//	  innodb_flush_log_at_trx_commit:
+//	    in: [1, 2]
+//	  my_key_buffer:
+//	    alias: key_buffer_size
+//	assertions:
+//	  - gtid_mode = ON
+//	  - binlog_format in (ROW, MIXED)
+//	  - max_allowed_packet >= 64M
+type rulesFile struct {
+	Rules      map[string]variableRule `yaml:"rules"`
+	Assertions []string                `yaml:"assertions"`
+}
+
+// variableRule is how a variable is compared. Required, In, Min and Max are
+// checked in every source: the values that don't meet them are reported as
+// violations, besides the differences.
+type variableRule struct {
+	Ignore    bool     `yaml:"ignore"`    // Leave the variable out
+	Tolerance string   `yaml:"tolerance"` // Like --tolerance, for this variable
+	Required  string   `yaml:"required"`  // The only allowed value
+	In        []string `yaml:"in"`        // The allowed values
+	Min       string   `yaml:"min"`       // Minimum allowed value
+	Max       string   `yaml:"max"`       // Maximum allowed value
+	Alias     string   `yaml:"alias"`     // Compare the variable as this one
+
+	tolerance *tolerance
+}
+
+// assertion is a condition on a variable, like sync_binlog = 1, that every
+// source must meet
+type assertion struct {
+	Variable string
+	Operator string   // =, !=, <, <=, >, >=, in, not in, contains or not contains
+	Values   []string // Only in and not in have more than one
+}
+
+// assertionOperators are the operators of the assertions, the longest first
+var assertionOperators = []string{"<=", ">=", "!=", "<>", "==", "=", "<", ">"}
+
+// parseAssertion parses assertions like gtid_mode = ON, max_connections <= 2000,
+// innodb_flush_log_at_trx_commit in (1, 2) or sql_mode contains
+// STRICT_ALL_TABLES. Values can be quoted, to
+// assert empty values like secure_file_priv != "".
+func parseAssertion(text string) (assertion, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "contains") || len(fields) == 4 && strings.EqualFold(fields[1], "not") && strings.EqualFold(fields[2], "contains") {
+		a := assertion{Variable: fields[0], Operator: "contains", Values: []string{unquote(fields[len(fields)-1])}}
+		if len(fields) == 4 {
+			a.Operator = "not contains"
+		}
+		return a, nil
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "in") || len(fields) >= 4 && strings.EqualFold(fields[1], "not") && strings.EqualFold(fields[2], "in") {
+		operator, list := "in", strings.Join(fields[2:], " ")
+		if !strings.EqualFold(fields[1], "in") {
+			operator, list = "not in", strings.Join(fields[3:], " ")
+		}
+		list = strings.TrimSpace(list)
+		if len(list) < 2 || !strings.ContainsAny(list[:1], "({[") || !strings.ContainsAny(list[len(list)-1:], ")}]") {
+			return assertion{}, fmt.Errorf("Invalid assertion %s. Use variable in (value, value...)", text)
+		}
+		a := assertion{Variable: fields[0], Operator: operator}
+		for _, value := range strings.Split(list[1:len(list)-1], ",") {
+			a.Values = append(a.Values, strings.TrimSpace(value))
+		}
+		return a, nil
+	}
+
+	for _, operator := range assertionOperators {
+		pos := strings.Index(text, operator)
+		if pos <= 0 {
+			continue
+		}
+		a := assertion{Variable: strings.TrimSpace(text[:pos]), Operator: operator, Values: []string{strings.TrimSpace(text[pos+len(operator):])}}
+		switch operator {
+		case "==":
+			a.Operator = "="
+		case "<>":
+			a.Operator = "!="
+		}
+		if a.Values[0] == "" || strings.ContainsAny(a.Variable, " \t") {
+			break
+		}
+		a.Values[0] = unquote(a.Values[0])
+		if strings.ContainsAny(operator, "<>") && a.Operator != "!=" && !guessTypedValue(a.Values[0]).isNumber() {
+			return assertion{}, fmt.Errorf("Invalid assertion %s. %s needs a number", text, operator)
+		}
+		return a, nil
+	}
+	return assertion{}, fmt.Errorf("Invalid assertion %s. Use variable operator value, with =, !=, <, <=, >, >=, in, not in, contains or not contains", text)
+}
+
+// quoteEmpty shows the empty values as a pair of quotes
+func quoteEmpty(value string) string {
+	if value == "" {
+		return "''"
+	}
+	return value
+}
+
+// unquote removes the quotes of a value, so empty values can be asserted
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// String describes what the assertion requires, for the violations
+func (a assertion) String() string {
+	values := make([]string, len(a.Values))
+	for i, value := range a.Values {
+		values[i] = quoteEmpty(value)
+	}
+	a.Values = values
+
+	switch a.Operator {
+	case "=":
+		return "required " + a.Values[0]
+	case "!=":
+		return "must not be " + a.Values[0]
+	case ">=":
+		return "below the minimum " + a.Values[0]
+	case "<=":
+		return "above the maximum " + a.Values[0]
+	case ">":
+		return "must be above " + a.Values[0]
+	case "<":
+		return "must be below " + a.Values[0]
+	case "in":
+		return "must be one of " + strings.Join(a.Values, ", ")
+	case "contains":
+		return "must contain " + a.Values[0]
+	case "not contains":
+		return "must not contain " + a.Values[0]
+	default:
+		return "must not be one of " + strings.Join(a.Values, ", ")
+	}
+}
+
+// assertions returns the conditions of a variable rule
+func (r variableRule) assertions(name string) []assertion {
+	var assertions []assertion
+	if r.Required != "" {
+		assertions = append(assertions, assertion{Variable: name, Operator: "=", Values: []string{r.Required}})
+	}
+	if len(r.In) > 0 {
+		assertions = append(assertions, assertion{Variable: name, Operator: "in", Values: r.In})
+	}
+	if r.Min != "" {
+		assertions = append(assertions, assertion{Variable: name, Operator: ">=", Values: []string{r.Min}})
+	}
+	if r.Max != "" {
+		assertions = append(assertions, assertion{Variable: name, Operator: "<=", Values: []string{r.Max}})
+	}
+	return assertions
+}
+
+// ruleViolation is a value of a source that doesn't meet an assertion
+type ruleViolation struct {
+	Source   string      `json:"source"`
+	Variable string      `json:"variable"`
+	Value    interface{} `json:"value"`
+	Default  bool        `json:"default,omitempty"` // The variable is not set, Value is its default
+	Rule     string      `json:"rule"`
+}
+
+// loadRules reads a --rules file. The rules are keyed by the name variables
+// are compared by (see variableName). The assertions are the ones of the rules
+// and the assertions list.
+func loadRules(filename string) (map[string]variableRule, []assertion, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file := &rulesFile{}
+	if err := yaml.UnmarshalStrict(data, file); err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(file.Rules))
+	for name := range file.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make(map[string]variableRule, len(file.Rules))
+	var assertions []assertion
+	for _, name := range names {
+		rule := file.Rules[name]
+		if rule.Tolerance != "" {
+			t, err := parseTolerance(rule.Tolerance)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %s", name, err.Error())
+			}
+			rule.tolerance = &t
+		}
+		for _, limit := range []string{rule.Min, rule.Max} {
+			if limit != "" && !parseTypedValue(name, limit).isNumber() {
+				return nil, nil, fmt.Errorf("%s: the minimum and maximum must be numbers, got %s", name, limit)
+			}
+		}
+		if rule.Alias != "" {
+			rule.Alias = variableName(rule.Alias)
+		}
+		rules[variableName(name)] = rule
+		assertions = append(assertions, rule.assertions(name)...)
+	}
+
+	for _, text := range file.Assertions {
+		a, err := parseAssertion(text)
+		if err != nil {
+			return nil, nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return rules, assertions, nil
+}
+
+// checkRules returns the values of the configs that don't meet the
+// assertions, sorted by variable. The variables not set in a cnf are checked
+// with their documented default, for the version of the first server; the
+// ones without a known default are not checked.
+func (c *comparer) checkRules(configs []configReader) []ruleViolation {
+	version := serverVersion(configs)
+
+	var violations []ruleViolation
+	for _, cfg := range configs {
+		if cfg.Type() == "documented-defaults" {
+			continue
+		}
+		prepared := c.prepareConfig(cfg)
+		for _, a := range c.assertions {
+			name := c.variableName(a.Variable)
+			value, isDefault, ok := c.assertedValue(prepared, name, version)
+			if !ok || isDefault && !c.compared(name) {
+				continue
+			}
+			if !c.meets(name, a, value) {
+				violations = append(violations, ruleViolation{Source: cfg.Name(), Variable: name, Value: value.raw, Default: isDefault, Rule: a.String()})
+			}
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Variable < violations[j].Variable })
+	return violations
+}
+
+// assertedValue returns the value of a variable of a prepared config, or its
+// documented default for the version if the config is a cnf that doesn't set
+// it. isDefault tells it is the default. ok is false if there is neither.
+func (c *comparer) assertedValue(prepared preparedConfig, name, version string) (value preparedValue, isDefault bool, ok bool) {
+	if value, ok := prepared.get(name); ok {
+		return value, false, true
+	}
+	if reportsAllVariables(prepared.configType) {
+		return preparedValue{}, false, false
+	}
+	info, _ := getVariableInfo(name)
+	def, found := defaultForVersion(info, version)
+	if !found {
+		return preparedValue{}, false, false
+	}
+	return c.prepare(name, def), true, true
+}
+
+// meets returns true if the value of the variable meets the assertion
+func (c *comparer) meets(name string, a assertion, value preparedValue) bool {
+	switch a.Operator {
+	case "contains", "not contains":
+		member := c.prepare(name, a.Values[0]).typed.text
+		found := false
+		for _, m := range strings.Split(value.typed.text, ",") {
+			if strings.EqualFold(strings.TrimSpace(m), member) {
+				found = true
+				break
+			}
+		}
+		return found == (a.Operator == "contains")
+	case "=", "!=", "in", "not in":
+		found := false
+		for _, allowed := range a.Values {
+			if c.equalValues(name, c.prepare(name, allowed), value) {
+				found = true
+				break
+			}
+		}
+		return found == (a.Operator == "=" || a.Operator == "in")
+	}
+
+	if !value.typed.isNumber() {
+		return false
+	}
+	limit := parseTypedValue(name, a.Values[0]).number
+	switch a.Operator {
+	case "<":
+		return value.typed.number < limit
+	case "<=":
+		return value.typed.number <= limit
+	case ">":
+		return value.typed.number > limit
+	default:
+		return value.typed.number >= limit
+	}
+}
+
+// violationsReport is the section of the rule violations of the diff output
+func violationsReport(violations []ruleViolation) reportSection {
+	if violations == nil {
+		violations = []ruleViolation{}
+	}
+	section := reportSection{Key: "violations", Title: "Rule violations", Items: violations}
+	for _, v := range violations {
+		value := valueString(v.Value)
+		if v.Default {
+			value += " (default)"
+		}
+		section.Lines = append(section.Lines, fmt.Sprintf("%35s: %s in %s, %s", v.Variable, value, v.Source, v.Rule))
+	}
+	return section
+}
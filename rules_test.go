@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	rules := `rules:
+  server_id:
+    ignore: true
+  innodb_buffer_pool_size:
+    tolerance: 5%
+  sync-binlog:
+    required: 1
+  max_connections:
+    min: 100
+    max: 2000
+  my_key_buffer:
+    alias: key_buffer_size
+`
+	if err := ioutil.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmp, err := newComparer(&options{RulesFile: rulesFile})
+	if err != nil {
+		t.Fatalf("Shouldn't return error on a valid rules file: %s", err.Error())
+	}
+
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"server_id":               "1",
+		"innodb_buffer_pool_size": "1000M",
+		"sync_binlog":             "1",
+		"max_connections":         "5000",
+		"my_key_buffer":           "64M",
+	}}
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"server_id":               "2",
+		"innodb_buffer_pool_size": "1073741824",
+		"sync_binlog":             "0",
+		"max_connections":         "500",
+		"key_buffer_size":         "67108864",
+	}}
+
+	want := map[string][]interface{}{
+		"sync_binlog":     {"1", "0"},
+		"max_connections": {"5000", "500"},
+	}
+	if got := cmp.compare([]configReader{cnf, server}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	wantViolations := []ruleViolation{
+		{Source: "my.cnf", Variable: "max_connections", Value: "5000", Rule: "above the maximum 2000"},
+		{Source: "db1", Variable: "sync_binlog", Value: "0", Rule: "required 1"},
+	}
+	violations := cmp.checkRules([]configReader{cnf, server})
+	if !reflect.DeepEqual(violations, wantViolations) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", violations, wantViolations)
+	}
+
+	got, err := formatReportSections("json", `{}`, []reportSection{violationsReport(violations[:1])})
+	wantJSON := `{"differences":{},"violations":[{"source":"my.cnf","variable":"max_connections","value":"5000","rule":"above the maximum 2000"}]}`
+	if err != nil || got != wantJSON {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, wantJSON)
+	}
+
+	if err := ioutil.WriteFile(rulesFile, []byte("rules:\n  max_connections:\n    min: many\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadRules(rulesFile); err == nil {
+		t.Error("Should return error on a minimum that is not a number")
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		text string
+		want assertion
+	}{
+		{"sync_binlog = 1", assertion{Variable: "sync_binlog", Operator: "=", Values: []string{"1"}}},
+		{"gtid_mode==ON", assertion{Variable: "gtid_mode", Operator: "=", Values: []string{"ON"}}},
+		{"max_connections <= 2000", assertion{Variable: "max_connections", Operator: "<=", Values: []string{"2000"}}},
+		{"binlog_format <> STATEMENT", assertion{Variable: "binlog_format", Operator: "!=", Values: []string{"STATEMENT"}}},
+		{"innodb_flush_log_at_trx_commit in {1, 2}", assertion{Variable: "innodb_flush_log_at_trx_commit", Operator: "in", Values: []string{"1", "2"}}},
+		{"transaction_isolation NOT IN (READ-UNCOMMITTED)", assertion{Variable: "transaction_isolation", Operator: "not in", Values: []string{"READ-UNCOMMITTED"}}},
+	}
+	for _, test := range tests {
+		got, err := parseAssertion(test.text)
+		if err != nil {
+			t.Errorf("%s: shouldn't return error: %s", test.text, err.Error())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, test.want)
+		}
+	}
+	for _, invalid := range []string{"sync_binlog", "sync_binlog =", "max_connections > many", "binlog_format in ROW"} {
+		if _, err := parseAssertion(invalid); err == nil {
+			t.Errorf("Should return error on the invalid assertion %s", invalid)
+		}
+	}
+
+	cmp := &comparer{}
+	for _, text := range []string{"innodb_flush_log_at_trx_commit in (1, 2)", "gtid_mode = ON", "max_allowed_packet >= 64M"} {
+		a, _ := parseAssertion(text)
+		cmp.assertions = append(cmp.assertions, a)
+	}
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{
+		"version":                        "8.0.36",
+		"innodb_flush_log_at_trx_commit": "2",
+		"gtid_mode":                      "OFF",
+		"max_allowed_packet":             "67108864",
+	}}
+	cnf := &config{configType: "cnf", name: "my.cnf", entries: map[string]interface{}{
+		"innodb_flush_log_at_trx_commit": "0",
+		"max_allowed_packet":             "16M",
+	}}
+	want := []ruleViolation{
+		{Source: "db1", Variable: "gtid_mode", Value: "OFF", Rule: "required ON"},
+		{Source: "my.cnf", Variable: "gtid_mode", Value: "OFF", Default: true, Rule: "required ON"},
+		{Source: "my.cnf", Variable: "innodb_flush_log_at_trx_commit", Value: "0", Rule: "must be one of 1, 2"},
+		{Source: "my.cnf", Variable: "max_allowed_packet", Value: "16M", Rule: "below the minimum 64M"},
+	}
+	if got := cmp.checkRules([]configReader{server, cnf}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
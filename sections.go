@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// sectionReader is a config of a single cnf group, read with SplitGroups
+type sectionReader interface {
+	Section() string
+}
+
+// configSection returns the cnf group of a config, or "" for the configs of
+// the merged groups and the other sources
+func configSection(cfg configReader) string {
+	if s, ok := cfg.(sectionReader); ok {
+		return s.Section()
+	}
+	return ""
+}
+
+// newCNFSectionReaders reads every group of the read options of a cnf file as
+// a config of its own. Like newCNFReader, [mysqld] includes the groups of the
+// server version.
+func newCNFSectionReaders(ctx context.Context, filename string, readOpts cnfReadOptions, runCommand commandRunner) ([]configReader, error) {
+	options, err := readOptionFiles(ctx, filename, readOpts, runCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []configReader
+	for _, group := range (cnfReadOptions{Groups: readOpts.Groups}).groups() {
+		groupOpts := cnfReadOptions{Groups: []string{group}, ServerVersion: readOpts.ServerVersion}
+		cnf := mergeOptions(options, groupOpts.groups()...)
+		cnf.name = filename
+		cnf.section = group
+		configs = append(configs, cnf)
+	}
+	return configs, nil
+}
+
+// sectionConfigs returns the configs of a cnf group: the cnf files read for
+// it and, for the server groups, the sources that are not cnf files, like the
+// servers
+func sectionConfigs(configs []configReader, section string) []configReader {
+	var result []configReader
+	for _, cfg := range configs {
+		s := configSection(cfg)
+		if s == section || s == "" && serverGroups[section] {
+			result = append(result, cfg)
+		}
+	}
+	return result
+}
+
+// formatSections compares every --section on its own and adds the
+// differences of each one under its name. The JSON outputs are an object
+// keyed by section.
+func formatSections(opts *options, cmp *comparer, configs []configReader) (string, error) {
+	sections := (cnfReadOptions{Groups: opts.Sections}).groups()
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		result := make(map[string]json.RawMessage, len(sections))
+		for _, section := range sections {
+			group := sectionConfigs(configs, section)
+			output, err := formatDiffs(opts, cmp, group, cmp.compare(group))
+			if err != nil {
+				return "", err
+			}
+			result[section] = json.RawMessage(output)
+		}
+		var data []byte
+		var err error
+		if opts.OutputFmt == "prettyJson" {
+			data, err = json.MarshalIndent(result, "", "\t")
+		} else {
+			data, err = json.Marshal(result)
+		}
+		return string(data), err
+	case "plain":
+		var buffer bytes.Buffer
+		for _, section := range sections {
+			group := sectionConfigs(configs, section)
+			output, err := formatDiffs(opts, cmp, group, cmp.compare(group))
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(fmt.Sprintf("# [%s]\n", section))
+			buffer.WriteString(output)
+		}
+		return buffer.String(), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available with several --section", opts.OutputFmt)
+	}
+}
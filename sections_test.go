@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSections(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.cnf": "[mysqld]\nport = 3306\nmax_connections = 100\n[client]\nuser = root\nport = 3306\n[mysqld-8.0]\nsync_binlog = 1\n",
+		"b.cnf": "[mysqld]\nport = 3306\nmax_connections = 200\nsync_binlog = 0\n[client]\nuser = admin\nport = 3306\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readOpts := cnfReadOptions{Groups: []string{"mysqld", "[client]"}, SplitGroups: true, ServerVersion: "8.0.36"}
+	cnfs, err := getCNFs(context.Background(), []string{filepath.Join(dir, "a.cnf"), filepath.Join(dir, "b.cnf")}, readOpts, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if len(cnfs) != 4 {
+		t.Fatalf("Got %d configs  --  Want: 4 (2 files, 2 sections)\n", len(cnfs))
+	}
+	server := &config{configType: "mysql", name: "db1", entries: map[string]interface{}{"port": "3306", "max_connections": "100", "sync_binlog": "1"}}
+	configs := compactConfigs(append(cnfs, server), newInternPool())
+
+	opts := &options{Sections: []string{"mysqld", "[client]"}, OutputFmt: "json"}
+	cmp, err := newComparer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"client":{"user":{"values":["root","admin"],"status":"different"}},` +
+		`"mysqld":{"max_connections":{"values":["100","200"],"status":"different"},"sync_binlog":{"values":["1","0"],"status":"different"}}}`
+	got, err := formatSections(opts, cmp, configs)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	opts.OutputFmt = "plain"
+	got, _ = formatSections(opts, cmp, configs)
+	want = "# [mysqld]\n"
+	if len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("Got:\n%s\nWant it to start with:\n%s\n", got, want)
+	}
+}
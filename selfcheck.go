@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// serverOptionFilesQuery lists the option files that set variables of the
+// server (MySQL 8.0+), in the order they were read. The included files are
+// listed too.
+const serverOptionFilesQuery = "SELECT VARIABLE_PATH FROM performance_schema.variables_info " +
+	"WHERE VARIABLE_SOURCE IN ('GLOBAL', 'SERVER', 'EXPLICIT', 'EXTRA') AND VARIABLE_PATH != '' " +
+	"GROUP BY VARIABLE_PATH ORDER BY MIN(FIELD(VARIABLE_SOURCE, 'GLOBAL', 'SERVER', 'EXPLICIT', 'EXTRA')), VARIABLE_PATH"
+
+// dynamicVariablesQuery lists the variables changed at runtime (MySQL 8.0+)
+const dynamicVariablesQuery = "SELECT VARIABLE_NAME FROM performance_schema.variables_info WHERE VARIABLE_SOURCE = 'DYNAMIC'"
+
+// restartDefault is the value of the variables changed at runtime that are not
+// in the option files: they go back to their default on restart
+const restartDefault = "DEFAULT"
+
+// defaultOptionFiles are the files mysqld reads on Unix, used when the server
+// cannot tell which ones it read. The ones of the basedir are added.
+var defaultOptionFiles = []string{"/etc/my.cnf", "/etc/mysql/my.cnf"}
+
+// serverOptionFiles returns the option files of the server. The second value
+// is false if they are the default ones, that could not exist.
+func serverOptionFiles(ctx context.Context, db *sql.DB) ([]string, bool, error) {
+	rows, err := db.QueryContext(ctx, serverOptionFilesQuery)
+	if err == nil {
+		defer rows.Close()
+		var files []string
+		for rows.Next() {
+			var file string
+			if err := rows.Scan(&file); err != nil {
+				return nil, false, err
+			}
+			files = append(files, file)
+		}
+		return files, true, rows.Err()
+	}
+
+	var basedir string
+	if err := db.QueryRowContext(ctx, "SELECT @@basedir").Scan(&basedir); err != nil {
+		return nil, false, err
+	}
+	files := append([]string{}, defaultOptionFiles...)
+	if basedir != "" {
+		files = append(files, path.Join(basedir, "my.cnf"))
+	}
+	return files, false, nil
+}
+
+// selfCheckFileName returns the name to read a file of the server: the local
+// file for the servers of this host, else the file over ssh
+func selfCheckFileName(dsn, filename string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil || cfg.Net == "unix" {
+		return filename
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return filename
+	}
+	return "ssh://" + host + ":" + filename
+}
+
+// readSelfCheckCNF reads the option files of a server, so its running values
+// can be compared with the ones it will use after a restart: the options of
+// the files plus the persisted variables (MySQL 8.0+). The variables set at
+// runtime that none of them has are DEFAULT.
+func readSelfCheckCNF(ctx context.Context, dsn string, readOpts cnfReadOptions, dbConnector func(string) (*sql.DB, error), runCommand commandRunner) (configReader, error) {
+	db, err := dbConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to the db %s", err.Error())
+	}
+	defer db.Close()
+
+	files, found, err := serverOptionFiles(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot find the option files: %s", err.Error())
+	}
+
+	var options []cnfOption
+	read := make(map[string]bool)
+	for _, file := range files {
+		filename := selfCheckFileName(dsn, file)
+		if read[filename] {
+			continue
+		}
+		fileOptions, err := readOptionFiles(ctx, filename, readOpts, runCommand)
+		if err != nil {
+			// mysqld skips the default files that don't exist
+			if !found {
+				continue
+			}
+			return nil, fmt.Errorf("Cannot read %s: %s", file, err.Error())
+		}
+		for _, option := range fileOptions {
+			read[option.File] = true
+		}
+		options = append(options, fileOptions...)
+	}
+
+	cnf := mergeOptions(options, readOpts.groups()...)
+	cnf.name = "cnf:" + dsnName(dsn)
+
+	// Only MySQL 8.0+ has persisted variables
+	persisted := make(map[string]interface{})
+	if err := readVariables(ctx, db, persistedVariablesQuery, persisted); err == nil {
+		for key, value := range persisted {
+			cnf.entries[key] = value
+			cnf.origins[key] = entryOrigin{Source: "PERSISTED"}
+		}
+	}
+
+	if rows, err := db.QueryContext(ctx, dynamicVariablesQuery); err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			if _, ok := cnf.entries[name]; !ok {
+				if _, ok := cnf.entries[strings.Replace(name, "_", "-", -1)]; !ok {
+					cnf.entries[name] = restartDefault
+				}
+			}
+		}
+	}
+
+	return cnf, nil
+}
+
+// getSelfCheckCNFs returns the option files of every --dsn server, each with
+// the version groups of its server
+func getSelfCheckCNFs(ctx context.Context, opts *options, readOpts cnfReadOptions, mysqls []configReader, dbConnector func(string) (*sql.DB, error), runCommand commandRunner) ([]configReader, error) {
+	if !opts.SelfCheck {
+		return nil, nil
+	}
+
+	var configs []configReader
+	for i, dsn := range opts.DSNs {
+		serverOpts := readOpts
+		if i < len(mysqls) && serverOpts.ServerVersion == "" {
+			serverOpts.ServerVersion = serverVersion(mysqls[i : i+1])
+		}
+		cfg, err := readSelfCheckCNF(ctx, dsn, serverOpts, dbConnector, runCommand)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read the option files of %s: %s", dsnName(dsn), err.Error())
+		}
+		configs = append(configs, cfg)
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSelfCheckFileName(t *testing.T) {
+	tests := map[string]string{
+		"root@unix(/var/run/mysqld/mysqld.sock)/": "/etc/my.cnf",
+		"root@tcp(127.0.0.1:3306)/":               "/etc/my.cnf",
+		"root@tcp(localhost:3306)/":               "/etc/my.cnf",
+		"root@tcp(db1.example.com:3306)/":         "ssh://db1.example.com:/etc/my.cnf",
+	}
+	for dsn, want := range tests {
+		if got := selfCheckFileName(dsn, "/etc/my.cnf"); got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+}
+
+func TestReadSelfCheckCNF(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "my.cnf")
+	ioutil.WriteFile(filename, []byte("[mysqld]\nmax_connections = 500\n[mysqld-8.0]\nsql_mode = ''\n"), 0644)
+
+	dbConnector := func(dsn string) (*sql.DB, error) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(serverOptionFilesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_PATH"}).AddRow(filename))
+		mock.ExpectQuery(persistedVariablesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).AddRow("innodb_log_file_size", "1073741824"))
+		mock.ExpectQuery(dynamicVariablesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME"}).AddRow("max_connections").AddRow("long_query_time"))
+		return db, nil
+	}
+
+	cnf, err := readSelfCheckCNF(context.Background(), "root@tcp(127.0.0.1:3306)/", cnfReadOptions{ServerVersion: "8.0.36"}, dbConnector, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := map[string]interface{}{
+		"max_connections":      "500",
+		"sql_mode":             "",
+		"innodb_log_file_size": "1073741824",
+		"long_query_time":      restartDefault,
+	}
+	if cnf.Name() != "cnf:127.0.0.1:3306" || !reflect.DeepEqual(cnf.Entries(), want) {
+		t.Errorf("Got:\n%s %#v\nWant:\n%#v\n", cnf.Name(), cnf.Entries(), want)
+	}
+
+	// MySQL 5.7 doesn't know which files it read: the missing default files
+	// are skipped
+	files, found, err := serverOptionFiles(context.Background(), func() *sql.DB {
+		db, mock, _ := sqlmock.New()
+		mock.ExpectQuery(regexp.QuoteMeta(serverOptionFilesQuery)).WillReturnError(errors.New("Table 'variables_info' doesn't exist"))
+		mock.ExpectQuery("SELECT @@basedir").WillReturnRows(sqlmock.NewRows([]string{"@@basedir"}).AddRow("/usr"))
+		return db
+	}())
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if wantFiles := []string{"/etc/my.cnf", "/etc/mysql/my.cnf", "/usr/my.cnf"}; found || !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", files, wantFiles)
+	}
+}
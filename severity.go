@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// severities are the levels of --min-severity, from the least to the most
+// dangerous
+var severities = []string{"low", "medium", "high"}
+
+// defaultSeverity is the severity of the variables without impact category
+const defaultSeverity = "medium"
+
+// impactRule is an impact category and the patterns, like the ones of
+// --ignore-variables, of its variables
+type impactRule struct {
+	Category string
+	Severity string
+	Patterns string
+}
+
+// impactRules classify the variables. The first matching rule wins, so the
+// exceptions come before the prefixes, like the binlog_cache_size memory
+// before the binlog_* replication variables.
+var impactRules = []impactRule{
+	{Category: "durability", Severity: "high", Patterns: "innodb_flush_log_at_trx_commit,innodb_flush_log_at_timeout,sync_binlog,sync_relay_log,sync_relay_log_info,sync_source_info,sync_master_info,innodb_doublewrite,innodb_flush_method,innodb_checksum_algorithm,innodb_use_fdatasync,binlog_order_commits"},
+	{Category: "memory sizing", Severity: "medium", Patterns: "innodb_buffer_pool_size,innodb_buffer_pool_instances,innodb_buffer_pool_chunk_size,innodb_log_buffer_size,key_buffer_size,max_connections,max_user_connections,sort_buffer_size,join_buffer_size,read_buffer_size,read_rnd_buffer_size,tmp_table_size,max_heap_table_size,temptable_max_ram,thread_stack,thread_cache_size,binlog_cache_size,binlog_stmt_cache_size,table_open_cache,table_definition_cache,max_allowed_packet,performance_schema"},
+	{Category: "replication safety", Severity: "high", Patterns: "binlog_*,gtid_*,enforce_gtid_consistency,log_bin,log_replica_updates,server_id,read_only,super_read_only,replica_*,relay_log_recovery,rpl_semi_sync_*,skip_replica_start,replicate_*"},
+	{Category: "security", Severity: "high", Patterns: "require_secure_transport,ssl_*,tls_*,admin_ssl_*,admin_tls_*,local_infile,secure_file_priv,skip_grant_tables,default_authentication_plugin,authentication_policy,validate_password*,password_*,audit_log_*,bind_address,symbolic_links,automatic_sp_privileges,log_raw,skip_name_resolve,skip_networking"},
+	{Category: "cosmetic", Severity: "low", Patterns: "version_comment,log_timestamps,log_error_verbosity,lc_messages,report_host,report_port,hostname,server_uuid,pid_file,general_log_file,slow_query_log_file"},
+}
+
+// impactFilters are the compiled patterns of impactRules
+var impactFilters = compileImpactRules(impactRules)
+
+func compileImpactRules(rules []impactRule) []variableFilter {
+	filters := make([]variableFilter, len(rules))
+	for i, rule := range rules {
+		filter, err := newVariableFilter([]string{rule.Patterns})
+		if err != nil {
+			panic(err)
+		}
+		filters[i] = filter
+	}
+	return filters
+}
+
+// variableImpact returns the impact category and the severity of a variable.
+// The category is empty for the variables not classified.
+func variableImpact(name string) (string, string) {
+	for i, filter := range impactFilters {
+		if filter.match(name) {
+			return impactRules[i].Category, impactRules[i].Severity
+		}
+	}
+	return "", defaultSeverity
+}
+
+// severityLevel returns the position of the severity in severities, or -1
+func severityLevel(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterBySeverity returns the diffs of the variables with the minimum
+// severity or above, for --min-severity. All of them without minimum.
+func filterBySeverity(diffs map[string][]interface{}, minimum string) (map[string][]interface{}, error) {
+	if minimum == "" {
+		return diffs, nil
+	}
+	level := severityLevel(minimum)
+	if level < 0 {
+		return nil, fmt.Errorf("Invalid severity %s. Use one of: %s", minimum, strings.Join(severities, ", "))
+	}
+
+	filtered := make(map[string][]interface{}, len(diffs))
+	for key, values := range diffs {
+		if _, severity := variableImpact(key); severityLevel(severity) >= level {
+			filtered[key] = values
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		impact   string
+		severity string
+	}{
+		{"innodb_flush_log_at_trx_commit", "durability", "high"},
+		{"sync-binlog", "durability", "high"},
+		{"binlog_cache_size", "memory sizing", "medium"},
+		{"binlog_format", "replication safety", "high"},
+		{"ssl_cipher", "security", "high"},
+		{"log_timestamps", "cosmetic", "low"},
+		{"innodb_io_capacity", "", "medium"},
+	}
+	for _, test := range tests {
+		if impact, severity := variableImpact(test.name); impact != test.impact || severity != test.severity {
+			t.Errorf("%s. Got: %s, %s  --  Want: %s, %s\n", test.name, impact, severity, test.impact, test.severity)
+		}
+	}
+
+	diffs := map[string][]interface{}{
+		"sync_binlog":        {"1", "0"},
+		"innodb_io_capacity": {"200", "2000"},
+		"log_timestamps":     {"UTC", "SYSTEM"},
+	}
+	got, err := filterBySeverity(diffs, "medium")
+	if err != nil {
+		t.Fatalf("Shouldn't return error for a valid severity: %s", err.Error())
+	}
+	want := map[string][]interface{}{
+		"sync_binlog":        {"1", "0"},
+		"innodb_io_capacity": {"200", "2000"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if _, err := filterBySeverity(diffs, "critical"); err == nil {
+		t.Error("Should return error on invalid severities")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sidecarComponent      = "pt-mysql-config-diff"
+	driftAnnotation       = "pt-mysql-config-diff/drift"
+	driftedVarsAnnotation = "pt-mysql-config-diff/drifted-variables"
+	checkedAtAnnotation   = "pt-mysql-config-diff/checked-at"
+
+	// How often the mounted files are checked for changes. ConfigMap updates
+	// are seen by the pod up to a minute after they are applied anyway.
+	sidecarPollInterval = 5 * time.Second
+)
+
+// sidecarCheck compares the mounted config with the running server. It
+// annotates the pod on every check and emits an event when the set of
+// drifted variables changes. It returns the drifted variables.
+func sidecarCheck(ctx context.Context, opts *options, loadConfigs configLoader, kube *kubernetesAPI, previous []string, now time.Time) ([]string, error) {
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return previous, err
+	}
+
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return previous, err
+	}
+
+	diffs := cmp.compare(configs)
+	drifted := make([]string, 0, len(diffs))
+	for key := range diffs {
+		drifted = append(drifted, key)
+	}
+	sort.Strings(drifted)
+
+	err = kube.annotatePod(ctx, kube.namespace, opts.PodName, map[string]string{
+		driftAnnotation:       fmt.Sprintf("%d", len(drifted)),
+		driftedVarsAnnotation: strings.Join(drifted, ","),
+		checkedAtAnnotation:   now.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return previous, fmt.Errorf("Cannot annotate the pod: %s", err.Error())
+	}
+
+	if strings.Join(drifted, ",") == strings.Join(previous, ",") {
+		return drifted, nil
+	}
+
+	if len(drifted) == 0 {
+		err = kube.emitEvent(ctx, kube.namespace, opts.PodName, "Normal", "ConfigInSync",
+			"The running MySQL config matches the mounted config", now)
+	} else {
+		details := make([]string, 0, len(drifted))
+		for _, key := range drifted {
+			details = append(details, fmt.Sprintf("%s: %s != %s", key, diffs[key][0], diffs[key][1]))
+		}
+		err = kube.emitEvent(ctx, kube.namespace, opts.PodName, "Warning", "ConfigDrift",
+			fmt.Sprintf("%d variables differ from the mounted config: %s", len(drifted), strings.Join(details, "; ")), now)
+	}
+	if err != nil {
+		return previous, fmt.Errorf("Cannot create the event: %s", err.Error())
+	}
+
+	return drifted, nil
+}
+
+// filesModTime returns the newest modification time of the files. ConfigMap
+// volumes are updated by swapping a symlink, so the targets are checked.
+func filesModTime(filenames []string) time.Time {
+	var newest time.Time
+	for _, filename := range filenames {
+		if info, err := os.Stat(filename); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+// runSidecar runs next to a MySQL container: it compares the mounted cnf
+// files (ConfigMap) with the local server every --interval and when the files
+// change, and reports the drift as events and annotations of the pod.
+func runSidecar(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if opts.PodName == "" {
+		return "", errors.New("The sidecar requires --pod-name (or the POD_NAME env var from the downward API)")
+	}
+
+	// The pod is in the namespace of the service account, or POD_NAMESPACE
+	kube := opts.kubernetes
+	_, err := kube.client()
+	if err != nil {
+		return "", err
+	}
+
+	interval := time.NewTicker(opts.Interval)
+	defer interval.Stop()
+	poll := time.NewTicker(sidecarPollInterval)
+	defer poll.Stop()
+
+	var drifted []string
+	lastModTime := filesModTime(opts.CNFs)
+	for {
+		if drifted, err = sidecarCheck(ctx, opts, loadConfigs, kube, drifted, time.Now()); err != nil {
+			log.Print(err.Error())
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return "", nil
+			case <-interval.C:
+				break wait
+			case <-poll.C:
+				if modTime := filesModTime(opts.CNFs); !modTime.Equal(lastModTime) {
+					lastModTime = modTime
+					break wait
+				}
+			}
+		}
+	}
+}
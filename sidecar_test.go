@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSidecarCheck(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: "mysql-0"}})
+	kube := &kubernetesAPI{clientset: clientset, namespace: "db"}
+	cnf := &config{configType: "cnf", name: "/etc/mysql/conf.d/my.cnf", entries: map[string]interface{}{"max_connections": "500"}}
+	mysql := &config{configType: "mysql", name: "127.0.0.1:3306", entries: map[string]interface{}{"max_connections": "151"}}
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf, mysql}, nil
+	}
+	opts := &options{PodName: "mysql-0"}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	drifted, err := sidecarCheck(context.Background(), opts, loadConfigs, kube, nil, now)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	// The same drift again must not create another event
+	drifted, err = sidecarCheck(context.Background(), opts, loadConfigs, kube, drifted, now)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	pod, err := clientset.CoreV1().Pods("db").Get(context.Background(), "mysql-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantAnnotations := map[string]string{
+		driftAnnotation:       "1",
+		driftedVarsAnnotation: "max_connections",
+		checkedAtAnnotation:   "2024-05-01T10:00:00Z",
+	}
+	if !reflect.DeepEqual(pod.Annotations, wantAnnotations) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", pod.Annotations, wantAnnotations)
+	}
+	events, err := clientset.CoreV1().Events("db").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != "ConfigDrift" || events.Items[0].InvolvedObject.Name != "mysql-0" ||
+		events.Items[0].Message != "1 variables differ from the mounted config: max_connections: 500 != 151" {
+		t.Errorf("Got:\n%#v\nWant a ConfigDrift event\n", events.Items)
+	}
+
+	mysql.entries["max_connections"] = "500"
+	if _, err := sidecarCheck(context.Background(), opts, loadConfigs, kube, drifted, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	events, _ = clientset.CoreV1().Events("db").List(context.Background(), metav1.ListOptions{})
+	if len(events.Items) != 2 || events.Items[1].Reason != "ConfigInSync" || events.Items[1].Type != "Normal" {
+		t.Errorf("Got:\n%#v\nWant a ConfigInSync event\n", events.Items)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// snapshot is the serializable version of a config, used to move configs
+// between processes (agents) and to store them.
+type snapshot struct {
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Taken   time.Time              `json:"taken"`
+	Entries map[string]interface{} `json:"entries"`
+	Origins map[string]entryOrigin `json:"origins,omitempty"`
+}
+
+func newSnapshot(cfg configReader, taken time.Time) snapshot {
+	keys := cfg.Keys()
+	s := snapshot{
+		Name:    cfg.Name(),
+		Type:    cfg.Type(),
+		Taken:   taken,
+		Entries: make(map[string]interface{}, len(keys)),
+	}
+
+	for _, key := range keys {
+		value, _ := cfg.Get(key)
+		s.Entries[key] = valueString(value)
+		if origin, ok := cfg.Origin(key); ok {
+			if s.Origins == nil {
+				s.Origins = make(map[string]entryOrigin)
+			}
+			s.Origins[key] = origin
+		}
+	}
+
+	return s
+}
+
+func (s snapshot) config() *config {
+	return &config{configType: s.Type, name: s.Name, entries: s.Entries, origins: s.Origins}
+}
+
+// valueString returns the value as a string. The MySQL driver returns []byte
+// for most of the variables, that would be encoded as base64 in JSON.
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// runSnapshot prints the snapshots of all the sources as JSON, to be stored
+// and compared later with diff-snapshots.
+func runSnapshot(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	snapshots := make([]snapshot, 0, len(configs))
+	for _, cfg := range configs {
+		snapshots = append(snapshots, newSnapshot(cfg, now))
+	}
+
+	output, err := json.MarshalIndent(snapshots, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	return string(output) + "\n", nil
+}
+
+// readSnapshots reads a file written by the snapshot command. A file with a
+// single snapshot object is also accepted. See readFile for the supported
+// file names.
+func readSnapshots(ctx context.Context, filename string, remote remoteOptions, runCommand commandRunner) ([]snapshot, error) {
+	data, err := readFile(ctx, filename, remote, runCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []snapshot
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var s snapshot
+		err = json.Unmarshal(data, &s)
+		snapshots = append(snapshots, s)
+	} else {
+		err = json.Unmarshal(data, &snapshots)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid snapshot file %s: %s", filename, err.Error())
+	}
+
+	return snapshots, nil
+}
+
+// getSnapshots reads the --snapshot files. Every snapshot of the files is a
+// source, named after the source and when it was taken, to be compared with
+// the live servers and files.
+func getSnapshots(ctx context.Context, opts *options, runCommand commandRunner) ([]configReader, error) {
+	var configs []configReader
+
+	for _, filename := range opts.Snapshots {
+		snapshots, err := readSnapshots(ctx, filename, remoteFileOptions(opts), runCommand)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snapshots {
+			cfg := s.config()
+			cfg.name = fmt.Sprintf("%s@%s", s.Name, s.Taken.Format(time.RFC3339))
+			configs = append(configs, cfg)
+		}
+	}
+
+	return configs, nil
+}
+
+// snapshotDiff is the comparison of a snapshot with the newer one of the same
+// source, in the json outputs of diff-snapshots. Missing is set if the newer
+// file has no snapshot of the source.
+type snapshotDiff struct {
+	Old     time.Time       `json:"old"`
+	New     *time.Time      `json:"new,omitempty"`
+	Diff    json.RawMessage `json:"diff,omitempty"`
+	Missing bool            `json:"missing,omitempty"`
+}
+
+// runDiffSnapshots compares two snapshot files of the same hosts (old.json
+// new.json) to see what changed between them. Snapshots are matched by
+// name; if each file has only one snapshot they are compared even if the
+// names differ.
+func runDiffSnapshots(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if len(opts.args) != 2 {
+		return "", errors.New("Usage: diff-snapshots old.json new.json")
+	}
+
+	olds, err := readSnapshots(ctx, opts.args[0], remoteFileOptions(opts), execCommand)
+	if err != nil {
+		return "", err
+	}
+	news, err := readSnapshots(ctx, opts.args[1], remoteFileOptions(opts), execCommand)
+	if err != nil {
+		return "", err
+	}
+
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return "", err
+	}
+
+	asJSON := opts.OutputFmt == "json" || opts.OutputFmt == "prettyJson"
+	diffs := make(map[string]snapshotDiff)
+	var buffer bytes.Buffer
+	for _, old := range olds {
+		var current *snapshot
+		for i := range news {
+			if news[i].Name == old.Name || (len(olds) == 1 && len(news) == 1) {
+				current = &news[i]
+				break
+			}
+		}
+		if current == nil {
+			diffs[old.Name] = snapshotDiff{Old: old.Taken, Missing: true}
+			buffer.WriteString(fmt.Sprintf("# %s is missing in %s\n", old.Name, opts.args[1]))
+			continue
+		}
+
+		configs := []configReader{old.config(), current.config()}
+		formatter, err := getOutputFormatter(opts, cmp, configs)
+		if err != nil {
+			return "", err
+		}
+		output, err := formatter.Format(cmp.compare(configs))
+		if err != nil {
+			return "", err
+		}
+
+		if asJSON {
+			diffs[old.Name] = snapshotDiff{Old: old.Taken, New: &current.Taken, Diff: json.RawMessage(output)}
+			continue
+		}
+
+		buffer.WriteString(fmt.Sprintf("# %s: %s -> %s\n", old.Name,
+			old.Taken.Format(time.RFC3339), current.Taken.Format(time.RFC3339)))
+		buffer.WriteString(output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			buffer.WriteString("\n")
+		}
+	}
+
+	if asJSON {
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(diffs, "", "\t")
+		} else {
+			output, err = json.Marshal(diffs)
+		}
+		return string(output), err
+	}
+
+	return buffer.String(), nil
+}
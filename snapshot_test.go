@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	opts := &options{
+		OutputFmt: "json",
+		args:      []string{"./test/snapshot-march.json", "./test/snapshot-april.json"},
+	}
+
+	got, err := runDiffSnapshots(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid snapshots: %s", err.Error())
+	}
+
+	want := `{"db1:3306":{"old":"2024-03-01T00:00:00Z","new":"2024-04-01T00:00:00Z","diff":{"max_connections":{"values":["500","1000"],"status":"different"}}}}`
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	opts.OutputFmt = "plain"
+	got, err = runDiffSnapshots(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid snapshots: %s", err.Error())
+	}
+	if !strings.HasPrefix(got, "# db1:3306: 2024-03-01T00:00:00Z -> 2024-04-01T00:00:00Z\n") {
+		t.Errorf("Got:\n%s\nWant the differences under the snapshot name and times\n", got)
+	}
+
+	if _, err := runDiffSnapshots(context.Background(), &options{args: []string{"./test/snapshot-march.json"}}, nil); err == nil {
+		t.Error("Should return error if there are no 2 snapshots")
+	}
+}
+
+func TestGetSnapshots(t *testing.T) {
+	configs, err := getSnapshots(context.Background(), &options{Snapshots: []string{"./test/snapshot-march.json"}}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid snapshots: %s", err.Error())
+	}
+	if len(configs) != 1 || configs[0].Name() != "db1:3306@2024-03-01T00:00:00Z" || configs[0].Type() != "mysql" {
+		t.Fatalf("Got: %#v  --  Want: the db1:3306 snapshot of March\n", configs)
+	}
+
+	live := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{"max_connections": "1000", "sync_binlog": "1"}}
+	got := compare([]configReader{configs[0], live})
+	if len(got) != 1 || got["max_connections"][1] != "1000" {
+		t.Errorf("Got: %#v  --  Want: the max_connections change\n", got)
+	}
+}
+
+func TestDiffSnapshotsJSONHosts(t *testing.T) {
+	dir := t.TempDir()
+	old := `[{"name":"db1","type":"mysql","taken":"2024-03-01T00:00:00Z","entries":{"sync_binlog":"1"}},
+		{"name":"db2","type":"mysql","taken":"2024-03-01T00:00:00Z","entries":{"sync_binlog":"1"}},
+		{"name":"db3","type":"mysql","taken":"2024-03-01T00:00:00Z","entries":{"sync_binlog":"1"}}]`
+	current := `[{"name":"db1","type":"mysql","taken":"2024-04-01T00:00:00Z","entries":{"sync_binlog":"0"}},
+		{"name":"db2","type":"mysql","taken":"2024-04-01T00:00:00Z","entries":{"sync_binlog":"1"}}]`
+	ioutil.WriteFile(filepath.Join(dir, "old.json"), []byte(old), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "new.json"), []byte(current), 0644)
+
+	opts := &options{OutputFmt: "prettyJson", args: []string{filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")}}
+	got, err := runDiffSnapshots(context.Background(), opts, nil)
+	if err != nil {
+		t.Fatalf("Shouldn't return error on valid snapshots: %s", err.Error())
+	}
+
+	// A single JSON document for all the hosts
+	var diffs map[string]struct {
+		New     string          `json:"new"`
+		Diff    json.RawMessage `json:"diff"`
+		Missing bool            `json:"missing"`
+	}
+	if err := json.Unmarshal([]byte(got), &diffs); err != nil {
+		t.Fatalf("Invalid JSON: %s\n%s", err.Error(), got)
+	}
+	if len(diffs) != 3 || diffs["db2"].New != "2024-04-01T00:00:00Z" || !diffs["db3"].Missing {
+		t.Errorf("Got:\n%s\n", got)
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// stdinFileName is the file name that reads the standard input, like
+// --cnf - in a pipeline
+const stdinFileName = "-"
+
+// stdinReader reads the standard input only once, since the cnf files can be
+// read more than once (see the version groups in getConfigs)
+type stdinReader struct {
+	r    io.Reader
+	once sync.Once
+	data []byte
+	err  error
+}
+
+func (s *stdinReader) read() ([]byte, error) {
+	s.once.Do(func() {
+		s.data, s.err = ioutil.ReadAll(s.r)
+	})
+	return s.data, s.err
+}
+
+var stdin = &stdinReader{r: os.Stdin}
+
+// sshFile is a file of another host, named like ssh://user@host:/etc/my.cnf
+// or ssh://user@host:port/etc/my.cnf
+type sshFile struct {
+	host string // [user@]host
+	port string
+	path string
+}
+
+func parseSSHFile(name string) (sshFile, error) {
+	rest := strings.TrimPrefix(name, "ssh://")
+	slash := strings.Index(rest, "/")
+	if slash <= 0 {
+		return sshFile{}, fmt.Errorf("Invalid ssh file %s. Use ssh://user@host:/path", name)
+	}
+
+	file := sshFile{host: strings.TrimSuffix(rest[:slash], ":"), path: rest[slash:]}
+	if pos := strings.LastIndex(file.host, ":"); pos >= 0 {
+		file.host, file.port = file.host[:pos], file.host[pos+1:]
+	}
+	if file.host == "" || strings.HasSuffix(file.host, "@") {
+		return sshFile{}, fmt.Errorf("Invalid ssh file %s. Use ssh://user@host:/path", name)
+	}
+	return file, nil
+}
+
+// prefix returns the ssh:// name of the host, to name other files in it
+func (f sshFile) prefix() string {
+	if f.port != "" {
+		return "ssh://" + f.host + ":" + f.port
+	}
+	return "ssh://" + f.host + ":"
+}
+
+// command returns the ssh arguments to run a command in the host. BatchMode
+// makes ssh fail instead of asking for a password: the ssh agent, the keys and
+// ~/.ssh/config are used.
+func (f sshFile) command(args ...string) []string {
+	sshArgs := []string{"-o", "BatchMode=yes"}
+	if f.port != "" {
+		sshArgs = append(sshArgs, "-p", f.port)
+	}
+	sshArgs = append(sshArgs, f.host, "--")
+	for _, arg := range args {
+		sshArgs = append(sshArgs, shellQuote(arg))
+	}
+	return sshArgs
+}
+
+// shellQuote quotes an argument of the remote command, that ssh runs with the
+// shell of the user
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// hostFile is a file of another host or container, read by running commands
+// on it: ssh://user@host:/path, docker://container/path or
+// k8s-pod://namespace/pod[/container]:/path
+type hostFile struct {
+	prefix  string // Name of the host, prepended to the paths of its files
+	path    string
+	program string
+	command func(args ...string) []string // Arguments of program to run a command on the host
+
+	// exec runs a command on the host instead of program, if set
+	exec func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// run runs a command on the host and returns its standard output
+func (f hostFile) run(ctx context.Context, runCommand commandRunner, args ...string) ([]byte, error) {
+	if f.exec != nil {
+		return f.exec(ctx, args...)
+	}
+	return runCommand(ctx, f.program, f.command(args...)...)
+}
+
+// parseHostFile returns false if the file is not in another host. The pod
+// files are read with the kube client.
+func parseHostFile(name string, kube *kubernetesAPI) (hostFile, bool, error) {
+	switch {
+	case strings.HasPrefix(name, "ssh://"):
+		file, err := parseSSHFile(name)
+		return hostFile{prefix: file.prefix(), path: file.path, program: "ssh", command: file.command}, true, err
+	case strings.HasPrefix(name, "docker://"):
+		container, path := dockerFile(name)
+		if container == "" || path == "" {
+			return hostFile{}, true, fmt.Errorf("Invalid docker file %s. Use docker://container/path", name)
+		}
+		return hostFile{prefix: "docker://" + container, path: path, program: "docker", command: func(args ...string) []string {
+			return append([]string{"exec", container}, args...)
+		}}, true, nil
+	case strings.HasPrefix(name, "k8s-pod://"):
+		return parsePodFile(name, kube)
+	}
+	return hostFile{}, false, nil
+}
+
+// dockerFile splits a docker://container/path name
+func dockerFile(name string) (string, string) {
+	rest := strings.TrimPrefix(name, "docker://")
+	slash := strings.Index(rest, "/")
+	if slash < 0 {
+		return rest, ""
+	}
+	return rest[:slash], rest[slash:]
+}
+
+// parsePodFile parses a k8s-pod://namespace/pod[/container]:/path name. The
+// files are read with the exec API of client-go, so the pod needs cat (and ls
+// for !includedir).
+func parsePodFile(name string, kube *kubernetesAPI) (hostFile, bool, error) {
+	rest := strings.TrimPrefix(name, "k8s-pod://")
+	pos := strings.Index(rest, ":")
+	if pos < 0 {
+		return hostFile{}, true, fmt.Errorf("Invalid pod file %s. Use k8s-pod://namespace/pod[/container]:/path", name)
+	}
+	parts := strings.Split(rest[:pos], "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" || !strings.HasPrefix(rest[pos+1:], "/") {
+		return hostFile{}, true, fmt.Errorf("Invalid pod file %s. Use k8s-pod://namespace/pod[/container]:/path", name)
+	}
+
+	namespace, pod, container := parts[0], parts[1], ""
+	if len(parts) == 3 {
+		container = parts[2]
+	}
+
+	return hostFile{prefix: "k8s-pod://" + rest[:pos+1], path: rest[pos+1:], exec: func(ctx context.Context, args ...string) ([]byte, error) {
+		return kube.podExec(ctx, namespace, pod, container, args...)
+	}}, true, nil
+}
+
+// gitFile is a file of a git revision, named like git:REF:path, with the
+// path relative to the root of the repository of the working directory
+type gitFile struct {
+	ref  string
+	path string
+}
+
+func parseGitFile(name string) (gitFile, error) {
+	parts := strings.SplitN(strings.TrimPrefix(name, "git:"), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return gitFile{}, fmt.Errorf("Invalid git file %s. Use git:REF:path", name)
+	}
+	return gitFile{ref: parts[0], path: parts[1]}, nil
+}
+
+// name returns the git:REF:path name of another file of the revision
+func (f gitFile) name(path string) string {
+	return "git:" + f.ref + ":" + path
+}
+
+// relative returns the name of a file relative to the directory of this one
+func (f gitFile) relative(name string) string {
+	return f.name(path.Join(path.Dir(f.path), name))
+}
+
+// readConfigMapFile reads a key of a ConfigMap, named like
+// k8s-configmap://namespace/name[/key], with client-go. The key can be
+// omitted if the ConfigMap has only one.
+func readConfigMapFile(ctx context.Context, name string, kube *kubernetesAPI) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(name, "k8s-configmap://"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("Invalid ConfigMap %s. Use k8s-configmap://namespace/name[/key]", name)
+	}
+
+	cmData, err := kube.configMapData(ctx, parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parts) == 3 {
+		data, ok := cmData[parts[2]]
+		if !ok {
+			return nil, fmt.Errorf("The ConfigMap %s/%s has no %s key", parts[0], parts[1], parts[2])
+		}
+		return []byte(data), nil
+	}
+
+	if len(cmData) != 1 {
+		keys := make([]string, 0, len(cmData))
+		for key := range cmData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("The ConfigMap %s/%s has %d keys, choose one: %s", parts[0], parts[1], len(keys), strings.Join(keys, ", "))
+	}
+	for _, data := range cmData {
+		return []byte(data), nil
+	}
+	return nil, nil
+}
+
+// httpAuth are the credentials sent to read http:// and https:// files. The
+// bearer token is used if both are set.
+type httpAuth struct {
+	User     string
+	Password string
+	Token    string
+}
+
+// remoteOptions are the settings to read the remote files
+type remoteOptions struct {
+	HTTP       httpAuth
+	AWSRegion  string         // Region of the s3:// buckets, else the AWS SDK default
+	AWSProfile string         // Shared config profile used for the s3:// files
+	Kubernetes *kubernetesAPI // Reads the k8s-pod:// and k8s-configmap:// files
+
+	s3Endpoint string // Used instead of the AWS endpoints, by the tests
+}
+
+// remoteFileOptions returns the remote settings of the --http-* and --aws-*
+// flags
+func remoteFileOptions(opts *options) remoteOptions {
+	return remoteOptions{
+		HTTP:       httpAuth{User: opts.HTTPUser, Password: opts.HTTPPassword, Token: opts.HTTPToken},
+		AWSRegion:  opts.AWSRegion,
+		AWSProfile: opts.AWSProfile,
+		Kubernetes: opts.kubernetes,
+	}
+}
+
+// readS3File downloads an object, named like s3://bucket/key, with the AWS
+// SDK. The credentials are the ones of the default chain (environment, shared
+// config and credentials files, SSO, instance and task roles...), with the
+// --aws-profile and --aws-region if set.
+func readS3File(ctx context.Context, url string, remote remoteOptions) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(url, "s3://"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("Invalid S3 object %s. Use s3://bucket/key", url)
+	}
+
+	var loadOptions []func(*awsconfig.LoadOptions) error
+	if remote.AWSRegion != "" {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(remote.AWSRegion))
+	}
+	if remote.AWSProfile != "" {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigProfile(remote.AWSProfile))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot load the AWS config: %s", err.Error())
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if remote.s3Endpoint != "" {
+			o.BaseEndpoint = aws.String(remote.s3Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	object, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(parts[0]), Key: aws.String(parts[1])})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Body.Close()
+
+	return ioutil.ReadAll(object.Body)
+}
+
+// defaultS3Region is used when neither --aws-region nor the AWS config set
+// the region, like the aws cli does for S3
+const defaultS3Region = "us-east-1"
+
+// readHTTPFile downloads a file, like a golden config from an artifact server
+func readHTTPFile(ctx context.Context, url string, auth httpAuth) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case auth.Token != "":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case auth.User != "":
+		req.SetBasicAuth(auth.User, auth.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// readFile reads a local file, the standard input (-), a file of another host
+// over ssh (ssh://user@host:/path), a file of a running container
+// (docker://container/path) or pod (k8s-pod://namespace/pod:/path), a key of
+// a ConfigMap (k8s-configmap://namespace/name/key), a http:// or https:// URL,
+// an object stored in S3 (s3://bucket/key) or Google Cloud Storage
+// (gs://bucket/object) or a file of a git revision (git:REF:path). Remote
+// files are read with the ssh, docker, gcloud and git clis, the S3 objects
+// with the AWS SDK and the pods and ConfigMaps with client-go, so their usual
+// credentials (ssh agent and keys, kubeconfig, environment, profiles, instance
+// roles, gcloud auth...) are used.
+func readFile(ctx context.Context, name string, remote remoteOptions, runCommand commandRunner) ([]byte, error) {
+	if file, ok, err := parseHostFile(name, remote.Kubernetes); ok {
+		if err != nil {
+			return nil, err
+		}
+		return file.run(ctx, runCommand, "cat", file.path)
+	}
+
+	switch {
+	case name == stdinFileName:
+		return stdin.read()
+	case strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://"):
+		return readHTTPFile(ctx, name, remote.HTTP)
+	case strings.HasPrefix(name, "k8s-configmap://"):
+		return readConfigMapFile(ctx, name, remote.Kubernetes)
+	case strings.HasPrefix(name, "s3://"):
+		return readS3File(ctx, name, remote)
+	case strings.HasPrefix(name, "gs://"):
+		return runCommand(ctx, "gcloud", "storage", "cat", name)
+	case strings.HasPrefix(name, "git:"):
+		file, err := parseGitFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return runCommand(ctx, "git", "show", file.ref+":"+file.path)
+	default:
+		return ioutil.ReadFile(name)
+	}
+}
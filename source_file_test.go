@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadRemoteFile(t *testing.T) {
+	var gotCommand string
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		gotCommand = name + " " + strings.Join(args, " ")
+		return []byte("[mysqld]\nmax_connections = 500\n"), nil
+	}
+
+	tests := map[string]string{
+		"gs://configs/golden/my.cnf":       "gcloud storage cat gs://configs/golden/my.cnf",
+		"ssh://dbadmin@db01:/etc/my.cnf":   "ssh -o BatchMode=yes dbadmin@db01 -- 'cat' '/etc/my.cnf'",
+		"ssh://db01:2222/etc/mysql/my.cnf": "ssh -o BatchMode=yes -p 2222 db01 -- 'cat' '/etc/mysql/my.cnf'",
+	}
+	for url, wantCommand := range tests {
+		cnf, err := newCNFReader(context.Background(), url, cnfReadOptions{}, runCommand)
+		if err != nil {
+			t.Fatalf("Shouldn't return error: %s", err.Error())
+		}
+		if gotCommand != wantCommand {
+			t.Errorf("Got: %s  --  Want: %s\n", gotCommand, wantCommand)
+		}
+		if value, _ := cnf.Get("max_connections"); value != "500" || cnf.Name() != url {
+			t.Errorf("Got: %s %v  --  Want: %s 500\n", cnf.Name(), value, url)
+		}
+	}
+}
+
+func TestReadStdin(t *testing.T) {
+	defer func(saved *stdinReader) { stdin = saved }(stdin)
+	stdin = &stdinReader{r: strings.NewReader("[mysqld]\nmax_connections = 500\n")}
+
+	// The second read gets the same content
+	for i := 0; i < 2; i++ {
+		cnf, err := newCNFReader(context.Background(), "-", cnfReadOptions{}, nil)
+		if err != nil {
+			t.Fatalf("Shouldn't return error: %s", err.Error())
+		}
+		if value, _ := cnf.Get("max_connections"); value != "500" {
+			t.Errorf("Got: %v  --  Want: 500\n", value)
+		}
+	}
+}
+
+func TestParseSSHFile(t *testing.T) {
+	for _, name := range []string{"ssh://db01", "ssh://:/etc/my.cnf", "ssh://dbadmin@:/etc/my.cnf"} {
+		if _, err := parseSSHFile(name); err == nil {
+			t.Errorf("%s -- Should return an error", name)
+		}
+	}
+
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("Got: %s  --  Want: %s\n", got, `'it'\''s'`)
+	}
+}
+
+func TestReadHTTPFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if r.Header.Get("Authorization") != "Bearer secret" && (user != "admin" || password != "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("[mysqld]\nmax_connections = 500\n"))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/mysql/prod.cnf"
+	for _, auth := range []httpAuth{{Token: "secret"}, {User: "admin", Password: "pass"}} {
+		cnf, err := newCNFReader(context.Background(), url, cnfReadOptions{Remote: remoteOptions{HTTP: auth}}, nil)
+		if err != nil {
+			t.Fatalf("Shouldn't return error: %s", err.Error())
+		}
+		if value, _ := cnf.Get("max_connections"); value != "500" {
+			t.Errorf("Got: %v  --  Want: 500\n", value)
+		}
+	}
+
+	if _, err := newCNFReader(context.Background(), url, cnfReadOptions{}, nil); err == nil {
+		t.Error("Should return an error without credentials")
+	}
+}
+
+func TestReadS3File(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		if r.URL.Path != "/configs/golden/my.cnf" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+			return
+		}
+		w.Write([]byte("[mysqld]\nmax_connections = 500\n"))
+	}))
+	defer server.Close()
+
+	remote := remoteOptions{AWSRegion: "eu-west-1", s3Endpoint: server.URL}
+	data, err := readS3File(context.Background(), "s3://configs/golden/my.cnf", remote)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	if string(data) != "[mysqld]\nmax_connections = 500\n" {
+		t.Errorf("Got: %q  --  Want: the object\n", data)
+	}
+	if gotPath != "/configs/golden/my.cnf" || !strings.Contains(gotAuth, "AKIAEXAMPLE/") || !strings.Contains(gotAuth, "/eu-west-1/s3/") {
+		t.Errorf("The request should be signed for the region. Got: %s %s", gotPath, gotAuth)
+	}
+
+	if _, err := readS3File(context.Background(), "s3://configs/missing.cnf", remote); err == nil {
+		t.Error("Should return an error for the missing objects")
+	}
+	if _, err := readS3File(context.Background(), "s3://configs", remote); err == nil {
+		t.Error("Should return an error without the key")
+	}
+}
+
+func TestReadKubernetesFiles(t *testing.T) {
+	var gotCommand string
+	remote := remoteOptions{Kubernetes: &kubernetesAPI{
+		clientset: fake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "mysql-config"},
+			Data:       map[string]string{"my.cnf": "[mysqld]\nport = 3306\n", "init.sql": "SELECT 1"},
+		}),
+		exec: func(ctx context.Context, namespace, pod, container string, command ...string) ([]byte, error) {
+			gotCommand = strings.Join(append([]string{namespace, pod, container}, command...), " ")
+			return []byte("[mysqld]\nport = 3307\n"), nil
+		},
+	}}
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Errorf("The Kubernetes files should not run %s", name)
+		return nil, nil
+	}
+
+	tests := map[string]string{
+		"k8s-pod://prod/mysql-0:/etc/my.cnf":       "prod mysql-0  cat /etc/my.cnf",
+		"k8s-pod://prod/mysql-0/mysql:/etc/my.cnf": "prod mysql-0 mysql cat /etc/my.cnf",
+	}
+	for name, want := range tests {
+		data, err := readFile(context.Background(), name, remote, runCommand)
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", name, err.Error())
+		}
+		if gotCommand != want || string(data) != "[mysqld]\nport = 3307\n" {
+			t.Errorf("Got: %s %q  --  Want: %s\n", gotCommand, data, want)
+		}
+	}
+
+	data, err := readFile(context.Background(), "k8s-configmap://prod/mysql-config/my.cnf", remote, runCommand)
+	if err != nil || string(data) != "[mysqld]\nport = 3306\n" {
+		t.Errorf("Got: %q %v  --  Want: the my.cnf key\n", data, err)
+	}
+	_, err = readFile(context.Background(), "k8s-configmap://prod/mysql-config", remote, runCommand)
+	if err == nil || !strings.Contains(err.Error(), "init.sql, my.cnf") {
+		t.Errorf("Should ask for one of the keys. Got: %v", err)
+	}
+	if _, err := readFile(context.Background(), "k8s-configmap://prod/other-config/my.cnf", remote, runCommand); err == nil {
+		t.Errorf("Should return an error for the missing ConfigMaps")
+	}
+	if _, err := readFile(context.Background(), "k8s-pod://prod:/etc/my.cnf", remote, runCommand); err == nil {
+		t.Errorf("Should return an error without the pod")
+	}
+
+	if got := includePath("k8s-pod://prod/mysql-0:/etc/my.cnf", "/etc/mysql/conf.d"); got != "k8s-pod://prod/mysql-0:/etc/mysql/conf.d" {
+		t.Errorf("Got: %s  --  Want: k8s-pod://prod/mysql-0:/etc/mysql/conf.d\n", got)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+)
+
+// sqlModeCombinations are the sql_mode values that stand for a list of modes.
+// Servers report the modes of a combination besides its name, like
+// REAL_AS_FLOAT,PIPES_AS_CONCAT,ANSI_QUOTES,IGNORE_SPACE,ONLY_FULL_GROUP_BY,ANSI
+// for ANSI. 8.0 removed NO_AUTO_CREATE_USER and the combinations of the other
+// databases.
+var sqlModeCombinations = map[string]map[string][]string{
+	"5.7": {
+		"ANSI":        {"REAL_AS_FLOAT", "PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "ONLY_FULL_GROUP_BY"},
+		"DB2":         {"PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "NO_FIELD_OPTIONS"},
+		"MAXDB":       {"PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "NO_FIELD_OPTIONS", "NO_AUTO_CREATE_USER"},
+		"MSSQL":       {"PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "NO_FIELD_OPTIONS"},
+		"MYSQL323":    {"MYSQL323", "HIGH_NOT_PRECEDENCE"},
+		"MYSQL40":     {"MYSQL40", "HIGH_NOT_PRECEDENCE"},
+		"ORACLE":      {"PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "NO_FIELD_OPTIONS", "NO_AUTO_CREATE_USER"},
+		"POSTGRESQL":  {"PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS", "NO_FIELD_OPTIONS"},
+		"TRADITIONAL": {"STRICT_TRANS_TABLES", "STRICT_ALL_TABLES", "NO_ZERO_IN_DATE", "NO_ZERO_DATE", "ERROR_FOR_DIVISION_BY_ZERO", "NO_AUTO_CREATE_USER", "NO_ENGINE_SUBSTITUTION"},
+	},
+	"8.0": {
+		"ANSI":        {"REAL_AS_FLOAT", "PIPES_AS_CONCAT", "ANSI_QUOTES", "IGNORE_SPACE", "ONLY_FULL_GROUP_BY"},
+		"TRADITIONAL": {"STRICT_TRANS_TABLES", "STRICT_ALL_TABLES", "NO_ZERO_IN_DATE", "NO_ZERO_DATE", "ERROR_FOR_DIVISION_BY_ZERO", "NO_ENGINE_SUBSTITUTION"},
+	},
+}
+
+// expandSQLModes replaces the combination modes of a sql_mode value with the
+// modes they stand for, so TRADITIONAL and its list of modes are the same
+// value. The combinations are the ones of the version, 8.0 if unknown.
+func expandSQLModes(value, version string) string {
+	combinations := sqlModeCombinations["8.0"]
+	if majorMinor(version) == "5.7" {
+		combinations = sqlModeCombinations["5.7"]
+	}
+
+	var modes []string
+	for _, mode := range strings.Split(value, ",") {
+		if expanded, ok := combinations[strings.ToUpper(strings.TrimSpace(mode))]; ok {
+			modes = append(modes, expanded...)
+			continue
+		}
+		modes = append(modes, mode)
+	}
+	return strings.Join(modes, ",")
+}
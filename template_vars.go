@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// templateVariable is a ${VAR} placeholder of a cnf template
+var templateVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandTemplate replaces the ${VAR} placeholders of a cnf file. $VAR without
+// braces is left as it is, since values like passwords can have a $. A
+// placeholder without a value is an error, so a golden config is never
+// compared half rendered.
+func expandTemplate(filename string, data []byte, vars map[string]string) ([]byte, error) {
+	var undefined []string
+	expanded := templateVariable.ReplaceAllFunc(data, func(placeholder []byte) []byte {
+		name := string(placeholder[2 : len(placeholder)-1])
+		value, ok := vars[name]
+		if !ok {
+			undefined = append(undefined, name)
+			return placeholder
+		}
+		return []byte(value)
+	})
+	if len(undefined) > 0 {
+		return nil, fmt.Errorf("%s: undefined template variables: %s", filename, strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
+
+// templateVars returns the values of the ${VAR} placeholders: the environment
+// and the --vars file, that wins. It returns nil if the cnf files are not
+// templates.
+func templateVars(opts *options) (map[string]string, error) {
+	if !opts.ExpandVars && opts.VarsFile == "" {
+		return nil, nil
+	}
+
+	vars := make(map[string]string)
+	for _, env := range os.Environ() {
+		if pos := strings.Index(env, "="); pos > 0 {
+			vars[env[:pos]] = env[pos+1:]
+		}
+	}
+
+	if opts.VarsFile != "" {
+		fileVars, err := readVarsFile(opts.VarsFile)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read %s: %s", opts.VarsFile, err.Error())
+		}
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+
+	return vars, nil
+}
+
+// readVarsFile reads a file of NAME=value lines, like the .env files. Blank
+// lines and # comments are skipped and the quotes around the values removed.
+func readVarsFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		pos := strings.Index(line, "=")
+		if pos <= 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=value: %s", lineNumber, line)
+		}
+		value := strings.TrimSpace(line[pos+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[strings.TrimSpace(line[:pos])] = value
+	}
+
+	return vars, scanner.Err()
+}
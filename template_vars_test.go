@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	dir := t.TempDir()
+	cnfFile := filepath.Join(dir, "golden.cnf")
+	varsFile := filepath.Join(dir, "prod.env")
+	ioutil.WriteFile(cnfFile, []byte("[mysqld]\ninnodb_buffer_pool_size = ${BUFFER_POOL}\nserver_id = ${SERVER_ID}\ninit_connect = 'SET @a=$b'\n"), 0644)
+	ioutil.WriteFile(varsFile, []byte("# prod\nexport BUFFER_POOL=\"8G\"\n\nSERVER_ID=1\n"), 0644)
+
+	os.Setenv("SERVER_ID", "2")
+	defer os.Unsetenv("SERVER_ID")
+
+	vars, err := templateVars(&options{VarsFile: varsFile})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	cnf, err := newCNFReader(context.Background(), cnfFile, cnfReadOptions{Vars: vars}, execCommand)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := map[string]interface{}{"innodb_buffer_pool_size": "8G", "server_id": "1", "init_connect": "SET @a=$b"}
+	if !reflect.DeepEqual(cnf.Entries(), want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cnf.Entries(), want)
+	}
+
+	// The environment is used without --vars
+	vars, _ = templateVars(&options{ExpandVars: true})
+	if _, err := newCNFReader(context.Background(), cnfFile, cnfReadOptions{Vars: vars}, execCommand); err == nil {
+		t.Error("Should return error on undefined variables")
+	}
+	if vars, _ := templateVars(&options{}); vars != nil {
+		t.Errorf("Got:\n%#v\nWant: nil\n", vars)
+	}
+}
@@ -0,0 +1,23 @@
+resource "aws_db_parameter_group" "default" {
+  name   = "rds-pg"
+  family = "mysql8.0"
+
+  parameter {
+    name  = "character_set_server"
+    value = "utf8mb4"
+  }
+
+  parameter {
+    name         = "innodb_flush_log_at_trx_commit"
+    value        = "2"
+    apply_method = "immediate"
+  }
+
+  tags = {
+    team = "dba"
+  }
+}
+
+resource "aws_db_instance" "db" {
+  parameter_group_name = aws_db_parameter_group.default.name
+}
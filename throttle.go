@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// connectionThrottle limits how many connections are open at the same time,
+// in total and per cluster, and how fast new connections are opened, so big
+// runs don't look like a connection storm to proxies and bastions.
+// A cluster is identified by the endpoint we connect to (host:port), so all
+// the servers behind the same ProxySQL share the same limit.
+type connectionThrottle struct {
+	global             chan struct{}
+	clusterConcurrency int
+	ticker             *time.Ticker
+
+	mu       sync.Mutex
+	clusters map[string]chan struct{}
+}
+
+// newConnectionThrottle returns a throttle allowing concurrency open
+// connections, clusterConcurrency per cluster and rate new connections per
+// second. Zero or negative values mean no limit (except concurrency, that is
+// at least 1).
+func newConnectionThrottle(concurrency, clusterConcurrency int, rate float64) *connectionThrottle {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	t := &connectionThrottle{
+		global:             make(chan struct{}, concurrency),
+		clusterConcurrency: clusterConcurrency,
+		clusters:           make(map[string]chan struct{}),
+	}
+	if rate > 0 {
+		t.ticker = time.NewTicker(time.Duration(float64(time.Second) / rate))
+	}
+
+	return t
+}
+
+// acquire blocks until a new connection to the cluster can be opened or the
+// context is done. The returned func must be called once the connection is
+// closed.
+func (t *connectionThrottle) acquire(ctx context.Context, cluster string) (func(), error) {
+	var clusterSlots chan struct{}
+	if t.clusterConcurrency > 0 {
+		t.mu.Lock()
+		clusterSlots = t.clusters[cluster]
+		if clusterSlots == nil {
+			clusterSlots = make(chan struct{}, t.clusterConcurrency)
+			t.clusters[cluster] = clusterSlots
+		}
+		t.mu.Unlock()
+		select {
+		case clusterSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case t.global <- struct{}{}:
+	case <-ctx.Done():
+		if clusterSlots != nil {
+			<-clusterSlots
+		}
+		return nil, ctx.Err()
+	}
+
+	release := func() {
+		<-t.global
+		if clusterSlots != nil {
+			<-clusterSlots
+		}
+	}
+
+	if t.ticker != nil {
+		select {
+		case <-t.ticker.C:
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+func (t *connectionThrottle) stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConnectionThrottle(t *testing.T) {
+	throttle := newConnectionThrottle(4, 2, 0)
+	defer throttle.stop()
+
+	var mu sync.Mutex
+	open, maxOpen := map[string]int{}, map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		cluster := "proxy:6033"
+		if i%3 == 0 {
+			cluster = "db1:3306"
+		}
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			release, err := throttle.acquire(context.Background(), cluster)
+			if err != nil {
+				t.Errorf("Shouldn't return error: %s", err.Error())
+				return
+			}
+			mu.Lock()
+			open[cluster]++
+			open["total"]++
+			for _, key := range []string{cluster, "total"} {
+				if open[key] > maxOpen[key] {
+					maxOpen[key] = open[key]
+				}
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			open[cluster]--
+			open["total"]--
+			mu.Unlock()
+			release()
+		}(cluster)
+	}
+	wg.Wait()
+
+	if maxOpen["proxy:6033"] > 2 || maxOpen["db1:3306"] > 2 {
+		t.Errorf("There must be at most 2 connections per cluster. Got %v", maxOpen)
+	}
+	if maxOpen["total"] > 4 {
+		t.Errorf("There must be at most 4 connections. Got %d", maxOpen["total"])
+	}
+}
+
+func TestConnectionThrottleRate(t *testing.T) {
+	throttle := newConnectionThrottle(10, 0, 100)
+	defer throttle.stop()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, _ := throttle.acquire(context.Background(), "db1:3306")
+		release()
+	}
+
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("5 connections at 100/s must take at least 40ms. Got %s", elapsed)
+	}
+}
+
+func TestConnectionThrottleCancel(t *testing.T) {
+	throttle := newConnectionThrottle(1, 0, 0)
+	defer throttle.stop()
+
+	release, _ := throttle.acquire(context.Background(), "db1:3306")
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := throttle.acquire(ctx, "db1:3306"); err != context.DeadlineExceeded {
+		t.Errorf("Got: %v Want: %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// tolerance is the margin of --tolerance: the numeric values closer than it
+// are equal, like the innodb_buffer_pool_size rounded by the server to a
+// multiple of the chunk size
+type tolerance struct {
+	value   float64
+	percent bool // value is a percentage of the largest value
+}
+
+// parseTolerance parses an absolute tolerance, with an optional K, M, G or T
+// size suffix, or a percentage like 5%
+func parseTolerance(str string) (tolerance, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return tolerance{}, nil
+	}
+
+	if strings.HasSuffix(str, "%") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(str, "%")), 64)
+		if err != nil || f < 0 {
+			return tolerance{}, fmt.Errorf("Invalid tolerance %s", str)
+		}
+		return tolerance{value: f, percent: true}, nil
+	}
+
+	v, ok := parseInteger(str)
+	if !ok {
+		v, ok = parseFloat(str)
+	}
+	if !ok || v.number < 0 {
+		return tolerance{}, fmt.Errorf("Invalid tolerance %s. Use a number, a size like 1M or a percentage like 5%%", str)
+	}
+	return tolerance{value: v.number}, nil
+}
+
+// match returns true if both values are numbers within the tolerance
+func (t tolerance) match(v1, v2 typedValue) bool {
+	if t.value == 0 || !v1.isNumber() || !v2.isNumber() {
+		return false
+	}
+	margin := t.value
+	if t.percent {
+		margin = math.Max(math.Abs(v1.number), math.Abs(v2.number)) * t.value / 100
+	}
+	return math.Abs(v1.number-v2.number) <= margin
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTolerance(t *testing.T) {
+	tests := []struct {
+		tolerance      string
+		name           string
+		value1, value2 interface{}
+		want           bool
+	}{
+		{"", "innodb_buffer_pool_size", "1G", "1073741824", true},
+		{"", "innodb_buffer_pool_size", "1000M", "1G", false},
+		{"128M", "innodb_buffer_pool_size", "1000M", "1G", true},
+		{"5%", "innodb_buffer_pool_size", "1000M", "1G", true},
+		{"1%", "innodb_buffer_pool_size", "1000M", "1G", false},
+		{"10%", "table_open_cache", "4000", "4096", true},
+		{"10%", "binlog_format", "ROW", "MIXED", false},
+		{"0.5", "long_query_time", "1", "1.25", true},
+		{"1M", "sync_binlog", "0", "1", false},
+		{"1M", "server_id", "1", "2", false},
+	}
+
+	for _, test := range tests {
+		cmp, err := newComparer(&options{Tolerance: test.tolerance})
+		if err != nil {
+			t.Fatalf("Shouldn't return error on a valid tolerance: %s", err.Error())
+		}
+		if got := cmp.equal(test.name, test.value1, test.value2); got != test.want {
+			t.Errorf("%s with tolerance %s: %#v == %#v Got: %v Want: %v", test.name, test.tolerance, test.value1, test.value2, got, test.want)
+		}
+	}
+
+	// A per variable rule applies an absolute tolerance to any number
+	one, _ := parseTolerance("1")
+	cmp := &comparer{tolerance: tolerance{value: 1 << 20}, rules: map[string]variableRule{"server_id": {tolerance: &one}}}
+	if !cmp.equal("server_id", "1", "2") {
+		t.Errorf("server_id with the rule tolerance 1: 1 == 2 Got: false Want: true")
+	}
+	if cmp.equal("sync_binlog", "0", "1") {
+		t.Errorf("sync_binlog with tolerance 1M: 0 == 1 Got: true Want: false")
+	}
+
+	for _, invalid := range []string{"-1", "x%", "1X"} {
+		if _, err := parseTolerance(invalid); err == nil {
+			t.Errorf("Should return error on the invalid tolerance %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// defaultTopologyRecursion is used by topology-diff when --recursion-method
+// is not set
+const defaultTopologyRecursion = "hosts,processlist"
+
+// isServer returns true for the configs read from a running server
+func isServer(cfg configReader) bool {
+	return cfg.Type() == "mysql" || cfg.Type() == "mariadb"
+}
+
+// runTopologyDiff finds the replicas of a primary, and of its replicas, and
+// compares every one of them with the primary, one by one, so the
+// deviations of each replica are listed apart.
+func runTopologyDiff(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if len(opts.DSNs) != 1 {
+		return "", errors.New("topology-diff needs the --dsn of the primary")
+	}
+	if (opts.RecursionMethod == "" || opts.RecursionMethod == "none") && opts.RecurseToDSNTable == "" {
+		opts.RecursionMethod = defaultTopologyRecursion
+	}
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cmp, err := newComparer(opts)
+	if err != nil {
+		return "", err
+	}
+
+	servers := make(map[string]configReader)
+	for _, cfg := range configs {
+		if isServer(cfg) {
+			servers[cfg.Name()] = cfg
+		}
+	}
+	primaryName := dsnName(opts.DSNs[0])
+	primary, ok := servers[primaryName]
+	if !ok {
+		return "", fmt.Errorf("The primary %s was not read", primaryName)
+	}
+
+	var buffer bytes.Buffer
+	replicas, deviating := 0, 0
+	for _, node := range opts.topology {
+		replica, ok := servers[dsnName(node.DSN)]
+		if node.Level == 0 || !ok {
+			continue
+		}
+		replicas++
+
+		pair := []configReader{primary, replica}
+		diffs := cmp.compare(pair)
+		header := fmt.Sprintf("# %s -> %s", primary.Name(), replica.Name())
+		if node.Source != primary.Name() {
+			header += fmt.Sprintf(" (replica of %s)", node.Source)
+		}
+		buffer.WriteString(header + "\n")
+		if len(diffs) == 0 {
+			buffer.WriteString("No differences\n")
+			continue
+		}
+		deviating++
+
+		formatter, err := getOutputFormatter(opts, cmp, pair)
+		if err != nil {
+			return "", err
+		}
+		output, err := formatter.Format(diffs)
+		if err != nil {
+			return "", err
+		}
+		buffer.WriteString(output)
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			buffer.WriteString("\n")
+		}
+	}
+
+	if replicas == 0 {
+		return "", fmt.Errorf("No replicas of %s were found with --recursion-method %s", primaryName, opts.RecursionMethod)
+	}
+	buffer.WriteString(fmt.Sprintf("%d of %d replicas deviate from %s\n", deviating, replicas, primaryName))
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestRunTopologyDiff(t *testing.T) {
+	primary := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{"max_connections": "500", "sync_binlog": "1"}}
+	replica := &config{configType: "mysql", name: "db2:3306", entries: map[string]interface{}{"max_connections": "500", "sync_binlog": "1"}}
+	cascaded := &config{configType: "mysql", name: "db3:3306", entries: map[string]interface{}{"max_connections": "300", "sync_binlog": "1"}}
+
+	opts := &options{OutputFmt: "plain", DSNs: []string{"root@tcp(db1:3306)/"}}
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		opts.topology = []topologyNode{
+			{DSN: "root@tcp(db1:3306)/"},
+			{DSN: "root@tcp(db2:3306)/", Source: "db1:3306", Level: 1},
+			{DSN: "root@tcp(db3:3306)/", Source: "db2:3306", Level: 2},
+		}
+		return []configReader{primary, replica, cascaded}, nil
+	}
+
+	got, err := runTopologyDiff(context.Background(), opts, loadConfigs)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := "# db1:3306 -> db2:3306\n" +
+		"No differences\n" +
+		"# db1:3306 -> db3:3306 (replica of db2:3306)\n" +
+		fmt.Sprintf("%35s: %40s : %40s\n", "max_connections", "500", "300") +
+		"1 of 2 replicas deviate from db1:3306\n"
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+	if opts.RecursionMethod != defaultTopologyRecursion {
+		t.Errorf("Got: %s  --  Want: %s\n", opts.RecursionMethod, defaultTopologyRecursion)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueKind is how a variable value is compared
+type valueKind int
+
+const (
+	stringKind valueKind = iota
+	integerKind
+	floatKind
+	booleanKind
+	setKind
+	pathKind
+)
+
+// typedValue is a variable value parsed according to its type. text is the
+// canonical form of the value: two values of the same kind are equal when
+// their texts are equal. number is set for integers and floats.
+type typedValue struct {
+	kind   valueKind
+	text   string
+	number float64
+}
+
+func (v typedValue) isNumber() bool {
+	return v.kind == integerKind || v.kind == floatKind
+}
+
+func (v typedValue) equal(other typedValue) bool {
+	if v.isNumber() && other.isNumber() && (v.kind == floatKind || other.kind == floatKind) {
+		// 0 and 0.000000 are the same
+		return v.number == other.number
+	}
+	if v.kind == pathKind && other.kind == pathKind && v.text != other.text {
+		// A relative path, in a config without datadir to resolve it, is the
+		// same as the absolute path ending with it
+		return relativeMatch(v.text, other.text) || relativeMatch(other.text, v.text)
+	}
+	return v.text == other.text
+}
+
+func relativeMatch(relative, absolute string) bool {
+	return relative != "" && !filepath.IsAbs(relative) && filepath.IsAbs(absolute) && strings.HasSuffix(absolute, string(filepath.Separator)+relative)
+}
+
+// cleanPath removes the trailing slashes, the repeated slashes and the . and
+// .. elements of a path, so /var/lib/mysql/ and /var/lib//mysql are the same
+// as /var/lib/mysql and ./mysql-bin is mysql-bin
+func cleanPath(str string) string {
+	if str == "" {
+		return str
+	}
+	return filepath.Clean(str)
+}
+
+// booleanValues are the spellings of booleans accepted by the server and the
+// option files. Flags without value are read as "true" from the cnf files.
+var booleanValues = map[string]bool{
+	"ON":    true,
+	"TRUE":  true,
+	"YES":   true,
+	"OFF":   false,
+	"FALSE": false,
+	"NO":    false,
+}
+
+// pathValue is a value that is a path, whatever the type of the variable, like
+// the values of the type: path normalizers
+type pathValue string
+
+// parseTypedValue parses the value using the type from the variables
+// metadata. Variables without a known type, or values that don't match their
+// type (like UNLIMITED), have their type guessed from the value.
+func parseTypedValue(name string, value interface{}) typedValue {
+	if path, ok := value.(pathValue); ok {
+		return typedValue{kind: pathKind, text: cleanPath(strings.TrimSpace(string(path)))}
+	}
+	str := strings.TrimSpace(valueString(value))
+	info, _ := getVariableInfo(name)
+
+	if info.Unit != "" {
+		if v, ok := parseDuration(str, info.Unit); ok {
+			return v
+		}
+	}
+
+	switch info.Type {
+	case "integer", "size":
+		if v, ok := parseInteger(str); ok {
+			return v
+		}
+	case "numeric":
+		if v, ok := parseFloat(str); ok {
+			return v
+		}
+	case "boolean":
+		// 1 and 0 are booleans only for the variables we know are booleans
+		switch str {
+		case "1":
+			str = "ON"
+		case "0":
+			str = "OFF"
+		}
+		if v, ok := parseBoolean(str); ok {
+			return v
+		}
+	case "set":
+		return parseSet(strings.ToUpper(str))
+	case "list":
+		// Lists are sets of names, like accounts, that are case sensitive
+		return parseSet(str)
+	case "enumeration":
+		// Enumeration values are case insensitive
+		return typedValue{kind: stringKind, text: strings.ToUpper(str)}
+	case "file", "directory":
+		return typedValue{kind: pathKind, text: cleanPath(str)}
+	case "string":
+		return typedValue{kind: stringKind, text: str}
+	}
+
+	return guessTypedValue(str)
+}
+
+func guessTypedValue(str string) typedValue {
+	if v, ok := parseInteger(str); ok {
+		return v
+	}
+	if v, ok := parseFloat(str); ok {
+		return v
+	}
+	if v, ok := parseBoolean(str); ok {
+		return v
+	}
+	if strings.Contains(str, ",") {
+		return parseSet(str)
+	}
+	return typedValue{kind: stringKind, text: str}
+}
+
+// parseInteger parses integers with an optional K, M, G, T, P or E size
+// suffix, in any case, and the hex and exponent notations of integers, like
+// 0x1000 and 1e6
+func parseInteger(str string) (typedValue, bool) {
+	if str == "" {
+		return typedValue{}, false
+	}
+	// 0x1E is a hex number, not 1 exabyte
+	if v, ok := parseHexInteger(str); ok {
+		return v, true
+	}
+	if v, ok := parseScientificInteger(str); ok {
+		return v, true
+	}
+
+	multiplier := uint64(1)
+	if m, ok := sizeMultipliers[strings.ToUpper(str[len(str)-1:])]; ok {
+		multiplier, str = uint64(m), str[:len(str)-1]
+	}
+	if str == "" {
+		return typedValue{}, false
+	}
+
+	if strings.HasPrefix(str, "-") {
+		i, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return typedValue{}, false
+		}
+		i *= int64(multiplier)
+		return typedValue{kind: integerKind, text: strconv.FormatInt(i, 10), number: float64(i)}, true
+	}
+
+	u, err := strconv.ParseUint(strings.TrimPrefix(str, "+"), 10, 64)
+	if err != nil || u > math.MaxUint64/multiplier {
+		return typedValue{}, false
+	}
+	u *= multiplier
+	return typedValue{kind: integerKind, text: strconv.FormatUint(u, 10), number: float64(u)}, true
+}
+
+// unitSeconds are the seconds of the units of the durations
+var unitSeconds = map[string]float64{
+	"microseconds": 1e-6,
+	"milliseconds": 1e-3,
+	"seconds":      1,
+	"days":         86400,
+}
+
+// parseDuration parses a duration with units, like 500ms, 1h30m or 7d, as a
+// number of the unit of the variable, so 1h is 3600 for a variable in
+// seconds. The numbers without unit are not durations.
+func parseDuration(str, unit string) (typedValue, bool) {
+	seconds, ok := unitSeconds[unit]
+	if !ok || str == "" {
+		return typedValue{}, false
+	}
+
+	var duration float64
+	if strings.HasSuffix(str, "d") {
+		days, err := strconv.ParseFloat(str[:len(str)-1], 64)
+		if err != nil {
+			return typedValue{}, false
+		}
+		duration = days * unitSeconds["days"]
+	} else {
+		// ParseDuration accepts 0 without unit
+		last := str[len(str)-1]
+		d, err := time.ParseDuration(str)
+		if err != nil || last >= '0' && last <= '9' {
+			return typedValue{}, false
+		}
+		duration = d.Seconds()
+	}
+
+	number := duration / seconds
+	if number == math.Trunc(number) {
+		return typedValue{kind: integerKind, text: strconv.FormatFloat(number, 'f', 0, 64), number: number}, true
+	}
+	return typedValue{kind: floatKind, text: strconv.FormatFloat(number, 'f', -1, 64), number: number}, true
+}
+
+// parseHexInteger parses integers like 0x1000
+func parseHexInteger(str string) (typedValue, bool) {
+	if len(str) < 3 || str[0] != '0' || (str[1] != 'x' && str[1] != 'X') {
+		return typedValue{}, false
+	}
+	u, err := strconv.ParseUint(str[2:], 16, 64)
+	if err != nil {
+		return typedValue{}, false
+	}
+	return typedValue{kind: integerKind, text: strconv.FormatUint(u, 10), number: float64(u)}, true
+}
+
+// parseScientificInteger parses the integers in exponent notation, like 1e6
+// or 1.5E3. The E suffix alone is a size, like 2E.
+func parseScientificInteger(str string) (typedValue, bool) {
+	pos := strings.IndexAny(str, "eE")
+	if pos <= 0 || pos == len(str)-1 {
+		return typedValue{}, false
+	}
+	v, ok := parseFloat(str)
+	if !ok || v.number != math.Trunc(v.number) || math.Abs(v.number) >= 1<<63 {
+		return typedValue{}, false
+	}
+	i := int64(v.number)
+	return typedValue{kind: integerKind, text: strconv.FormatInt(i, 10), number: float64(i)}, true
+}
+
+func parseFloat(str string) (typedValue, bool) {
+	// ParseFloat also accepts NaN, Inf and hex values
+	if str == "" || strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+' && r != 'e' && r != 'E'
+	}) >= 0 {
+		return typedValue{}, false
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return typedValue{}, false
+	}
+	return typedValue{kind: floatKind, text: strconv.FormatFloat(f, 'g', -1, 64), number: f}, true
+}
+
+func parseBoolean(str string) (typedValue, bool) {
+	b, ok := booleanValues[strings.ToUpper(str)]
+	if !ok {
+		return typedValue{}, false
+	}
+	if b {
+		return typedValue{kind: booleanKind, text: "ON"}, true
+	}
+	return typedValue{kind: booleanKind, text: "OFF"}, true
+}
+
+func parseSet(str string) typedValue {
+	members := setMembers(strings.Split(str, ","))
+	sort.Strings(members)
+	return typedValue{kind: setKind, text: strings.Join(members, ",")}
+}
+
+// setMembers removes the spaces and the repeated members of a set. Members
+// like the index_merge=on of optimizer_switch are flags: the last value of a
+// flag wins, like when the server applies them, so
+// index_merge=off,index_merge=on is index_merge=on.
+func setMembers(members []string) []string {
+	result := make([]string, 0, len(members))
+	positions := make(map[string]int, len(members))
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		key := member
+		if pos := strings.Index(member, "="); pos >= 0 {
+			key = strings.TrimSpace(member[:pos])
+			member = key + "=" + strings.TrimSpace(member[pos+1:])
+		}
+		if i, ok := positions[key]; ok && key != "" {
+			result[i] = member
+			continue
+		}
+		positions[key] = len(result)
+		result = append(result, member)
+	}
+	return result
+}
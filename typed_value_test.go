@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTypedValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		value1, value2 interface{}
+		want           bool
+	}{
+		{"long_query_time", "0", "0.000000", true},
+		{"long_query_time", "10", "10.5", false},
+		{"unknown_variable", "10.0", "10", true},
+		{"unknown_variable", "10.4", "10", false},
+		{"key_buffer_size", "1G", []byte("1073741824"), true},
+		{"max_binlog_cache_size", "18446744073709551615", "18446744073709551614", false},
+		{"local_infile", "1", "ON", true},
+		{"local_infile", "true", []byte("ON"), true},
+		{"unknown_flag", "true", "ON", true},
+		{"unknown_flag", "1", "ON", false},
+		{"sql_mode", "no_zero_date,strict_trans_tables", "STRICT_TRANS_TABLES,NO_ZERO_DATE", true},
+		{"binlog_format", "row", "ROW", true},
+		{"datadir", "/var/lib/MySQL", "/var/lib/mysql", false},
+		{"optimizer_switch", "index_merge=on,mrr=off", "mrr=OFF, index_merge=ON", true},
+		{"optimizer_switch", "mrr=on,index_merge=on,mrr=off", "index_merge=on,mrr=off", true},
+		{"optimizer_switch", "mrr=off,index_merge=on", "index_merge=on,mrr=on", false},
+		{"session_track_system_variables", "time_zone,autocommit,time_zone", "autocommit,time_zone", true},
+		{"unknown_list", "a=1,b=2", "b=2,a=1", true},
+		{"max_allowed_packet", "0x4000000", "67108864", true},
+		{"max_allowed_packet", "0X1E", "30", true},
+		{"max_allowed_packet", "6.7108864e7", "64M", true},
+		{"max_connections", "1E3", "1000", true},
+		{"max_connections", "1.5e3", "1501", false},
+		{"unknown_variable", "0x10", "16", true},
+		{"unknown_variable", "2.5e-1", "0.25", true},
+		{"long_query_time", "1e1", "10", true},
+		{"long_query_time", "500ms", "0.500000", true},
+		{"long_query_time", "2s", "2.000000", true},
+		{"wait_timeout", "8h", "28800", true},
+		{"wait_timeout", "1m", "60", true},
+		{"wait_timeout", "1m", "1048576", false},
+		{"max_execution_time", "1.5s", "1500", true},
+		{"binlog_expire_logs_seconds", "30d", "2592000", true},
+		{"expire_logs_days", "168h", "7", true},
+		{"interactive_timeout", "0", "0s", true},
+	}
+
+	cmp := &comparer{}
+	for _, test := range tests {
+		if got := cmp.equal(test.name, test.value1, test.value2); got != test.want {
+			t.Errorf("%s: %#v == %#v Got: %v Want: %v", test.name, test.value1, test.value2, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// variableChange is a change made in a MySQL version to a variable that
+// affects the option files of the older versions.
+type variableChange struct {
+	Version     string   // major.minor of the first version with the change
+	Kind        string   // removed, deprecated, renamed (the old name is a deprecated alias) or semantics
+	Replacement string   // Variable to use instead, if any
+	Values      []string // Only these values are affected, if set
+	Note        string
+}
+
+// variableChanges are the variables removed, deprecated, renamed or with
+// changed semantics in 8.0 and 8.4. Values limits a change to the values (or set
+// members, like the sql_mode flags) that are affected.
+var variableChanges = map[string][]variableChange{
+	"avoid_temporal_upgrade":                 {{Version: "8.4", Kind: "removed"}},
+	"binlog_transaction_dependency_tracking": {{Version: "8.0", Kind: "deprecated"}, {Version: "8.4", Kind: "removed", Note: "WRITESET is always used."}},
+	"date_format":                            {{Version: "8.0", Kind: "removed"}},
+	"datetime_format":                        {{Version: "8.0", Kind: "removed"}},
+	"default_authentication_plugin":          {{Version: "8.0", Kind: "deprecated", Replacement: "authentication_policy"}, {Version: "8.4", Kind: "removed", Replacement: "authentication_policy", Note: "Use authentication_policy = mysql_native_password,, to keep the old plugin."}},
+	"expire_logs_days":                       {{Version: "8.0", Kind: "deprecated", Replacement: "binlog_expire_logs_seconds"}, {Version: "8.4", Kind: "removed", Replacement: "binlog_expire_logs_seconds", Note: "Multiply the days by 86400."}},
+	"group_replication_recovery_complete_at": {{Version: "8.4", Kind: "removed"}},
+	"have_crypt":                             {{Version: "8.0", Kind: "removed"}},
+	"ignore_builtin_innodb":                  {{Version: "8.0", Kind: "removed"}},
+	"innodb_file_format":                     {{Version: "8.0", Kind: "removed", Note: "Barracuda is the only file format."}},
+	"innodb_file_format_check":               {{Version: "8.0", Kind: "removed"}},
+	"innodb_file_format_max":                 {{Version: "8.0", Kind: "removed"}},
+	"innodb_large_prefix":                    {{Version: "8.0", Kind: "removed", Note: "Large index key prefixes are always enabled."}},
+	"innodb_locks_unsafe_for_binlog":         {{Version: "8.0", Kind: "removed", Note: "Use the READ COMMITTED isolation level instead."}},
+	"innodb_log_file_size":                   {{Version: "8.0", Kind: "semantics", Replacement: "innodb_redo_log_capacity", Note: "Deprecated since 8.0.30: the redo log is sized with innodb_redo_log_capacity."}},
+	"innodb_log_files_in_group":              {{Version: "8.0", Kind: "semantics", Replacement: "innodb_redo_log_capacity", Note: "Deprecated since 8.0.30: the redo log is sized with innodb_redo_log_capacity."}},
+	"innodb_support_xa":                      {{Version: "8.0", Kind: "removed", Note: "XA support is always enabled."}},
+	"innodb_undo_logs":                       {{Version: "8.0", Kind: "removed", Replacement: "innodb_rollback_segments"}},
+	"internal_tmp_disk_storage_engine":       {{Version: "8.0", Kind: "removed", Note: "InnoDB is always used for the on-disk internal temporary tables."}},
+	"log_bin_use_v1_row_events":              {{Version: "8.0", Kind: "deprecated"}, {Version: "8.4", Kind: "removed"}},
+	"log_slave_updates":                      {{Version: "8.0", Kind: "renamed", Replacement: "log_replica_updates"}},
+	"log_syslog":                             {{Version: "8.0", Kind: "removed", Note: "Load the component_log_sink_syslog component and add it to log_error_services."}},
+	"log_warnings":                           {{Version: "8.0", Kind: "removed", Replacement: "log_error_verbosity"}},
+	"master_info_repository":                 {{Version: "8.0", Kind: "deprecated"}, {Version: "8.4", Kind: "removed", Note: "The connection metadata is always stored in a table."}},
+	"max_tmp_tables":                         {{Version: "8.0", Kind: "removed"}},
+	"metadata_locks_cache_size":              {{Version: "8.0", Kind: "removed"}},
+	"metadata_locks_hash_instances":          {{Version: "8.0", Kind: "removed"}},
+	"multi_range_count":                      {{Version: "8.0", Kind: "removed"}},
+	"old_passwords":                          {{Version: "8.0", Kind: "removed"}},
+	"query_cache_limit":                      {{Version: "8.0", Kind: "removed", Note: "The query cache was removed."}},
+	"query_cache_min_res_unit":               {{Version: "8.0", Kind: "removed", Note: "The query cache was removed."}},
+	"query_cache_size":                       {{Version: "8.0", Kind: "removed", Note: "The query cache was removed."}},
+	"query_cache_type":                       {{Version: "8.0", Kind: "removed", Note: "The query cache was removed."}},
+	"query_cache_wlock_invalidate":           {{Version: "8.0", Kind: "removed", Note: "The query cache was removed."}},
+	"relay_log_info_repository":              {{Version: "8.0", Kind: "deprecated"}, {Version: "8.4", Kind: "removed", Note: "The applier metadata is always stored in a table."}},
+	"secure_auth":                            {{Version: "8.0", Kind: "removed"}},
+	"show_compatibility_56":                  {{Version: "8.0", Kind: "removed"}},
+	"show_old_temporals":                     {{Version: "8.4", Kind: "removed"}},
+	"slave_parallel_workers":                 {{Version: "8.0", Kind: "renamed", Replacement: "replica_parallel_workers"}},
+	"slave_preserve_commit_order":            {{Version: "8.0", Kind: "renamed", Replacement: "replica_preserve_commit_order"}},
+	"sql_mode":                               {{Version: "8.0", Kind: "removed", Values: []string{"NO_AUTO_CREATE_USER"}, Note: "The NO_AUTO_CREATE_USER flag was removed."}},
+	"sync_frm":                               {{Version: "8.0", Kind: "removed"}},
+	"time_format":                            {{Version: "8.0", Kind: "removed"}},
+	"transaction_write_set_extraction":       {{Version: "8.0", Kind: "deprecated"}, {Version: "8.4", Kind: "removed"}},
+	"tx_isolation":                           {{Version: "8.0", Kind: "removed", Replacement: "transaction_isolation"}},
+	"tx_read_only":                           {{Version: "8.0", Kind: "removed", Replacement: "transaction_read_only"}},
+}
+
+// upgradeFinding is a variable of a source that must or should be changed
+// before upgrading to the target version.
+type upgradeFinding struct {
+	Source   string       `json:"source"`
+	Variable string       `json:"variable"`
+	Value    string       `json:"value"`
+	Severity string       `json:"severity"` // blocker (mysqld won't start), edit or warning
+	Message  string       `json:"message"`
+	Edit     string       `json:"edit,omitempty"` // Suggested cnf line, empty to remove it
+	Origin   *entryOrigin `json:"origin,omitempty"`
+}
+
+// versionAtLeastString compares two versions with versionAtLeast, the minimum
+// given as major.minor
+func versionAtLeastString(version, minimum string) bool {
+	var major, minor int
+	if _, err := fmt.Sscanf(minimum, "%d.%d", &major, &minor); err != nil {
+		return false
+	}
+	return versionAtLeast(version, major, minor)
+}
+
+// upgradeVariableName returns the server name of a cnf option and whether it
+// is loose-, that is, ignored with a warning if the server doesn't know it
+func upgradeVariableName(key string) (string, bool) {
+	key = strings.Replace(key, "-", "_", -1)
+	if strings.HasPrefix(key, "loose_") {
+		return strings.TrimPrefix(key, "loose_"), true
+	}
+	return key, false
+}
+
+// isSetOnServer returns true if a running server has a value other than the
+// documented default, so it was set somewhere. Without a documented default we
+// cannot know it, and the variable is not reported.
+func isSetOnServer(name, version string, value interface{}) bool {
+	info, _ := getVariableInfo(name)
+	def, ok := defaultForVersion(info, version)
+	return ok && !parseTypedValue(name, def).equal(parseTypedValue(name, value))
+}
+
+// removedMembers returns the members of a set value that are in values
+func removedMembers(value string, values []string) []string {
+	var found []string
+	for _, member := range strings.Split(value, ",") {
+		for _, removed := range values {
+			if strings.EqualFold(strings.TrimSpace(member), removed) {
+				found = append(found, removed)
+			}
+		}
+	}
+	return found
+}
+
+// withoutMembers returns a set value without the given members
+func withoutMembers(value string, members []string) string {
+	var kept []string
+	for _, member := range strings.Split(value, ",") {
+		if len(removedMembers(member, members)) == 0 {
+			kept = append(kept, strings.TrimSpace(member))
+		}
+	}
+	return strings.Join(kept, ",")
+}
+
+// isRemoved returns true if the changes remove the variable in the target
+// version, not only some of its values
+func isRemoved(changes []variableChange, target string) bool {
+	for _, change := range changes {
+		if change.Kind == "removed" && len(change.Values) == 0 && versionAtLeastString(target, change.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUpgrade returns the findings of a source for the target version.
+// Changes made in versions older or equal than the version of a server are
+// not reported for it.
+func checkUpgrade(cfg configReader, target string) []upgradeFinding {
+	// The catalog only has the MySQL changes
+	if cfg.Type() == "mariadb" {
+		return nil
+	}
+
+	version := ""
+	if v, ok := cfg.Get("version"); ok && cfg.Type() == "mysql" {
+		version = valueString(v)
+	}
+
+	var findings []upgradeFinding
+	for _, key := range cfg.Keys() {
+		name, loose := upgradeVariableName(key)
+		changes, ok := variableChanges[name]
+		if !ok {
+			continue
+		}
+		raw, _ := cfg.Get(key)
+		value := valueString(raw)
+		if cfg.Type() == "mysql" && !isSetOnServer(name, version, raw) {
+			continue
+		}
+
+		removed := isRemoved(changes, target)
+		for _, change := range changes {
+			if !versionAtLeastString(target, change.Version) || (version != "" && versionAtLeastString(version, change.Version)) {
+				continue
+			}
+			// The removal is reported instead
+			if change.Kind == "deprecated" && removed {
+				continue
+			}
+
+			finding := upgradeFinding{Source: cfg.Name(), Variable: key, Value: value}
+			if origin, ok := cfg.Origin(key); ok {
+				finding.Origin = &origin
+			}
+
+			switch {
+			case len(change.Values) > 0:
+				members := removedMembers(value, change.Values)
+				if len(members) == 0 {
+					continue
+				}
+				finding.Severity = "blocker"
+				finding.Message = fmt.Sprintf("%s is not valid in %s", strings.Join(members, ","), change.Version)
+				finding.Edit = fmt.Sprintf("%s = %s", key, withoutMembers(value, members))
+			case change.Kind == "removed":
+				finding.Severity = "blocker"
+				finding.Message = fmt.Sprintf("Removed in %s", change.Version)
+				if loose {
+					finding.Severity = "edit"
+					finding.Message += ", ignored because of the loose- prefix"
+				}
+				if change.Replacement != "" {
+					finding.Message += ". Use " + change.Replacement
+					finding.Edit = fmt.Sprintf("%s = %s", strings.Replace(change.Replacement, "_", "-", -1), value)
+				}
+			case change.Kind == "deprecated":
+				finding.Severity = "warning"
+				finding.Message = fmt.Sprintf("Deprecated since %s", change.Version)
+				if change.Replacement != "" {
+					finding.Message += ". Use " + change.Replacement
+				}
+			case change.Kind == "renamed":
+				finding.Severity = "edit"
+				finding.Message = fmt.Sprintf("Deprecated alias since %s. Use %s", change.Version, change.Replacement)
+				finding.Edit = fmt.Sprintf("%s = %s", strings.Replace(change.Replacement, "_", "-", -1), value)
+			default:
+				finding.Severity = "warning"
+				finding.Message = fmt.Sprintf("Changed in %s", change.Version)
+			}
+			if change.Note != "" {
+				finding.Message += ". " + change.Note
+			}
+			if cfg.Type() != "cnf" {
+				// Only cnf lines can be edited
+				finding.Edit = ""
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Variable < findings[j].Variable })
+	return findings
+}
+
+// checkKnownVersion returns an error if the version is not one of the catalog
+func checkKnownVersion(version string) error {
+	if !isKnownVersion(version) {
+		return fmt.Errorf("Unknown version %s. Known versions are: %s", version, strings.Join(knownVersions(), ", "))
+	}
+	return nil
+}
+
+// runUpgradeCheck checks the cnf files and the servers for the variables
+// removed, renamed or with different semantics in the --target version, and
+// suggests the cnf edits needed before the upgrade.
+func runUpgradeCheck(ctx context.Context, opts *options, loadConfigs configLoader) (string, error) {
+	if opts.Target == "" {
+		return "", fmt.Errorf("upgrade-check needs --target, like --target 8.4")
+	}
+	if err := checkKnownVersion(opts.Target); err != nil {
+		return "", err
+	}
+
+	for name := range variableChanges {
+		opts.extraVariables = append(opts.extraVariables, name)
+	}
+	sort.Strings(opts.extraVariables)
+
+	configs, err := loadConfigs(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	findings := []upgradeFinding{}
+	for _, cfg := range configs {
+		findings = append(findings, checkUpgrade(cfg, opts.Target)...)
+	}
+
+	switch opts.OutputFmt {
+	case "json", "prettyJson":
+		var output []byte
+		if opts.OutputFmt == "prettyJson" {
+			output, err = json.MarshalIndent(findings, "", "\t")
+		} else {
+			output, err = json.Marshal(findings)
+		}
+		return string(output), err
+	case "plain":
+		return formatUpgradeFindings(opts.Target, findings), nil
+	default:
+		return "", fmt.Errorf("The %s output format is not available for upgrade-check", opts.OutputFmt)
+	}
+}
+
+// upgradeReport is the section of the diff output with the findings of
+// --target-version
+func upgradeReport(target string, findings []upgradeFinding) reportSection {
+	if findings == nil {
+		findings = []upgradeFinding{}
+	}
+	section := reportSection{Key: "upgrade", Title: "Removed and deprecated in " + target, Items: findings}
+	for _, f := range findings {
+		section.Lines = append(section.Lines, fmt.Sprintf("%35s: %s in %s, %s: %s", f.Variable, quoteEmpty(f.Value), f.Source, f.Severity, f.Message))
+	}
+	return section
+}
+
+func formatUpgradeFindings(target string, findings []upgradeFinding) string {
+	var buffer bytes.Buffer
+
+	blockers := 0
+	source := ""
+	for _, finding := range findings {
+		if finding.Source != source {
+			source = finding.Source
+			buffer.WriteString(fmt.Sprintf("# %s\n", source))
+		}
+		if finding.Severity == "blocker" {
+			blockers++
+		}
+
+		line := fmt.Sprintf("%-8s %s = %s: %s", strings.ToUpper(finding.Severity), finding.Variable, finding.Value, finding.Message)
+		if finding.Origin != nil {
+			line += fmt.Sprintf(" (set at %s)", finding.Origin)
+		}
+		buffer.WriteString(line + "\n")
+		if finding.Origin != nil {
+			if finding.Edit != "" {
+				buffer.WriteString(fmt.Sprintf("%8s replace with: %s\n", "", finding.Edit))
+			} else if finding.Severity != "warning" {
+				buffer.WriteString(fmt.Sprintf("%8s remove the line\n", ""))
+			}
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("%d blockers, %d findings for %s\n", blockers, len(findings), target))
+	return buffer.String()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCheckUpgrade(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf",
+		entries: map[string]interface{}{
+			"query_cache_size":         "0",
+			"loose-innodb_file_format": "Barracuda",
+			"tx-isolation":             "READ-COMMITTED",
+			"sql_mode":                 "STRICT_TRANS_TABLES,NO_AUTO_CREATE_USER",
+			"expire_logs_days":         "7",
+			"max_connections":          "500",
+		},
+		origins: map[string]entryOrigin{"tx-isolation": {File: "my.cnf", Section: "mysqld", Line: 4}},
+	}
+
+	got := checkUpgrade(cnf, "8.0")
+	want := []upgradeFinding{
+		{Source: "my.cnf", Variable: "expire_logs_days", Value: "7", Severity: "warning",
+			Message: "Deprecated since 8.0. Use binlog_expire_logs_seconds"},
+		{Source: "my.cnf", Variable: "loose-innodb_file_format", Value: "Barracuda", Severity: "edit",
+			Message: "Removed in 8.0, ignored because of the loose- prefix. Barracuda is the only file format."},
+		{Source: "my.cnf", Variable: "query_cache_size", Value: "0", Severity: "blocker",
+			Message: "Removed in 8.0. The query cache was removed."},
+		{Source: "my.cnf", Variable: "sql_mode", Value: "STRICT_TRANS_TABLES,NO_AUTO_CREATE_USER", Severity: "blocker",
+			Message: "NO_AUTO_CREATE_USER is not valid in 8.0. The NO_AUTO_CREATE_USER flag was removed.",
+			Edit:    "sql_mode = STRICT_TRANS_TABLES"},
+		{Source: "my.cnf", Variable: "tx-isolation", Value: "READ-COMMITTED", Severity: "blocker",
+			Message: "Removed in 8.0. Use transaction_isolation", Edit: "transaction-isolation = READ-COMMITTED",
+			Origin: &entryOrigin{File: "my.cnf", Section: "mysqld", Line: 4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	// expire_logs_days is removed in 8.4, only the removal is reported
+	found := 0
+	for _, finding := range checkUpgrade(cnf, "8.4") {
+		if finding.Variable == "expire_logs_days" {
+			found++
+			if finding.Severity != "blocker" {
+				t.Errorf("Got: %s  --  Want: %s\n", finding.Severity, "blocker")
+			}
+		}
+	}
+	if found != 1 {
+		t.Errorf("expire_logs_days must be reported once for 8.4, got %d findings", found)
+	}
+}
+
+func TestCheckUpgradeServer(t *testing.T) {
+	mysql57 := &config{configType: "mysql", name: "db1:3306", entries: map[string]interface{}{
+		"version":          "5.7.44-log",
+		"query_cache_size": "1048576", // The default, not set
+		"tx_isolation":     "READ-COMMITTED",
+	}}
+	mysql80 := &config{configType: "mysql", name: "db2:3306", entries: map[string]interface{}{
+		"version":      "8.0.36",
+		"tx_isolation": "READ-COMMITTED",
+	}}
+
+	got := checkUpgrade(mysql57, "8.0")
+	want := []upgradeFinding{
+		{Source: "db1:3306", Variable: "tx_isolation", Value: "READ-COMMITTED", Severity: "blocker",
+			Message: "Removed in 8.0. Use transaction_isolation"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	if got := checkUpgrade(mysql80, "8.4"); len(got) != 0 {
+		t.Errorf("Changes of 8.0 must not be reported for a 8.0 server. Got:\n%#v\n", got)
+	}
+}
+
+func TestRunUpgradeCheck(t *testing.T) {
+	cnf := &config{configType: "cnf", name: "my.cnf",
+		entries: map[string]interface{}{"query_cache_type": "0"},
+		origins: map[string]entryOrigin{"query_cache_type": {File: "my.cnf", Section: "mysqld", Line: 2}},
+	}
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf}, nil
+	}
+
+	got, err := runUpgradeCheck(context.Background(), &options{OutputFmt: "plain", Target: "8.0"}, loadConfigs)
+	if err != nil {
+		t.Errorf("Shouldn't return error: %s", err.Error())
+	}
+	want := "# my.cnf\n" +
+		"BLOCKER  query_cache_type = 0: Removed in 8.0. The query cache was removed. (set at my.cnf:2 [mysqld])\n" +
+		fmt.Sprintf("%8s remove the line\n", "") +
+		"1 blockers, 1 findings for 8.0\n"
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	if _, err := runUpgradeCheck(context.Background(), &options{OutputFmt: "plain"}, loadConfigs); err == nil {
+		t.Errorf("Should return an error without --target")
+	}
+}
+
+func TestDiffTargetVersion(t *testing.T) {
+	cnf1 := &config{configType: "cnf", name: "a.cnf", entries: map[string]interface{}{"query_cache_size": "0", "expire_logs_days": "7"}}
+	cnf2 := &config{configType: "cnf", name: "b.cnf", entries: map[string]interface{}{"query_cache_size": "0", "expire_logs_days": "3"}}
+	loadConfigs := func(ctx context.Context) ([]configReader, error) {
+		return []configReader{cnf1, cnf2}, nil
+	}
+
+	got, err := runDiff(context.Background(), &options{OutputFmt: "json", TargetVersion: "8.0"}, loadConfigs)
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	want := `{"differences":{"expire_logs_days":{"values":["7","3"],"status":"different"}},"upgrade":[` +
+		`{"source":"a.cnf","variable":"expire_logs_days","value":"7","severity":"warning","message":"Deprecated since 8.0. Use binlog_expire_logs_seconds"},` +
+		`{"source":"a.cnf","variable":"query_cache_size","value":"0","severity":"blocker","message":"Removed in 8.0. The query cache was removed."},` +
+		`{"source":"b.cnf","variable":"expire_logs_days","value":"3","severity":"warning","message":"Deprecated since 8.0. Use binlog_expire_logs_seconds"},` +
+		`{"source":"b.cnf","variable":"query_cache_size","value":"0","severity":"blocker","message":"Removed in 8.0. The query cache was removed."}]}`
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s\n", got, want)
+	}
+
+	if _, err := runDiff(context.Background(), &options{OutputFmt: "json", TargetVersion: "7.1"}, loadConfigs); err == nil {
+		t.Errorf("Should return an error on an unknown version")
+	}
+}
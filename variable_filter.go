@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// hostSpecificVariables are the variables expected to be different on every
+// host, left out with --ignore-host-specific
+var hostSpecificVariables = []string{
+	"auto_increment_offset",
+	"datadir",
+	"general_log_file",
+	"hostname",
+	"log_error",
+	"pid_file",
+	"relay_log",
+	"relay_log_basename",
+	"relay_log_index",
+	"relay_log_info_file",
+	"report_host",
+	"report_port",
+	"server_id",
+	"server_uuid",
+	"slow_query_log_file",
+	"socket",
+}
+
+// variablePattern compiles a --ignore-variables pattern. /regex/ is a regular
+// expression; anything else is a glob where * and % (like in SQL LIKE) match
+// any text and ? one character. Both match the whole name, in any case, and
+// the names are compared with _ as separator, so innodb-log-% matches the cnf
+// and server spellings.
+func variablePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid variables regex %s: %s", pattern, err.Error())
+		}
+		return re, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("(?i)^")
+	for _, c := range strings.Replace(pattern, "-", "_", -1) {
+		switch c {
+		case '*', '%':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// variableFilter matches the variables of a list of patterns
+type variableFilter []*regexp.Regexp
+
+func newVariableFilter(patterns []string) (variableFilter, error) {
+	var filter variableFilter
+	for _, pattern := range patterns {
+		for _, p := range strings.Split(pattern, ",") {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			re, err := variablePattern(p)
+			if err != nil {
+				return nil, err
+			}
+			filter = append(filter, re)
+		}
+	}
+	return filter, nil
+}
+
+// match returns true if any pattern matches the name. The renamed variables
+// match by both names, so slave_* leaves out the replica_* variables of 8.0
+// too (see variableAliases).
+func (f variableFilter) match(name string) bool {
+	name = strings.Replace(name, "-", "_", -1)
+	names := []string{name}
+	if current := variableName(name); current != canonicalName(name) {
+		names = append(names, current)
+	} else if previous, ok := previousNames[current]; ok {
+		names = append(names, previous)
+	}
+	for _, re := range f {
+		for _, n := range names {
+			if re.MatchString(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readIgnoreFile reads the patterns of an --ignore-file: one or more per
+// line, like --ignore-variables, with # comments
+func readIgnoreFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if pos := strings.Index(line, "#"); pos >= 0 {
+			line = line[:pos]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, scanner.Err()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestVariableFilters(t *testing.T) {
+	filter, err := newVariableFilter([]string{"wsrep_%,report_host", "/^innodb_.*_size$/", "log-?in"})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	tests := map[string]bool{
+		"wsrep_cluster_name":      true,
+		"wsrep-node-address":      true,
+		"report_host":             true,
+		"report-host":             true,
+		"report_port":             false,
+		"innodb_buffer_pool_size": true,
+		"innodb_flush_method":     false,
+		"log_bin":                 true,
+		"log_bin_index":           false,
+	}
+	for name, want := range tests {
+		if got := filter.match(name); got != want {
+			t.Errorf("%s -- Got: %v  --  Want: %v\n", name, got, want)
+		}
+	}
+
+	cmp, err := newComparer(&options{IgnoreVariables: []string{"report_*"}})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	configs := []configReader{
+		&config{configType: "cnf", entries: map[string]interface{}{"report_host": "db1", "max_connections": "500"}},
+		&config{configType: "cnf", entries: map[string]interface{}{"report_host": "db2", "max_connections": "1000"}},
+	}
+	want := map[string][]interface{}{"max_connections": {"500", "1000"}}
+	if got := cmp.compare(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+	if fingerprint(configs[0], cmp) != fingerprint(&config{entries: map[string]interface{}{"max_connections": "500"}}, cmp) {
+		t.Error("The ignored variables should not change the fingerprint")
+	}
+
+	cmp, err = newComparer(&options{Variables: []string{"max_%,report_*"}, IgnoreVariables: []string{"report_host"}})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	configs[0].(*config).entries["sync_binlog"] = "1"
+	if got := cmp.compare(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+
+	ignoreFile := filepath.Join(t.TempDir(), "ignore.txt")
+	ioutil.WriteFile(ignoreFile, []byte("# Host specific\nreport_host  # set by puppet\n\nserver_id, server_uuid\n"), 0644)
+	cmp, err = newComparer(&options{IgnoreFiles: []string{ignoreFile}})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+	for name, want := range map[string]bool{"report_host": false, "server_uuid": false, "max_connections": true} {
+		if got := cmp.compared(name); got != want {
+			t.Errorf("%s -- Got: %v  --  Want: %v\n", name, got, want)
+		}
+	}
+
+	cmp, _ = newComparer(&options{IgnoreHostSpecific: true})
+	for name, want := range map[string]bool{"server-id": false, "pid_file": false, "max_connections": true} {
+		if got := cmp.compared(name); got != want {
+			t.Errorf("%s -- Got: %v  --  Want: %v\n", name, got, want)
+		}
+	}
+
+	if _, err := newVariableFilter([]string{"/(/"}); err == nil {
+		t.Error("Should return error on invalid regexes")
+	}
+}
+
+func TestVariableFiltersRenamedVariables(t *testing.T) {
+	mysql57 := &config{configType: "mysql", entries: map[string]interface{}{"slave_parallel_workers": "4", "max_connections": "500"}}
+	mysql80 := &config{configType: "mysql", entries: map[string]interface{}{"replica_parallel_workers": "8", "max_connections": "1000"}}
+	want := map[string][]interface{}{"max_connections": {"500", "1000"}}
+
+	for _, pattern := range []string{"slave_*", "replica_*"} {
+		cmp, err := newComparer(&options{IgnoreVariables: []string{pattern}})
+		if err != nil {
+			t.Fatalf("Shouldn't return error: %s", err.Error())
+		}
+		if got := cmp.compare([]configReader{mysql57, mysql80}); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s -- Got:\n%#v\nWant:\n%#v\n", pattern, got, want)
+		}
+	}
+
+	cmp, _ := newComparer(&options{Variables: []string{"slave_parallel_workers"}})
+	want = map[string][]interface{}{"replica_parallel_workers": {"4", "8"}}
+	if got := cmp.compare([]configReader{mysql57, mysql80}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", got, want)
+	}
+}
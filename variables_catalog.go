@@ -0,0 +1,868 @@
+package main
+
+// variablesMetadata is a catalog of well known variables. Variables not
+// listed here are considered dynamic.
+// Defaults are keyed by major.minor version. A missing version means the
+// variable does not exist in that version or its default depends on the
+// system.
+var variablesMetadata = map[string]variableInfo{
+	"audit_log_exclude_accounts": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Accounts not audited. Cannot be used with audit_log_include_accounts.",
+	},
+	"audit_log_exclude_commands": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Command types not audited (Percona audit log plugin).",
+	},
+	"audit_log_exclude_databases": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Databases not audited (Percona audit log plugin).",
+	},
+	"audit_log_file": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Name of the file where the audit log is written.",
+	},
+	"audit_log_format": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Format of the audit log file: OLD, NEW, JSON or CSV.",
+	},
+	"audit_log_include_accounts": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Accounts audited. Cannot be used with audit_log_exclude_accounts.",
+	},
+	"audit_log_include_commands": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Command types audited (Percona audit log plugin).",
+	},
+	"audit_log_include_databases": {
+		Type:        "list",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Databases audited (Percona audit log plugin).",
+	},
+	"audit_log_policy": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Events logged: ALL, LOGINS, QUERIES or NONE.",
+	},
+	"audit_log_strategy": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "How the audit log is written: ASYNCHRONOUS, PERFORMANCE, SEMISYNCHRONOUS or SYNCHRONOUS.",
+	},
+	"auto_increment_increment": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Interval between successive AUTO_INCREMENT values.",
+		Defaults:    map[string]string{"5.7": "1", "8.0": "1", "8.4": "1"},
+	},
+	"auto_increment_offset": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Starting point for AUTO_INCREMENT values.",
+		Defaults:    map[string]string{"5.7": "1", "8.0": "1", "8.4": "1"},
+	},
+	"basedir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Path to the MySQL installation base directory.",
+	},
+	"bind_address": {
+		Type:        "string",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Addresses the server listens on for TCP/IP connections.",
+		Defaults:    map[string]string{"5.7": "*", "8.0": "*", "8.4": "*"},
+	},
+	"binlog_cache_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Memory per connection to hold the changes of a transaction for the binary log.",
+		Defaults:    map[string]string{"5.7": "32768", "8.0": "32768", "8.4": "32768"},
+	},
+	"binlog_expire_logs_seconds": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Binary log expiration period in seconds.",
+		Defaults:    map[string]string{"8.0": "2592000", "8.4": "2592000"},
+		Unit:        "seconds",
+	},
+	"binlog_format": {
+		Type:        "enumeration",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Binary logging format: ROW, STATEMENT or MIXED.",
+		Defaults:    map[string]string{"5.7": "ROW", "8.0": "ROW", "8.4": "ROW"},
+	},
+	"binlog_transaction_dependency_tracking": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "How the source computes the dependencies used by multithreaded replicas.",
+		Defaults:    map[string]string{"5.7": "COMMIT_ORDER", "8.0": "COMMIT_ORDER"},
+	},
+	"character_set_server": {
+		Type:        "string",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Default server character set.",
+		Defaults:    map[string]string{"5.7": "latin1", "8.0": "utf8mb4", "8.4": "utf8mb4"},
+	},
+	"character_sets_dir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory where the character sets are installed.",
+	},
+	"collation_server": {
+		Type:        "string",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Default server collation.",
+		Defaults:    map[string]string{"5.7": "latin1_swedish_ci", "8.0": "utf8mb4_0900_ai_ci", "8.4": "utf8mb4_0900_ai_ci"},
+	},
+	"connect_timeout": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Seconds the server waits for the connect packet.",
+		Defaults:    map[string]string{"5.7": "10", "8.0": "10", "8.4": "10"},
+		Unit:        "seconds",
+	},
+	"datadir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Path to the data directory.",
+	},
+	"default_authentication_plugin": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Default plugin for new accounts.",
+		Defaults:    map[string]string{"5.7": "mysql_native_password", "8.0": "caching_sha2_password"},
+	},
+	"default_password_lifetime": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Days after which the passwords expire. 0 means they never expire.",
+		Defaults:    map[string]string{"5.7": "0", "8.0": "0", "8.4": "0"},
+	},
+	"event_scheduler": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether the Event Scheduler is running.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "ON", "8.4": "ON"},
+	},
+	"expire_logs_days": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Days before binary logs are removed automatically.",
+		Defaults:    map[string]string{"5.7": "0", "8.0": "0"},
+		Unit:        "days",
+	},
+	"explicit_defaults_for_timestamp": {
+		Type:        "boolean",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Standard behavior for TIMESTAMP columns defaults and NULL handling.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "ON", "8.4": "ON"},
+	},
+	"general_log_file": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Name of the general query log file.",
+	},
+	"gtid_mode": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether GTID based logging is enabled.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"host_cache_size": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Size of the host cache. -1 means autosized.",
+		Defaults:    map[string]string{"5.7": "-1", "8.0": "-1", "8.4": "-1"},
+		AutoSize:    "-1",
+	},
+	"innodb_adaptive_hash_index": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether the InnoDB adaptive hash index is enabled.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "ON", "8.4": "OFF"},
+	},
+	"innodb_autoinc_lock_mode": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Lock mode used to generate AUTO_INCREMENT values.",
+		Defaults:    map[string]string{"5.7": "1", "8.0": "2", "8.4": "2"},
+	},
+	"innodb_buffer_pool_chunk_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Size of the chunks the InnoDB buffer pool is resized by. The buffer pool size is a multiple of it times the instances.",
+		Defaults:    map[string]string{"5.7": "134217728", "8.0": "134217728", "8.4": "134217728"},
+	},
+	"innodb_buffer_pool_instances": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Number of regions the InnoDB buffer pool is divided into.",
+	},
+	"innodb_buffer_pool_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Size in bytes of the InnoDB buffer pool.",
+		Defaults:    map[string]string{"5.7": "134217728", "8.0": "134217728", "8.4": "134217728"},
+	},
+	"innodb_change_buffering": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Operations buffered by the InnoDB change buffer.",
+		Defaults:    map[string]string{"5.7": "all", "8.0": "all", "8.4": "none"},
+	},
+	"innodb_data_file_path": {
+		Type:        "string",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Paths and sizes of the InnoDB system tablespace files.",
+		Defaults:    map[string]string{"5.7": "ibdata1:12M:autoextend", "8.0": "ibdata1:12M:autoextend", "8.4": "ibdata1:12M:autoextend"},
+	},
+	"innodb_data_home_dir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory of the InnoDB system tablespace data files.",
+	},
+	"innodb_doublewrite": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether the InnoDB doublewrite buffer is enabled.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "ON", "8.4": "ON"},
+	},
+	"innodb_file_format": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "InnoDB file format for file-per-table tablespaces.",
+		Defaults:    map[string]string{"5.7": "Barracuda"},
+	},
+	"innodb_file_per_table": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether InnoDB tables are created in their own tablespace.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "ON", "8.4": "ON"},
+	},
+	"innodb_flush_log_at_trx_commit": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "When the InnoDB log is written and flushed to disk: 1 is full ACID compliance.",
+		Defaults:    map[string]string{"5.7": "1", "8.0": "1", "8.4": "1"},
+	},
+	"innodb_flush_method": {
+		Type:             "enumeration",
+		Scope:            "global",
+		Dynamic:          false,
+		Description:      "Method used to flush data to the InnoDB data and log files.",
+		Defaults:         map[string]string{"5.7": "", "8.0": "fsync", "8.4": "O_DIRECT"},
+		PlatformDefaults: map[string]string{"linux": "fsync", "darwin": "fsync", "windows": "unbuffered"},
+	},
+	"innodb_io_capacity": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "I/O operations per second available to InnoDB background tasks.",
+		Defaults:    map[string]string{"5.7": "200", "8.0": "200", "8.4": "10000"},
+	},
+	"innodb_lock_wait_timeout": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Seconds a transaction waits for a row lock before giving up.",
+		Defaults:    map[string]string{"5.7": "50", "8.0": "50", "8.4": "50"},
+		Unit:        "seconds",
+	},
+	"innodb_log_buffer_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Size in bytes of the InnoDB log buffer.",
+		Defaults:    map[string]string{"5.7": "16777216", "8.0": "16777216", "8.4": "67108864"},
+	},
+	"innodb_log_file_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Size in bytes of each InnoDB redo log file.",
+		Defaults:    map[string]string{"5.7": "50331648", "8.0": "50331648", "8.4": "50331648"},
+	},
+	"innodb_log_files_in_group": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Number of InnoDB redo log files.",
+		Defaults:    map[string]string{"5.7": "2", "8.0": "2", "8.4": "2"},
+	},
+	"innodb_log_group_home_dir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory of the InnoDB redo log files.",
+	},
+	"innodb_open_files": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Maximum number of .ibd files InnoDB can keep open. -1 means autosized.",
+		Defaults:    map[string]string{"5.7": "-1", "8.0": "-1", "8.4": "-1"},
+		AutoSize:    "-1",
+	},
+	"innodb_page_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Page size of all InnoDB tablespaces.",
+		Defaults:    map[string]string{"5.7": "16384", "8.0": "16384", "8.4": "16384"},
+	},
+	"innodb_read_io_threads": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Number of InnoDB I/O threads for read operations.",
+		Defaults:    map[string]string{"5.7": "4", "8.0": "4"},
+	},
+	"innodb_redo_log_capacity": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Disk space occupied by the InnoDB redo log files (8.0.30+).",
+		Defaults:    map[string]string{"8.0": "104857600", "8.4": "104857600"},
+	},
+	"innodb_thread_concurrency": {
+		Type:          "integer",
+		Scope:         "global",
+		Dynamic:       true,
+		Description:   "Maximum number of threads inside InnoDB. 0 means no limit.",
+		Defaults:      map[string]string{"5.7": "0", "8.0": "0", "8.4": "0"},
+		Unlimited:     true,
+		ZeroUnlimited: true,
+	},
+	"innodb_undo_directory": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory of the InnoDB undo tablespaces.",
+	},
+	"innodb_write_io_threads": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Number of InnoDB I/O threads for write operations.",
+		Defaults:    map[string]string{"5.7": "4", "8.0": "4", "8.4": "4"},
+	},
+	"interactive_timeout": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Seconds the server waits for activity on an interactive connection before closing it.",
+		Defaults:    map[string]string{"5.7": "28800", "8.0": "28800", "8.4": "28800"},
+		Unit:        "seconds",
+	},
+	"join_buffer_size": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Memory per join without indexes, allocated by each connection.",
+		Defaults:    map[string]string{"5.7": "262144", "8.0": "262144", "8.4": "262144"},
+	},
+	"key_buffer_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Size in bytes of the MyISAM index blocks buffer.",
+		Defaults:    map[string]string{"5.7": "8388608", "8.0": "8388608", "8.4": "8388608"},
+	},
+	"lc_messages_dir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory where the error messages are located.",
+	},
+	"local_infile": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether LOAD DATA LOCAL is allowed.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"lock_wait_timeout": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Seconds a statement waits for a metadata lock before giving up.",
+		Defaults:    map[string]string{"5.7": "31536000", "8.0": "31536000", "8.4": "31536000"},
+		Unit:        "seconds",
+	},
+	"log_bin": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether binary logging is enabled.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "ON", "8.4": "ON"},
+	},
+	"log_bin_trust_function_creators": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether stored functions can be created without SUPER when binary logging is on.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"log_error": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Error log destination.",
+	},
+	"log_error_verbosity": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Messages written to the error log: 1 errors, 2 also warnings, 3 also notes.",
+		Defaults:    map[string]string{"5.7": "3", "8.0": "2", "8.4": "2"},
+	},
+	"log_output": {
+		Type:        "set",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Destination of the general and slow query logs: FILE, TABLE or NONE.",
+		Defaults:    map[string]string{"5.7": "FILE", "8.0": "FILE", "8.4": "FILE"},
+	},
+	"log_raw": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether the general log has the statements as received, without rewriting the passwords.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"log_slave_updates": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether a replica writes the replicated updates to its own binary log.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "ON"},
+	},
+	"long_query_time": {
+		Type:        "numeric",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Seconds after which a query is written to the slow query log.",
+		Defaults:    map[string]string{"5.7": "10.000000", "8.0": "10.000000", "8.4": "10.000000"},
+		Unit:        "seconds",
+	},
+	"lower_case_table_names": {
+		Type:             "integer",
+		Scope:            "global",
+		Dynamic:          false,
+		Description:      "How table and database names are stored and compared.",
+		PlatformDefaults: map[string]string{"linux": "0", "darwin": "2", "windows": "1"},
+	},
+	"master_info_repository": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Where the replica stores its connection metadata.",
+		Defaults:    map[string]string{"5.7": "FILE", "8.0": "TABLE"},
+	},
+	"max_allowed_packet": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Maximum size of one packet or any generated string.",
+		Defaults:    map[string]string{"5.7": "4194304", "8.0": "67108864", "8.4": "67108864"},
+	},
+	"max_binlog_cache_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Maximum memory a multi-statement transaction can use for the binary log.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"max_binlog_stmt_cache_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Maximum memory for nontransactional statements in the binary log cache.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"max_connections": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Maximum number of simultaneous client connections.",
+		Defaults:    map[string]string{"5.7": "151", "8.0": "151", "8.4": "151"},
+	},
+	"max_execution_time": {
+		Type:          "integer",
+		Scope:         "both",
+		Dynamic:       true,
+		Description:   "Execution timeout for SELECT statements in milliseconds. 0 means no timeout.",
+		Defaults:      map[string]string{"5.7": "0", "8.0": "0", "8.4": "0"},
+		Unlimited:     true,
+		ZeroUnlimited: true,
+		Unit:          "milliseconds",
+	},
+	"max_join_size": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Maximum number of rows a join may examine.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"max_seeks_for_key": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Limit of key seeks assumed by the optimizer.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"max_user_connections": {
+		Type:          "integer",
+		Scope:         "both",
+		Dynamic:       true,
+		Description:   "Maximum simultaneous connections per account. 0 means no limit.",
+		Defaults:      map[string]string{"5.7": "0", "8.0": "0", "8.4": "0"},
+		Unlimited:     true,
+		ZeroUnlimited: true,
+	},
+	"max_write_lock_count": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Write locks after which pending read locks are allowed.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"myisam_max_sort_file_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Maximum size of the temporary file used to re-create a MyISAM index.",
+		Defaults:    map[string]string{"5.7": "9223372036853727232", "8.0": "9223372036853727232", "8.4": "9223372036853727232"},
+		Unlimited:   true,
+	},
+	"open_files_limit": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Number of file descriptors available to mysqld. Computed from other settings.",
+		AutoSize:    "0",
+	},
+	"optimizer_switch": {
+		Type:        "set",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Optimizer flags, as flag=on|off|default pairs. Flags not listed keep their value.",
+	},
+	"performance_schema": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether the Performance Schema is enabled.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "ON", "8.4": "ON"},
+	},
+	"pid_file": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Path of the process ID file.",
+	},
+	"plugin_dir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory of the plugins.",
+	},
+	"port": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "TCP/IP port the server listens on.",
+		Defaults:    map[string]string{"5.7": "3306", "8.0": "3306", "8.4": "3306"},
+	},
+	"query_cache_size": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Memory allocated for the query cache.",
+		Defaults:    map[string]string{"5.7": "1048576"},
+	},
+	"query_cache_type": {
+		Type:        "enumeration",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Query cache mode.",
+		Defaults:    map[string]string{"5.7": "OFF"},
+	},
+	"read_buffer_size": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Memory per sequential scan of MyISAM tables, allocated by each connection.",
+		Defaults:    map[string]string{"5.7": "131072", "8.0": "131072", "8.4": "131072"},
+	},
+	"read_only": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether clients without privileges can modify data.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"read_rnd_buffer_size": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Memory to read the rows in sorted order after a sort, allocated by each connection.",
+		Defaults:    map[string]string{"5.7": "262144", "8.0": "262144", "8.4": "262144"},
+	},
+	"relay_log": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Base name of the relay log files.",
+	},
+	"relay_log_info_repository": {
+		Type:        "enumeration",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Where the replica stores its applier metadata.",
+		Defaults:    map[string]string{"5.7": "FILE", "8.0": "TABLE"},
+	},
+	"require_secure_transport": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether the connections must use TLS or a socket.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"secure_file_priv": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory for import and export operations. Empty means no restriction.",
+	},
+	"server_id": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Server ID used in replication topologies.",
+		Defaults:    map[string]string{"5.7": "0", "8.0": "1", "8.4": "1"},
+	},
+	"session_track_system_variables": {
+		Type:        "set",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Variables whose changes are notified to the clients. * tracks all of them.",
+	},
+	"skip_name_resolve": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether host names are resolved when checking client connections.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"slave_parallel_workers": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Number of applier threads for parallel replication.",
+		Defaults:    map[string]string{"5.7": "0", "8.0": "4"},
+	},
+	"slave_preserve_commit_order": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether parallel replicas commit in the source order.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "ON"},
+	},
+	"slow_query_log": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether the slow query log is enabled.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"slow_query_log_file": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Name of the slow query log file.",
+	},
+	"socket": {
+		Type:        "file",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Unix socket file used for local connections.",
+		Defaults:    map[string]string{"5.7": "/tmp/mysql.sock", "8.0": "/tmp/mysql.sock", "8.4": "/tmp/mysql.sock"},
+	},
+	"sort_buffer_size": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Memory per sort, allocated by each connection.",
+		Defaults:    map[string]string{"5.7": "262144", "8.0": "262144", "8.4": "262144"},
+	},
+	"sql_mode": {
+		Type:        "set",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "SQL modes that change the syntax and semantic checks of the server.",
+		Defaults:    map[string]string{"5.7": "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION", "8.0": "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION", "8.4": "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION"},
+	},
+	"sql_select_limit": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Maximum number of rows returned by SELECT statements.",
+		Defaults:    map[string]string{"5.7": "18446744073709551615", "8.0": "18446744073709551615", "8.4": "18446744073709551615"},
+		Unlimited:   true,
+	},
+	"super_read_only": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Whether even users with SUPER can modify data.",
+		Defaults:    map[string]string{"5.7": "OFF", "8.0": "OFF", "8.4": "OFF"},
+	},
+	"symbolic_links": {
+		Type:        "boolean",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Whether symbolic links for MyISAM tables are supported.",
+		Defaults:    map[string]string{"5.7": "ON", "8.0": "OFF"},
+	},
+	"sync_binlog": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "How often the binary log is synchronized to disk. 1 is the safest value.",
+		Defaults:    map[string]string{"5.7": "1", "8.0": "1", "8.4": "1"},
+	},
+	"table_definition_cache": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Number of table definitions that can be cached. -1 means autosized.",
+		Defaults:    map[string]string{"5.7": "-1", "8.0": "-1", "8.4": "-1"},
+		AutoSize:    "-1",
+	},
+	"table_open_cache": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Number of open tables for all threads.",
+		Defaults:    map[string]string{"5.7": "2000", "8.0": "4000", "8.4": "4000"},
+	},
+	"thread_cache_size": {
+		Type:        "integer",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "Number of threads cached for reuse. -1 means autosized.",
+		Defaults:    map[string]string{"5.7": "-1", "8.0": "-1", "8.4": "-1"},
+		AutoSize:    "-1",
+	},
+	"thread_stack": {
+		Type:        "size",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Stack size of every connection thread.",
+		Defaults:    map[string]string{"5.7": "262144", "8.0": "1048576", "8.4": "1048576"},
+	},
+	"tls_version": {
+		Type:        "set",
+		Scope:       "global",
+		Dynamic:     true,
+		Description: "TLS protocols allowed for the encrypted connections.",
+		Defaults:    map[string]string{"5.7": "TLSv1,TLSv1.1,TLSv1.2", "8.0": "TLSv1.2,TLSv1.3", "8.4": "TLSv1.2,TLSv1.3"},
+	},
+	"tmp_table_size": {
+		Type:        "size",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Maximum size of the internal in-memory temporary tables.",
+		Defaults:    map[string]string{"5.7": "16777216", "8.0": "16777216", "8.4": "16777216"},
+	},
+	"tmpdir": {
+		Type:        "directory",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "Directory used for temporary files.",
+	},
+	"transaction_isolation": {
+		Type:        "enumeration",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Default transaction isolation level.",
+		Defaults:    map[string]string{"5.7": "REPEATABLE-READ", "8.0": "REPEATABLE-READ", "8.4": "REPEATABLE-READ"},
+	},
+	"tx_isolation": {
+		Type:        "enumeration",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Default transaction isolation level (replaced by transaction_isolation).",
+		Defaults:    map[string]string{"5.7": "REPEATABLE-READ"},
+	},
+	"user": {
+		Type:        "string",
+		Scope:       "global",
+		Dynamic:     false,
+		Description: "OS user mysqld runs as.",
+	},
+	"wait_timeout": {
+		Type:        "integer",
+		Scope:       "both",
+		Dynamic:     true,
+		Description: "Seconds the server waits for activity on a connection before closing it.",
+		Defaults:    map[string]string{"5.7": "28800", "8.0": "28800", "8.4": "28800"},
+		Unit:        "seconds",
+	},
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// variablesQueries has the queries used to read all the server variables for
+// each --scope and --variables-query-source. Every query must return 2
+// columns: the variable name (lowercase, as SHOW VARIABLES returns it) and its
+// value.
+var variablesQueries = map[string]map[string]string{
+	"global": {
+		"show":               "SHOW GLOBAL VARIABLES",
+		"performance_schema": "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_variables",
+		"information_schema": "SELECT LOWER(VARIABLE_NAME), VARIABLE_VALUE FROM information_schema.GLOBAL_VARIABLES",
+	},
+	// The values of the session of the tool, which start as the global ones
+	// but can be changed by init_connect or the dsn parameters
+	"session": {
+		"show":               "SHOW SESSION VARIABLES",
+		"performance_schema": "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.session_variables",
+		"information_schema": "SELECT LOWER(VARIABLE_NAME), VARIABLE_VALUE FROM information_schema.SESSION_VARIABLES",
+	},
+}
+
+// variablesInfoQuery reads the variables with where their values come from
+// (MySQL 8.0+), for the variables_info query source
+func variablesInfoQuery(scope string) string {
+	return "SELECT g.VARIABLE_NAME, g.VARIABLE_VALUE, i.VARIABLE_SOURCE, i.VARIABLE_PATH, i.SET_TIME, i.SET_USER, i.SET_HOST " +
+		"FROM performance_schema." + scope + "_variables g JOIN performance_schema.variables_info i USING (VARIABLE_NAME)"
+}
+
+var validVariableName = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+const (
+	errUnknownSystemVariable = 1193
+	errIncorrectGlobalLocal  = 1238
+)
+
+// readSelectedVariables reads the wanted variables one by one using
+// SELECT @@GLOBAL.<name> (or @@SESSION.<name>), for environments where SHOW VARIABLES and the
+// variables tables are restricted or mangled by a proxy.
+// Entries are stored using the wanted names so they match the cnf keys.
+// Variables unknown to the server are skipped, the same way SHOW VARIABLES
+// wouldn't return them.
+func readSelectedVariables(ctx context.Context, db *sql.DB, scope string, wanted []string, entries map[string]interface{}) error {
+	for _, key := range wanted {
+		name := strings.Replace(key, "-", "_", -1)
+		if !validVariableName.MatchString(name) {
+			continue
+		}
+
+		var val interface{}
+		err := db.QueryRowContext(ctx, "SELECT @@"+strings.ToUpper(scope)+"."+name).Scan(&val)
+		if err != nil {
+			if myErr, ok := err.(*mysql.MySQLError); ok &&
+				(myErr.Number == errUnknownSystemVariable || myErr.Number == errIncorrectGlobalLocal) {
+				continue
+			}
+			return err
+		}
+
+		entries[key] = val
+	}
+
+	return nil
+}
+
+// readVariablesInfo reads the variables and, for the ones not using their
+// compiled default, the origin of their values: the option file, the
+// persisted variables, the command line or who set them at runtime and when.
+func readVariablesInfo(ctx context.Context, db *sql.DB, scope string, cfg *config) error {
+	rows, err := db.QueryContext(ctx, variablesInfoQuery(scope))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cfg.origins = make(map[string]entryOrigin)
+	for rows.Next() {
+		var key string
+		var val interface{}
+		var source, path, setTime, setUser, setHost sql.NullString
+		if err := rows.Scan(&key, &val, &source, &path, &setTime, &setUser, &setHost); err != nil {
+			continue
+		}
+
+		cfg.entries[key] = val
+		if source.String == "" || source.String == "COMPILED" {
+			continue
+		}
+		origin := entryOrigin{File: path.String, Source: source.String, SetTime: setTime.String}
+		if setUser.String != "" {
+			origin.SetUser = setUser.String + "@" + setHost.String
+		}
+		cfg.origins[key] = origin
+	}
+	return rows.Err()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestReadVariablesInfo(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	columns := []string{"VARIABLE_NAME", "VARIABLE_VALUE", "VARIABLE_SOURCE", "VARIABLE_PATH", "SET_TIME", "SET_USER", "SET_HOST"}
+	mock.ExpectQuery(regexp.QuoteMeta(variablesInfoQuery("global"))).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("autocommit", "ON", "COMPILED", "", nil, nil, nil).
+		AddRow("max_connections", "500", "GLOBAL", "/etc/my.cnf", nil, nil, nil).
+		AddRow("long_query_time", "0.5", "DYNAMIC", "", "2024-03-01 10:00:00.000000", "dba", "localhost"))
+
+	cnf, err := newMySQLReader(context.Background(), db, "mock", mysqlReadOptions{QuerySource: "variables_info"})
+	if err != nil {
+		t.Fatalf("Shouldn't return error: %s", err.Error())
+	}
+
+	want := &config{
+		configType: "mysql",
+		name:       "mock",
+		entries:    map[string]interface{}{"autocommit": "ON", "max_connections": "500", "long_query_time": "0.5"},
+		origins: map[string]entryOrigin{
+			"max_connections": {File: "/etc/my.cnf", Source: "GLOBAL"},
+			"long_query_time": {Source: "DYNAMIC", SetTime: "2024-03-01 10:00:00.000000", SetUser: "dba@localhost"},
+		},
+	}
+	if !reflect.DeepEqual(cnf, want) {
+		t.Errorf("Got:\n%#v\nWant:\n%#v\n", cnf, want)
+	}
+
+	origin, _ := cnf.Origin("long_query_time")
+	if got := origin.String(); got != "DYNAMIC by dba@localhost at 2024-03-01 10:00:00.000000" {
+		t.Errorf("Got: %s  --  Want: DYNAMIC by dba@localhost at 2024-03-01 10:00:00.000000\n", got)
+	}
+}
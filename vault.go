@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// vaultPasswordPrefix marks the dsn passwords stored in Vault, like
+// user:vault:secret/mysql/prod#password@tcp(db1:3306)/
+const vaultPasswordPrefix = "vault:"
+
+// vaultSecrets reads the passwords from Vault with the vault cli, so
+// VAULT_ADDR, VAULT_TOKEN and the usual auth methods are used. Every secret
+// is read only once. It is safe for concurrent use.
+type vaultSecrets struct {
+	runCommand commandRunner
+	mu         sync.Mutex
+	cache      map[string]string
+}
+
+func newVaultSecrets(runCommand commandRunner) *vaultSecrets {
+	return &vaultSecrets{runCommand: runCommand, cache: make(map[string]string)}
+}
+
+// get reads a path#field reference. The field is password if not set.
+func (v *vaultSecrets) get(ctx context.Context, ref string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if value, ok := v.cache[ref]; ok {
+		return value, nil
+	}
+
+	path, field := ref, "password"
+	if pos := strings.LastIndex(ref, "#"); pos >= 0 {
+		path, field = ref[:pos], ref[pos+1:]
+	}
+	if path == "" || field == "" {
+		return "", fmt.Errorf("Invalid Vault secret %s. Use vault:path#field", ref)
+	}
+
+	output, err := v.runCommand(ctx, "vault", "kv", "get", "-field="+field, path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(string(output), "\r\n")
+	v.cache[ref] = value
+
+	return value, nil
+}
+
+// vaultDSN replaces a vault: password of the dsn with the secret
+func (v *vaultSecrets) vaultDSN(ctx context.Context, dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(cfg.Passwd, vaultPasswordPrefix) {
+		return dsn, nil
+	}
+
+	ref := strings.TrimPrefix(cfg.Passwd, vaultPasswordPrefix)
+	if cfg.Passwd, err = v.get(ctx, ref); err != nil {
+		return "", fmt.Errorf("Cannot read the password of %s@%s from Vault: %s", cfg.User, cfg.Addr, err.Error())
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+// vaultDBConnector wraps a dbConnector so the vault: passwords are read from
+// Vault, with ctx, before connecting
+func vaultDBConnector(ctx context.Context, secrets *vaultSecrets, dbConnector func(string) (*sql.DB, error)) func(string) (*sql.DB, error) {
+	return func(dsn string) (*sql.DB, error) {
+		vaultDSN, err := secrets.vaultDSN(ctx, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return dbConnector(vaultDSN)
+	}
+}
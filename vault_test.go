@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVaultDSN(t *testing.T) {
+	calls := 0
+	runCommand := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		if got := name + " " + strings.Join(args, " "); got != "vault kv get -field=password secret/mysql/prod" {
+			t.Errorf("Unexpected command: %s", got)
+		}
+		return []byte("s3cr3t\n"), nil
+	}
+	secrets := newVaultSecrets(runCommand)
+
+	tests := map[string]string{
+		"monitor:vault:secret/mysql/prod#password@tcp(db1:3306)/": "monitor:s3cr3t@tcp(db1:3306)/",
+		"monitor:vault:secret/mysql/prod@tcp(db2:3306)/":          "monitor:s3cr3t@tcp(db2:3306)/",
+		"monitor:plain@tcp(db3:3306)/":                            "monitor:plain@tcp(db3:3306)/",
+	}
+	for dsn, want := range tests {
+		got, err := secrets.vaultDSN(context.Background(), dsn)
+		if err != nil {
+			t.Errorf("%s -- Shouldn't return error: %s", dsn, err.Error())
+		}
+		if got != want {
+			t.Errorf("Got: %s  --  Want: %s\n", got, want)
+		}
+	}
+
+	// The secret is cached by reference
+	if calls != 2 {
+		t.Errorf("Got: %d calls  --  Want: 2\n", calls)
+	}
+}